@@ -15,12 +15,13 @@ var crawlCmd = &cobra.Command{
 	Short: "Downloads topics linked from a topic to doc dir",
 	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		l := schema.NewLookup(args[0], flagLang)
-		opts := schema.FetchOptions{}
-		opts.Debug, _ = cmd.Flags().GetBool("debug")
-		ctx := context.Background()
+		l, err := schema.NewLookup(args[0], flagLang)
+		fatal(err)
+		opts := fetchOptions(cmd)
+		ctx, cancel := schema.WithBrowserContext(context.Background())
+		defer cancel()
 		if !l.DocExists() {
-			schema.FetchTopic(ctx, l, opts)
+			fatal(fetchTopic(ctx, l, opts))
 		}
 		t, err := schema.ReadTopic(l)
 		fatal(err)
@@ -32,9 +33,9 @@ var crawlCmd = &cobra.Command{
 				// TODO: check last fetch, version
 				continue
 			}
-			tt := schema.FetchTopic(ctx, ll, fetchOptions(cmd))
-			fatal(writeTopic(ll, tt))
-			fmt.Fprintf(os.Stderr, "   %s [%s]\n", ll.DocPath, time.Since(tt.LastFetch))
+			start := time.Now()
+			fatal(fetchTopic(ctx, ll, opts))
+			fmt.Fprintf(os.Stderr, "   %s [%s]\n", ll.DocPath, time.Since(start))
 		}
 	},
 }
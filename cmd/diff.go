@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/progrium/macschema/schema"
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagDiffFormat string
+	flagDiffFailOn string
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <old-api-dir> <new-api-dir>",
+	Short: "List API changes between the schemas in two api dirs",
+	Long: `List the classes, protocols, members, enum cases and other declarations added,
+removed or changed between the Objective-C schemas in two api dirs, like those
+pulled for two SDK versions. Changes to types, nullability and deprecation are
+found by comparing the schemas, not their text. Changes that break code using
+bindings generated from the old schemas are labeled source-breaking or
+binary-breaking, and --fail-on exits with status 1 if there are any that break
+as much as the level given.`,
+	Example: `  macschema diff old/api api
+  macschema diff --format markdown old/api api > CHANGES.md
+  macschema diff --fail-on source-breaking old/api api`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		var level schema.Compatibility
+		if flagDiffFailOn != "" {
+			var err error
+			level, err = schema.ParseCompatibility(flagDiffFailOn)
+			fatal(err)
+		}
+		old, err := schema.ReadSchemas(args[0])
+		fatal(err)
+		new, err := schema.ReadSchemas(args[1])
+		fatal(err)
+		changes := schema.Diff(old, new)
+		fatal(printChanges(os.Stdout, changes, flagDiffFormat))
+		if level == "" {
+			return
+		}
+		var breaking int
+		for _, c := range changes {
+			if c.Compatibility.AtLeast(level) {
+				breaking++
+			}
+		}
+		if breaking > 0 {
+			fmt.Fprintf(os.Stderr, "=> %d of %d changes are %s or worse\n", breaking, len(changes), level)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	diffCmd.Flags().StringVar(&flagDiffFormat, "format", "text", "output format: text, json or markdown")
+	diffCmd.Flags().StringVar(&flagDiffFailOn, "fail-on", "", "exit with status 1 on changes that are source-breaking or binary-breaking")
+}
+
+func printChanges(w io.Writer, changes []schema.Change, format string) error {
+	switch format {
+	case "text":
+		for _, c := range changes {
+			fmt.Fprintf(w, "%s%s\n", c, breakingLabel(c, " (%s)"))
+		}
+	case "json":
+		if changes == nil {
+			changes = []schema.Change{}
+		}
+		b, err := json.MarshalIndent(changes, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, string(b))
+	case "markdown":
+		for _, kind := range []schema.ChangeKind{schema.ChangeAdded, schema.ChangeRemoved, schema.ChangeChanged} {
+			var section []schema.Change
+			for _, c := range changes {
+				if c.Kind == kind {
+					section = append(section, c)
+				}
+			}
+			if len(section) == 0 {
+				continue
+			}
+			fmt.Fprintf(w, "## %s\n\n", map[schema.ChangeKind]string{
+				schema.ChangeAdded:   "Added",
+				schema.ChangeRemoved: "Removed",
+				schema.ChangeChanged: "Changed",
+			}[kind])
+			for _, c := range section {
+				fmt.Fprintf(w, "- %s `%s`", c.Element, c.Symbol)
+				if kind == schema.ChangeChanged {
+					aspect := c.Aspect
+					if c.Arg != "" {
+						aspect += " of `" + c.Arg + "`"
+					}
+					fmt.Fprintf(w, ": %s `%s` → `%s`", aspect, c.Old, c.New)
+				}
+				fmt.Fprintf(w, "%s\n", breakingLabel(c, " (**%s**)"))
+			}
+			fmt.Fprintln(w)
+		}
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+	return nil
+}
+
+// breakingLabel returns the compatibility of a breaking change in format,
+// or nothing for additive changes.
+func breakingLabel(c schema.Change, format string) string {
+	if c.Compatibility == schema.Additive {
+		return ""
+	}
+	return fmt.Sprintf(format, c.Compatibility)
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -25,7 +26,8 @@ var fetchCmd = &cobra.Command{
 	Short: "Download a topic to doc dir",
 	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		l := schema.NewLookup(args[0], flagLang)
+		l, err := schema.NewLookup(args[0], flagLang)
+		fatal(err)
 
 		if l.DocExists() && flagShow {
 			b, err := ioutil.ReadFile(l.DocPath)
@@ -34,13 +36,25 @@ var fetchCmd = &cobra.Command{
 			return
 		}
 
-		ctx := context.Background()
-		t := schema.FetchTopic(ctx, l, fetchOptions(cmd))
-		fatal(writeTopic(l, t))
-		fmt.Fprintf(os.Stderr, "=> %s [%s]\n", l.DocPath, time.Since(t.LastFetch))
+		start := time.Now()
+		fatal(fetchTopic(context.Background(), l, fetchOptions(cmd)))
+		fmt.Fprintf(os.Stderr, "=> %s [%s]\n", l.DocPath, time.Since(start))
 	},
 }
 
+// fetchTopic fetches and writes the topic for a lookup. Topics with missing
+// fields are still written, with a warning; other errors are returned.
+func fetchTopic(ctx context.Context, l schema.Lookup, opts schema.FetchOptions) error {
+	t, err := schema.FetchTopic(ctx, l, opts)
+	var fetchErr *schema.FetchError
+	if errors.As(err, &fetchErr) {
+		fmt.Fprintln(os.Stderr, "warning:", err)
+	} else if err != nil {
+		return err
+	}
+	return writeTopic(l, t)
+}
+
 func writeTopic(l schema.Lookup, t schema.Topic) error {
 	b, err := json.MarshalIndent(t, "", "  ")
 	if err != nil {
@@ -0,0 +1,214 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/progrium/macschema/gen"
+	"github.com/progrium/macschema/schema"
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagGenOut      string
+	flagGenArch     string
+	flagGenEncoding string
+	flagGenMap      string
+	flagGenPackage  string
+	flagGenRustTmpl string
+	flagGenTemplate string
+	flagGenTarget   string
+)
+
+var genCmd = &cobra.Command{
+	Use:   "gen [path...]",
+	Short: "Generate bindings from schemas in api dir",
+	Long: `Generate bindings from the objc schemas of topics or directories in api, or
+all of api, with a built-in generator or the text/templates in a directory.
+Each template whose name doesn't start with _ is executed with every schema,
+and its output for NSWindow from go.tmpl is nswindow.go in the --out
+directory, or stdout. Templates have helper funcs, listed in the docs of
+gen.Funcs, and map types to the --target language.`,
+	Example: `  macschema gen --template templates/ --out appkit/ appkit
+  macschema gen --template templates/ --target rust appkit/nswindow`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if flagGenTemplate == "" {
+			fatal(cmd.Help())
+			return
+		}
+		schemas, err := readSchemas(args)
+		fatal(err)
+		types, err := newTypeMap(flagGenTarget, flagGenMap)
+		fatal(err)
+		t, err := gen.ParseTemplates(flagGenTemplate, types)
+		fatal(err)
+		files, err := t.Generate(schemas)
+		fatal(err)
+		fatal(writeFiles(files))
+	},
+}
+
+var genGoCmd = &cobra.Command{
+	Use:   "go [path...]",
+	Short: "Generate Go bindings",
+	Long: `Generate Go bindings from the objc schemas of topics or directories in api,
+or all of api. Messages are sent through the Runtime interface declared in
+the generated package.`,
+	Example: `  macschema gen go --package appkit appkit
+  macschema gen go --out window.go appkit/nswindow appkit/nswindowstylemask`,
+	Run: func(cmd *cobra.Command, args []string) {
+		schemas, err := readSchemas(args)
+		fatal(err)
+		enc, err := newEncoding(flagGenArch, flagGenEncoding)
+		fatal(err)
+		types, err := newTypeMap("go", flagGenMap)
+		fatal(err)
+
+		g := &gen.Go{Package: flagGenPackage, Encoding: enc, Types: types}
+		src, err := g.Generate(schemas)
+		fatal(err)
+		for _, s := range g.Skipped {
+			fmt.Fprintln(os.Stderr, "skipped", s)
+		}
+		fatal(writeOutput(src))
+	},
+}
+
+var genTSCmd = &cobra.Command{
+	Use:   "ts [path...]",
+	Short: "Generate TypeScript declarations",
+	Long: `Generate TypeScript declarations (.d.ts) from the objc schemas of topics or
+directories in api, or all of api, for APIs used through a JavaScript
+bridge. Methods are named after their selector with colons replaced by
+underscores.`,
+	Example: `  macschema gen ts --out appkit.d.ts appkit
+  macschema gen ts appkit/nswindow appkit/nswindowdelegate`,
+	Run: func(cmd *cobra.Command, args []string) {
+		schemas, err := readSchemas(args)
+		fatal(err)
+		enc, err := newEncoding(flagGenArch, flagGenEncoding)
+		fatal(err)
+		types, err := newTypeMap("ts", flagGenMap)
+		fatal(err)
+
+		g := &gen.TypeScript{Encoding: enc, Types: types}
+		src, err := g.Generate(schemas)
+		fatal(err)
+		for _, s := range g.Skipped {
+			fmt.Fprintln(os.Stderr, "skipped", s)
+		}
+		fatal(writeOutput(src))
+	},
+}
+
+var genRustCmd = &cobra.Command{
+	Use:   "rust [path...]",
+	Short: "Generate Rust bindings for the objc2 crates",
+	Long: `Generate Rust bindings in the style of the objc2 crates from the objc schemas
+of topics or directories in api, or all of api. The bindings are rendered
+with a text/template, which can be replaced with --template to tune them.`,
+	Example: `  macschema gen rust --out appkit.rs appkit
+  macschema gen rust --template rust.tmpl appkit/nswindow`,
+	Run: func(cmd *cobra.Command, args []string) {
+		schemas, err := readSchemas(args)
+		fatal(err)
+		enc, err := newEncoding(flagGenArch, flagGenEncoding)
+		fatal(err)
+		types, err := newTypeMap("rust", flagGenMap)
+		fatal(err)
+
+		g := &gen.Rust{Encoding: enc, Types: types}
+		if flagGenRustTmpl != "" {
+			b, err := ioutil.ReadFile(flagGenRustTmpl)
+			fatal(err)
+			g.Template = string(b)
+		}
+		src, err := g.Generate(schemas)
+		fatal(err)
+		for _, s := range g.Skipped {
+			fmt.Fprintln(os.Stderr, "skipped", s)
+		}
+		fatal(writeOutput(src))
+	},
+}
+
+func init() {
+	genCmd.AddCommand(genGoCmd)
+	genCmd.AddCommand(genTSCmd)
+	genCmd.AddCommand(genRustCmd)
+
+	genCmd.PersistentFlags().StringVarP(&flagGenOut, "out", "o", "", "write to a file, or directory with --template, instead of stdout")
+	genCmd.PersistentFlags().StringVar(&flagGenArch, "arch", "arm64", "architecture of type encodings: arm64 or x86_64")
+	genCmd.PersistentFlags().StringVar(&flagGenEncoding, "encoding", "", "JSON file with more types for type encodings")
+	genCmd.PersistentFlags().StringVar(&flagGenMap, "map", "", "YAML or JSON file with more target types")
+	genGoCmd.Flags().StringVar(&flagGenPackage, "package", "macos", "name of the generated package")
+	genRustCmd.Flags().StringVar(&flagGenRustTmpl, "template", "", "text/template file to render the bindings with")
+	genCmd.Flags().StringVar(&flagGenTemplate, "template", "", "directory of text/templates to generate code with")
+	genCmd.Flags().StringVar(&flagGenTarget, "target", "go", "target language of types in templates: go, rust or ts")
+}
+
+// readSchemas reads the objc schemas of topics, or of all topics in
+// directories of api. With no paths it reads all of api.
+func readSchemas(paths []string) ([]schema.Schema, error) {
+	if len(paths) == 0 {
+		return schema.ReadSchemas("./api")
+	}
+	var schemas []schema.Schema
+	for _, p := range paths {
+		if dir := filepath.Join("./api", p); isDir(dir) {
+			s, err := schema.ReadSchemas(dir)
+			if err != nil {
+				return nil, err
+			}
+			schemas = append(schemas, s...)
+			continue
+		}
+		l, err := schema.NewLookup(p, "objc")
+		if err != nil {
+			return nil, err
+		}
+		s, err := schema.ReadSchema(l)
+		if err != nil {
+			return nil, err
+		}
+		schemas = append(schemas, s)
+	}
+	return schemas, nil
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+func writeOutput(b []byte) error {
+	if flagGenOut == "" {
+		_, err := os.Stdout.Write(b)
+		return err
+	}
+	return ioutil.WriteFile(flagGenOut, b, 0644)
+}
+
+// writeFiles writes generated files to the output directory, or one after
+// the other to stdout.
+func writeFiles(files []gen.File) error {
+	if flagGenOut != "" {
+		if err := os.MkdirAll(flagGenOut, 0755); err != nil {
+			return err
+		}
+	}
+	for _, f := range files {
+		if flagGenOut == "" {
+			if _, err := os.Stdout.Write(f.Src); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := ioutil.WriteFile(filepath.Join(flagGenOut, f.Name), f.Src, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/progrium/macschema/schema"
+	"github.com/spf13/cobra"
+)
+
+var mergeCmd = &cobra.Command{
+	Use:   "merge",
+	Short: "Merge the objc and swift schemas of a topic into one",
+	Long: `Merge the objc and swift schemas of a topic, pulled with --lang objc and
+--lang swift, into name.json next to them. Members get their Swift view
+and name, aligned by topic and NS_SWIFT_NAME.`,
+	Example: "  macschema merge appkit/nswindow",
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		l, err := schema.NewLookup(args[0], "objc")
+		fatal(err)
+		objc, err := schema.ReadSchema(l)
+		fatal(err)
+		ls, err := schema.NewLookup(args[0], "swift")
+		fatal(err)
+		swift, err := schema.ReadSchema(ls)
+		fatal(err)
+
+		s, err := schema.Merge(objc, swift)
+		fatal(err)
+		l.APIPath = strings.TrimSuffix(l.APIPath, ".objc.json") + ".json"
+		fatal(writeSchema(l, s))
+		fmt.Fprintf(os.Stderr, "=> %s\n", l.APIPath)
+	},
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/progrium/macschema/schema"
+	"github.com/spf13/cobra"
+)
+
+var flagMigrateDryRun bool
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate [path...]",
+	Short: "Upgrade files in api and doc dirs written by older versions",
+	Long: `Upgrade the JSON files in api and doc, or in the directories and files given,
+that were written by an older version of macschema. Files are upgraded one
+version at a time. Schemas too old to upgrade are pulled again from their
+topics in doc when they exist; other files are listed and must be fetched,
+pulled or merged again. Exits with status 1 if any file can't be upgraded.`,
+	Example: `  macschema migrate --dry-run
+  macschema migrate api/appkit`,
+	Run: func(cmd *cobra.Command, args []string) {
+		explicit := len(args) > 0
+		if !explicit {
+			args = []string{"./api", "./doc"}
+		}
+		var migrated, failed int
+		for _, arg := range args {
+			if _, err := os.Stat(arg); os.IsNotExist(err) && !explicit {
+				continue
+			}
+			err := filepath.Walk(arg, func(p string, info os.FileInfo, err error) error {
+				if err != nil || info.IsDir() || !strings.HasSuffix(p, ".json") {
+					return err
+				}
+				ok, err := migrateFile(p)
+				switch {
+				case err != nil:
+					failed++
+					fmt.Printf("%s: %v\n", p, err)
+				case ok:
+					migrated++
+				}
+				return nil
+			})
+			fatal(err)
+		}
+		fmt.Fprintf(os.Stderr, "=> %d files migrated, %d failed\n", migrated, failed)
+		if failed > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	migrateCmd.Flags().BoolVar(&flagMigrateDryRun, "dry-run", false, "list the files to upgrade without writing them")
+}
+
+// migrateFile upgrades a topic or schema file, returning whether it was
+// of an older version.
+func migrateFile(p string) (bool, error) {
+	b, err := ioutil.ReadFile(p)
+	if err != nil {
+		return false, err
+	}
+	var v interface{}
+	var migrated bool
+	if isDoc(p) {
+		var t schema.Topic
+		migrated, err = schema.UnmarshalTopic(b, &t)
+		v = t
+	} else {
+		var s schema.Schema
+		migrated, err = schema.UnmarshalSchema(b, &s)
+		var verr *schema.VersionError
+		if errors.As(err, &verr) && verr.Version < schema.Version {
+			s, err = repullSchema(p, err)
+			migrated = err == nil
+		}
+		v = s
+	}
+	if err != nil || !migrated {
+		return false, err
+	}
+	fmt.Fprintln(os.Stderr, "  ", p)
+	if flagMigrateDryRun {
+		return true, nil
+	}
+	b, err = json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return false, err
+	}
+	return true, ioutil.WriteFile(p, b, 0644)
+}
+
+// repullSchema pulls the schema at path p in an api dir again from its
+// topic, or returns err if it or the topics it links to aren't in doc.
+func repullSchema(p string, err error) (schema.Schema, error) {
+	parts := strings.Split(filepath.ToSlash(p), "/")
+	for i := len(parts) - 2; i >= 0; i-- {
+		if parts[i] != "api" {
+			continue
+		}
+		name := strings.Split(parts[len(parts)-1], ".")
+		if len(name) != 3 {
+			break // a merged schema
+		}
+		l, lerr := schema.NewLookup(strings.Join(append(parts[i+1:len(parts)-1], name[0]), "/"), name[1])
+		if lerr != nil || !l.DocExists() {
+			break
+		}
+		t, terr := schema.ReadTopic(l)
+		if terr != nil {
+			return schema.Schema{}, terr
+		}
+		for _, link := range t.Topics {
+			if !schema.LookupFromPath(link.Path).DocExists() {
+				return schema.Schema{}, fmt.Errorf("%w, pull it again to fetch %s", err, link.Path)
+			}
+		}
+		return schema.PullSchema(l, schema.NewEncoding("arm64")), nil
+	}
+	return schema.Schema{}, fmt.Errorf("%w, pull it again", err)
+}
@@ -0,0 +1,412 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/progrium/macschema/declparse"
+	"github.com/progrium/macschema/declparse/preprocessor"
+	"github.com/progrium/macschema/lexer"
+	"github.com/progrium/macschema/swiftparse"
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagParseHint   string
+	flagParseFormat string
+	flagParseFile   string
+	flagParseInc    []string
+	flagParseDefs   []string
+	flagParseOnlyPP bool
+)
+
+var parseCmd = &cobra.Command{
+	Use:   "parse [declaration]",
+	Short: "Parse declarations from args, a header file or stdin",
+	Example: `  macschema parse --format tree -- "- (void)orderFront:(id)sender;"
+  macschema parse --hint function "CFTypeID CGEventGetTypeID(void);"
+  macschema parse -f NSWindow.h
+  macschema parse -I ./Headers -D TARGET_OS_IPHONE=1 -f ./Headers/AppKit/NSWindow.h
+  macschema parse --lang swift "func orderFront(_ sender: Any?)"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if flagLang == "swift" {
+			parseSwift(args)
+			return
+		}
+
+		hint, err := declparse.ParseHint(flagParseHint)
+		fatal(err)
+
+		var src string
+		switch {
+		case len(args) > 0:
+			src = strings.Join(args, " ")
+		case flagParseFile != "":
+			src, err = preprocess(flagParseFile, nil)
+			fatal(err)
+		default:
+			src, err = preprocess("<stdin>", os.Stdin)
+			fatal(err)
+		}
+		if flagParseOnlyPP {
+			fmt.Print(src)
+			return
+		}
+
+		if !parseDecls(os.Stdout, src, hint, flagParseFormat) {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	parseCmd.Flags().StringVar(&flagParseHint, "hint", "none", "parser hint: variable, enumcase or function")
+	parseCmd.Flags().StringVar(&flagParseFormat, "format", "string", "output format: json, string or tree")
+	parseCmd.Flags().StringVarP(&flagParseFile, "file", "f", "", "parse declarations in a header file")
+	parseCmd.Flags().StringSliceVarP(&flagParseInc, "include", "I", nil, "add a directory to the include search path")
+	parseCmd.Flags().StringArrayVarP(&flagParseDefs, "define", "D", nil, "define a macro as NAME, NAME=value or F(x)=body")
+	parseCmd.Flags().BoolVarP(&flagParseOnlyPP, "preprocess", "E", false, "print preprocessed source instead of parsing")
+}
+
+// parseDecls parses the declarations in src and prints them to w. It
+// reports declarations that don't parse and returns false if there were
+// any.
+func parseDecls(w io.Writer, src string, hint declparse.Hint, format string) bool {
+	ok := true
+	var assumeNonnull bool
+	for _, decl := range splitDecls(src) {
+		stmt, err := parseDecl(decl, hint, &assumeNonnull)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n  => %s\n", decl, err)
+			ok = false
+			continue
+		}
+		fatal(printStatement(w, stmt, format))
+	}
+	return ok
+}
+
+// parseDecl parses a declaration split from a header, tracking whether it
+// is in an assume_nonnull region. Without a hint, declarations that start
+// with a type name, as in extern NSString *const NSBazKey; or
+// NSRect NSInsetRect(NSRect rect, CGFloat dx, CGFloat dy);, are parsed as a
+// function if they declare one and as a variable otherwise.
+func parseDecl(decl string, hint declparse.Hint, assumeNonnull *bool) (stmt *declparse.Statement, err error) {
+	hints := []declparse.Hint{hint}
+	if hint == declparse.HintNone && startsWithType(decl) {
+		hints = []declparse.Hint{declparse.HintFunction, declparse.HintVariable}
+	}
+	nonnull := *assumeNonnull
+	for _, h := range hints {
+		p := declparse.NewStringParser(decl)
+		p.Hint = h
+		p.AssumeNonnull = nonnull
+		stmt, err = p.Parse()
+		*assumeNonnull = p.AssumeNonnull
+		if err == nil {
+			return stmt, nil
+		}
+	}
+	return nil, err
+}
+
+// startsWithType returns true if the first token of a declaration, after
+// any pragmas and storage classes, is a name other than NS_SWIFT_NAME.
+func startsWithType(decl string) bool {
+	sc := lexer.NewScanner(strings.NewReader(decl))
+	for {
+		tok, _, lit := sc.Scan()
+		switch tok {
+		case lexer.WS, lexer.COMMENT, lexer.DIRECTIVE,
+			lexer.AUTO, lexer.EXTERN, lexer.INLINE, lexer.REGISTER, lexer.STATIC, lexer.THREADLOCAL:
+			continue
+		case lexer.IDENT:
+			return lit != "NS_SWIFT_NAME" && lit != "CF_SWIFT_NAME"
+		}
+		return false
+	}
+}
+
+// parseSwift parses Swift declarations from args, a file or stdin. Swift
+// isn't preprocessed, and the parser reads one declaration after another.
+func parseSwift(args []string) {
+	var src string
+	switch {
+	case len(args) > 0:
+		src = strings.Join(args, " ")
+	case flagParseFile != "":
+		b, err := ioutil.ReadFile(flagParseFile)
+		fatal(err)
+		src = string(b)
+	default:
+		b, err := ioutil.ReadAll(os.Stdin)
+		fatal(err)
+		src = string(b)
+	}
+	p := swiftparse.NewStringParser(src)
+	for {
+		stmt, err := p.Parse()
+		if err == io.EOF {
+			return
+		}
+		fatal(err)
+		fatal(printStatement(os.Stdout, stmt, flagParseFormat))
+	}
+}
+
+// preprocess runs a header from a file, or from r if it isn't nil, through
+// the preprocessor. Includes that can't be found are reported and skipped.
+func preprocess(name string, r io.Reader) (string, error) {
+	pp := preprocessor.New()
+	pp.IncludePath = flagParseInc
+	for _, def := range flagParseDefs {
+		if err := pp.Define(def); err != nil {
+			return "", err
+		}
+	}
+	var src string
+	var err error
+	if r != nil {
+		src, err = pp.Preprocess(name, r)
+	} else {
+		src, err = pp.PreprocessFile(name)
+	}
+	for _, inc := range pp.Unresolved {
+		fmt.Fprintf(os.Stderr, "%s: include not found: %s\n", name, inc)
+	}
+	return src, err
+}
+
+// splitDecls splits source into declarations on semicolons outside of
+// braces. It works on tokens, so semicolons and braces in comments and
+// string literals don't count. Container headers like @interface end at
+// the end of their line once any protocol list is closed, instance
+// variable blocks are dropped, and so are keywords like @end, @class and
+// forward @protocol declarations, which declare nothing to parse. Function
+// bodies are replaced by a semicolon so definitions parse as prototypes.
+// A comment on the line after a declaration belongs to it and is dropped.
+// Pragmas are kept in front of the next declaration for the parser to
+// track assume_nonnull regions.
+func splitDecls(src string) []string {
+	var decls []string
+	var b strings.Builder
+	var pragmas string
+	var (
+		depth    int         // open braces
+		header   bool        // in an @interface, @protocol or @implementation header
+		angles   int         // open protocol lists in the header
+		last     lexer.Token // last token other than whitespace and comments
+		trailing bool        // on the line of a declaration that ended
+		skip     int         // depth of braces being skipped, if any
+		body     bool        // the skipped braces are a function body
+		forward  bool        // in an @class declaration
+	)
+	flush := func() {
+		decl := strings.TrimSpace(b.String())
+		if last != lexer.ILLEGAL && decl != "" {
+			decls = append(decls, pragmas+decl)
+			pragmas = ""
+		}
+		b.Reset()
+		header, angles, last = false, 0, lexer.ILLEGAL
+	}
+	last = lexer.ILLEGAL
+
+	sc := lexer.NewScanner(strings.NewReader(src))
+	for {
+		tok, _, lit := sc.Scan()
+		if tok == lexer.EOF {
+			break
+		}
+		if tok == lexer.WS && strings.Contains(lit, "\n") {
+			trailing = false
+		} else if tok == lexer.COMMENT && trailing {
+			continue
+		}
+
+		if skip > 0 {
+			switch tok {
+			case lexer.LCURLY:
+				skip++
+			case lexer.RCURLY:
+				if skip--; skip == 0 && body {
+					decl := strings.TrimSpace(b.String())
+					b.Reset()
+					b.WriteString(decl + ";")
+					flush()
+					trailing = true
+				}
+			}
+			continue
+		}
+		if forward {
+			forward = tok != lexer.SEMICOLON
+			trailing = !forward
+			continue
+		}
+
+		if depth == 0 {
+			switch tok {
+			case lexer.DIRECTIVE:
+				flush()
+				if strings.HasPrefix(lit, "#pragma") {
+					pragmas += lit + "\n"
+				}
+				continue
+			case lexer.END, lexer.OPTIONAL, lexer.REQUIRED, lexer.PUBLIC, lexer.PRIVATE, lexer.PROTECTED, lexer.PACKAGE:
+				flush()
+				trailing = true
+				continue
+			case lexer.CLASS:
+				flush()
+				forward = true
+				continue
+			case lexer.INTERFACE, lexer.PROTOCOL, lexer.IMPLEMENTATION:
+				if last != lexer.ILLEGAL && !isSwiftNameAnnotation(b.String()) {
+					flush()
+				}
+				header = true
+			case lexer.LCURLY:
+				if header || last == lexer.RPAREN {
+					// instance variables or a function body
+					skip, body = 1, !header
+					if header {
+						flush()
+					}
+					continue
+				}
+			case lexer.SEMICOLON:
+				if header {
+					// forward @protocol declaration
+					b.Reset()
+					last = lexer.ILLEGAL
+					flush()
+				} else {
+					b.WriteString(";")
+					flush()
+				}
+				trailing = true
+				continue
+			case lexer.WS:
+				if header && angles <= 0 && strings.Contains(lit, "\n") {
+					flush()
+					continue
+				}
+			}
+		}
+
+		b.WriteString(tokenText(tok, lit))
+		switch tok {
+		case lexer.WS, lexer.COMMENT:
+			continue
+		case lexer.LCURLY:
+			depth++
+		case lexer.RCURLY:
+			depth--
+		case lexer.LT:
+			angles++
+		case lexer.GT:
+			angles--
+		}
+		last = tok
+	}
+	flush()
+	return decls
+}
+
+// isSwiftNameAnnotation returns true for source that is only an
+// NS_SWIFT_NAME annotation, which leads the container after it.
+func isSwiftNameAnnotation(src string) bool {
+	src = strings.TrimSpace(src)
+	return (strings.HasPrefix(src, "NS_SWIFT_NAME(") || strings.HasPrefix(src, "CF_SWIFT_NAME(")) &&
+		strings.Count(src, "(") == strings.Count(src, ")") && strings.HasSuffix(src, ")")
+}
+
+// tokenText returns the source text of a token.
+func tokenText(tok lexer.Token, lit string) string {
+	quote := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	switch tok {
+	case lexer.STRING:
+		return `"` + quote.Replace(lit) + `"`
+	case lexer.ATSTRING:
+		return `@"` + quote.Replace(lit) + `"`
+	case lexer.CHAR:
+		return "'" + lit + "'"
+	case lexer.HASH:
+		return "#"
+	}
+	if lit == "" {
+		return tok.String()
+	}
+	return lit
+}
+
+// printStatement prints a declparse or swiftparse statement.
+func printStatement(w io.Writer, stmt fmt.Stringer, format string) error {
+	switch format {
+	case "json":
+		b, err := json.MarshalIndent(stmt, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(b))
+		return err
+	case "string":
+		_, err := fmt.Fprintln(w, stmt.String())
+		return err
+	case "tree":
+		printTree(w, "Statement", reflect.ValueOf(stmt), 0)
+		return nil
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
+
+// printTree prints the non-zero fields of an AST node as an indented tree.
+func printTree(w io.Writer, name string, v reflect.Value, depth int) {
+	indent := strings.Repeat("  ", depth)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		fmt.Fprintf(w, "%s%s\n", indent, name)
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Field(i)
+			if f.IsZero() || (f.Kind() == reflect.Map || f.Kind() == reflect.Slice) && f.Len() == 0 {
+				continue
+			}
+			printTree(w, v.Type().Field(i).Name, f, depth+1)
+		}
+	case reflect.Slice:
+		fmt.Fprintf(w, "%s%s\n", indent, name)
+		for i := 0; i < v.Len(); i++ {
+			printTree(w, fmt.Sprintf("[%d]", i), v.Index(i), depth+1)
+		}
+	case reflect.Map:
+		var keys []string
+		for _, k := range v.MapKeys() {
+			val := fmt.Sprint(v.MapIndex(k).Interface())
+			if val == "" || val == "true" {
+				keys = append(keys, fmt.Sprint(k.Interface()))
+			} else if val != "false" {
+				keys = append(keys, fmt.Sprintf("%s=%s", k.Interface(), val))
+			}
+		}
+		sort.Strings(keys)
+		fmt.Fprintf(w, "%s%s: %s\n", indent, name, strings.Join(keys, ", "))
+	case reflect.String:
+		fmt.Fprintf(w, "%s%s: %q\n", indent, name, v.String())
+	default:
+		fmt.Fprintf(w, "%s%s: %v\n", indent, name, v.Interface())
+	}
+}
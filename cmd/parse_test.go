@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/progrium/macschema/declparse"
+)
+
+func TestSplitDecls(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []string
+	}{
+		{
+			src: "/// Returns a thing; never nil.\n- (id)thing;\n- (void)setThing:(id)thing; // or {nil}\n",
+			want: []string{
+				"/// Returns a thing; never nil.\n- (id)thing;",
+				"- (void)setThing:(id)thing;",
+			},
+		},
+		{
+			src: "@class NSView, NSWindow;\n@protocol NSCopying;\n/** A window. */\n@interface NSWindow : NSResponder <NSCoding,\n    NSUserInterfaceValidations>\n@property (copy) NSString *title;\n@end",
+			want: []string{
+				"/** A window. */\n@interface NSWindow : NSResponder <NSCoding,\n    NSUserInterfaceValidations>",
+				"@property (copy) NSString *title;",
+			},
+		},
+		{
+			src: "@interface NSObject {\n    Class isa;\n}\n+ (instancetype)new;\n@end\n@protocol NSCoding\n@required\n- (void)encodeWithCoder:(NSCoder *)coder;\n@end",
+			want: []string{
+				"@interface NSObject",
+				"+ (instancetype)new;",
+				"@protocol NSCoding",
+				"- (void)encodeWithCoder:(NSCoder *)coder;",
+			},
+		},
+		{
+			src: "NSString *const NSSeparator = @\"; }\";\nconst char NSBrace = '{';\ntypedef enum NSState : NSInteger {\n    NSOff = 0,\n    NSOn = 1,\n} NSState;",
+			want: []string{
+				"NSString *const NSSeparator = @\"; }\";",
+				"const char NSBrace = '{';",
+				"typedef enum NSState : NSInteger {\n    NSOff = 0,\n    NSOn = 1,\n} NSState;",
+			},
+		},
+		{
+			src: "#pragma clang assume_nonnull begin\nstatic inline CGPoint CGPointMake(CGFloat x, CGFloat y) {\n    CGPoint p; p.x = x; p.y = y; return p;\n}\n#pragma clang assume_nonnull end\nextern NSString *NSName;",
+			want: []string{
+				"#pragma clang assume_nonnull begin\nstatic inline CGPoint CGPointMake(CGFloat x, CGFloat y);",
+				"#pragma clang assume_nonnull end\nextern NSString *NSName;",
+			},
+		},
+	}
+	for _, tt := range tests {
+		got := splitDecls(tt.src)
+		if strings.Join(got, "\n---\n") != strings.Join(tt.want, "\n---\n") {
+			t.Errorf("%q:\ngot:\n%s\nwant:\n%s", tt.src, strings.Join(got, "\n---\n"), strings.Join(tt.want, "\n---\n"))
+		}
+	}
+}
+
+func TestParseDecls_Header(t *testing.T) {
+	src, err := preprocess("testdata/NSBaz.h", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if !parseDecls(&buf, src, declparse.HintNone, "string") {
+		t.Fatal("declarations failed to parse")
+	}
+	want := []string{
+		"@interface NSBaz : NSObject",
+		"@property(copy) NSString *name;",
+		"- (instancetype)initWithName:(NSString *)name;",
+		"NSString *const NSBazKey;",
+		"void (*NSBazHook)(NSBaz *baz);",
+		"NSBaz *NSBazMake(NSString *name);",
+	}
+	if got := strings.TrimSpace(buf.String()); got != strings.Join(want, "\n") {
+		t.Errorf("got:\n%s\nwant:\n%s", got, strings.Join(want, "\n"))
+	}
+
+	buf.Reset()
+	parseDecls(&buf, src, declparse.HintNone, "json")
+	if strings.Count(buf.String(), `"Variable"`) != 2 || strings.Count(buf.String(), `"Function"`) != 1 {
+		t.Errorf("expected two variables and a function:\n%s", buf.String())
+	}
+}
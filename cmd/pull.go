@@ -11,6 +11,7 @@ import (
 
 	"github.com/progrium/macschema/schema"
 	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
 	"golang.org/x/sync/semaphore"
 )
 
@@ -22,42 +23,69 @@ var pullCmd = &cobra.Command{
 		start := time.Now()
 		ctx, cancel := schema.WithBrowserContext(context.Background())
 		defer cancel()
-		l := schema.NewLookup(args[0], flagLang)
+		opts := fetchOptions(cmd)
+		l, err := schema.NewLookup(args[0], flagLang)
+		fatal(err)
 		if !l.DocExists() {
 			fmt.Fprintln(os.Stderr, "=> Fetching topic...")
-			t := schema.FetchTopic(ctx, l, fetchOptions(cmd))
-			fatal(writeTopic(l, t))
+			fatal(fetchTopic(ctx, l, opts))
 		}
 		t, err := schema.ReadTopic(l)
 		fatal(err)
 
 		fmt.Fprintln(os.Stderr, "=> Fetching sub-topics...")
+		g, gctx := errgroup.WithContext(ctx)
 		sem := semaphore.NewWeighted(int64(flagPullConcurrency))
-
 		for _, link := range t.Topics {
 			ll := schema.LookupFromPath(link.Path)
 			if ll.DocExists() {
 				// TODO: check last fetch, version
 				continue
 			}
-			sem.Acquire(ctx, 1)
-			go func() {
+			if err := sem.Acquire(gctx, 1); err != nil {
+				break
+			}
+			g.Go(func() error {
 				defer sem.Release(1)
 				fmt.Fprintln(os.Stderr, "  ", ll.DocPath)
-				tt := schema.FetchTopic(ctx, ll, fetchOptions(cmd))
-				fatal(writeTopic(ll, tt))
-			}()
+				return fetchTopic(gctx, ll, opts)
+			})
 		}
 		fmt.Fprintln(os.Stderr, "=> Waiting for workers to finish...")
-		sem.Acquire(ctx, int64(flagPullConcurrency))
+		fatal(g.Wait())
 
 		fmt.Fprintln(os.Stderr, "=> Generating schema...")
-		s := schema.PullSchema(l)
+		enc, err := newEncoding(flagPullArch, flagPullEncoding)
+		fatal(err)
+		s := schema.PullSchema(l, enc)
 		fatal(writeSchema(l, s))
 		fmt.Fprintf(os.Stderr, "=> %s [%s]\n", l.APIPath, time.Since(start))
 	},
 }
 
+// newEncoding returns the type encodings for an architecture, with the
+// types of schemas already pulled and any in the table file, which is
+// JSON like a schema.Encoding.
+func newEncoding(arch, file string) (*schema.Encoding, error) {
+	if arch != "arm64" && arch != "x86_64" {
+		return nil, fmt.Errorf("unknown architecture %q", arch)
+	}
+	enc := schema.NewEncoding(arch)
+	if err := enc.AddSchemas("./api"); err != nil {
+		return nil, err
+	}
+	if file != "" {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(b, enc); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+	}
+	return enc, nil
+}
+
 func writeSchema(l schema.Lookup, s schema.Schema) error {
 	b, err := json.MarshalIndent(s, "", "  ")
 	if err != nil {
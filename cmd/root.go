@@ -19,6 +19,8 @@ var (
 	flagTimeout time.Duration
 
 	flagPullConcurrency int
+	flagPullArch        string
+	flagPullEncoding    string
 
 	rootCmd = &cobra.Command{
 		Version: Version,
@@ -29,10 +31,21 @@ var (
 
 func init() {
 	rootCmd.AddCommand(crawlCmd)
+	rootCmd.AddCommand(diffCmd)
 	rootCmd.AddCommand(fetchCmd)
+	rootCmd.AddCommand(genCmd)
+	rootCmd.AddCommand(mergeCmd)
+	rootCmd.AddCommand(migrateCmd)
+	rootCmd.AddCommand(parseCmd)
 	rootCmd.AddCommand(pullCmd)
+	rootCmd.AddCommand(searchCmd)
+	rootCmd.AddCommand(typesCmd)
+	rootCmd.AddCommand(validateCmd)
 
 	pullCmd.Flags().IntVar(&flagPullConcurrency, "concurrency", runtime.NumCPU(), "number of concurrent workers")
+	pullCmd.Flags().StringVar(&flagPullArch, "arch", "arm64", "architecture of type encodings: arm64 or x86_64")
+	pullCmd.Flags().StringVar(&flagPullEncoding, "encoding", "", "JSON file with more types for type encodings")
+	searchCmd.Flags().IntVar(&flagSearchLimit, "limit", 20, "maximum number of results")
 
 	rootCmd.PersistentFlags().BoolVar(&flagShow, "show", false, "show resulting JSON to stdout")
 	rootCmd.PersistentFlags().StringVar(&flagLang, "lang", "objc", "use language")
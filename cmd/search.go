@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/progrium/macschema/schema"
+	"github.com/spf13/cobra"
+)
+
+var flagSearchLimit int
+
+var searchCmd = &cobra.Command{
+	Use:   "search",
+	Short: "Search topics and schemas in doc and api dirs",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		idx, err := schema.BuildIndex("./doc", "./api")
+		fatal(err)
+
+		matches := idx.Search(args[0], flagLang)
+		if len(matches) == 0 {
+			fatal(fmt.Errorf("no matches for %q", args[0]))
+		}
+		if flagSearchLimit > 0 && len(matches) > flagSearchLimit {
+			matches = matches[:flagSearchLimit]
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for _, m := range matches {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", m.Name, m.Kind, m.Framework, m.Path)
+		}
+		fatal(w.Flush())
+	},
+}
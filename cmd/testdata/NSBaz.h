@@ -0,0 +1,13 @@
+NS_ASSUME_NONNULL_BEGIN
+
+/// A baz.
+@interface NSBaz : NSObject
+@property (copy) NSString *name;
+- (instancetype)initWithName:(NSString *)name;
+@end
+
+FOUNDATION_EXPORT NSString * const NSBazKey API_AVAILABLE(macos(10.10));
+extern void (*NSBazHook)(NSBaz *baz);
+APPKIT_EXTERN NSBaz *NSBazMake(NSString *name);
+
+NS_ASSUME_NONNULL_END
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/progrium/macschema/schema"
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagTypesTarget string
+	flagTypesMap    string
+)
+
+var typesCmd = &cobra.Command{
+	Use:   "types [path...]",
+	Short: "List the types used by schemas in api dir and their target types",
+	Long: `List the types used by the objc schemas of topics or directories in api, or
+all of api, with their types in a target language: go, rust or ts. The
+built-in type map of the target can be extended with a YAML or JSON file.
+Types with no target type are listed on stderr.`,
+	Example: `  macschema types --target rust appkit
+  macschema types --map types.yaml appkit/nswindow`,
+	Run: func(cmd *cobra.Command, args []string) {
+		m, err := newTypeMap(flagTypesTarget, flagTypesMap)
+		fatal(err)
+		schemas, err := readSchemas(args)
+		fatal(err)
+		for _, s := range schemas {
+			m.AddSchema(s)
+		}
+
+		uniq := make(map[string]string)
+		for _, dt := range schema.CollectTypes(schemas) {
+			t, err := m.Map(dt)
+			if err != nil {
+				t = "?"
+			}
+			uniq[dt.String()] = t
+		}
+		var types []string
+		for t := range uniq {
+			types = append(types, t)
+		}
+		sort.Strings(types)
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for _, t := range types {
+			fmt.Fprintf(w, "%s\t%s\n", t, uniq[t])
+		}
+		fatal(w.Flush())
+		if unmapped := m.Unmapped(schemas); len(unmapped) > 0 {
+			fmt.Fprintf(os.Stderr, "unmapped: %s\n", strings.Join(unmapped, ", "))
+		}
+	},
+}
+
+func init() {
+	typesCmd.Flags().StringVar(&flagTypesTarget, "target", "go", "target language: go, rust or ts")
+	typesCmd.Flags().StringVar(&flagTypesMap, "map", "", "YAML or JSON file with more target types")
+}
+
+// newTypeMap returns the type map of a target language with the types of
+// the schemas in api and any in the file.
+func newTypeMap(target, file string) (*schema.TypeMap, error) {
+	m, err := schema.DefaultTypeMap(target)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.AddSchemas("./api"); err != nil {
+		return nil, err
+	}
+	if file != "" {
+		if err := m.Load(file); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
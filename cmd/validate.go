@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/progrium/macschema/schema"
+	"github.com/spf13/cobra"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate [path...]",
+	Short: "Check files in api and doc dirs against their JSON Schema",
+	Long: `Check the JSON files in api and doc, or in the directories and files given,
+against the JSON Schema of their format, which is published in jsonschema.
+Files in a doc directory are topics and others are schemas. Exits with
+status 1 if any file is invalid.`,
+	Example: `  macschema validate
+  macschema validate api/appkit doc/appkit/nswindow.objc.json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		explicit := len(args) > 0
+		if !explicit {
+			args = []string{"./api", "./doc"}
+		}
+		api, doc := schema.APIJSONSchema(), schema.DocJSONSchema()
+		var files, invalid int
+		for _, arg := range args {
+			if _, err := os.Stat(arg); os.IsNotExist(err) && !explicit {
+				continue
+			}
+			err := filepath.Walk(arg, func(p string, info os.FileInfo, err error) error {
+				if err != nil || info.IsDir() || !strings.HasSuffix(p, ".json") {
+					return err
+				}
+				b, err := ioutil.ReadFile(p)
+				if err != nil {
+					return err
+				}
+				js := api
+				if isDoc(p) {
+					js = doc
+				}
+				files++
+				if err := js.Validate(b); err != nil {
+					invalid++
+					fmt.Printf("%s:\n  %s\n", p, strings.ReplaceAll(err.Error(), "\n", "\n  "))
+				}
+				return nil
+			})
+			fatal(err)
+		}
+		fmt.Fprintf(os.Stderr, "=> %d files, %d invalid\n", files, invalid)
+		if invalid > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+// isDoc returns true for paths in a doc directory.
+func isDoc(path string) bool {
+	for _, dir := range strings.Split(filepath.ToSlash(filepath.Dir(path)), "/") {
+		if dir == "doc" {
+			return true
+		}
+	}
+	return false
+}
@@ -10,26 +10,40 @@ type Node interface {
 }
 
 type Statement struct {
-	Method    *MethodDecl
-	Property  *PropertyDecl
-	Interface *InterfaceDecl
-	Protocol  *ProtocolDecl
-	Function  *FunctionDecl
-	Variable  *VariableDecl
-	Enum      *EnumDecl
-	Struct    *StructDecl
-	TypeAlias *TypeInfo
-	Typedef   string
+	Method    *MethodDecl    `json:",omitempty"`
+	Property  *PropertyDecl  `json:",omitempty"`
+	Interface *InterfaceDecl `json:",omitempty"`
+	Protocol  *ProtocolDecl  `json:",omitempty"`
+	Function  *FunctionDecl  `json:",omitempty"`
+	Variable  *VariableDecl  `json:",omitempty"`
+	Enum      *EnumDecl      `json:",omitempty"`
+	Struct    *StructDecl    `json:",omitempty"`
+	TypeAlias *TypeInfo      `json:",omitempty"`
+	Typedef   string         `json:",omitempty"`
+	Comment   string         `json:",omitempty"`
+
+	// AssumeNonnull is set for declarations in an assume_nonnull region,
+	// where pointers without a nullability annotation are nonnull.
+	AssumeNonnull bool `json:",omitempty"`
+
+	// SwiftName is the name NS_SWIFT_NAME gives a type declaration, like
+	// a typedef or enum. Members, functions and variables have their own.
+	SwiftName string `json:",omitempty"`
 }
 
 type ProtocolDecl struct {
 	Name      string
 	SuperName string
+	Protocols []string `json:",omitempty"`
 }
 
 type InterfaceDecl struct {
-	Name      string
-	SuperName string
+	Name       string
+	TypeParams []string `json:",omitempty"` // with variance, as in __covariant ObjectType
+	SuperName  string
+	Category   string   `json:",omitempty"`
+	Extension  bool     `json:",omitempty"` // class extension: @interface Name ()
+	Protocols  []string `json:",omitempty"`
 }
 
 type PropertyDecl struct {
@@ -37,6 +51,8 @@ type PropertyDecl struct {
 	Type     TypeInfo
 	Attrs    map[PropAttr]string
 	IsOutlet bool
+
+	SwiftName string `json:",omitempty"`
 }
 
 type FunctionDecl struct {
@@ -46,6 +62,8 @@ type FunctionDecl struct {
 	IsBlock    bool
 	IsPtr      bool
 	Variadic   bool
+
+	SwiftName string `json:",omitempty"`
 }
 
 func (f *FunctionDecl) Ident() string {
@@ -66,6 +84,9 @@ type MethodDecl struct {
 	NameParts  []string
 	Args       []ArgInfo
 	Variadic   bool
+
+	// SwiftName is the name given by NS_SWIFT_NAME, as in orderFront(_:).
+	SwiftName string `json:",omitempty"`
 }
 
 func (m *MethodDecl) Name() string {
@@ -78,13 +99,37 @@ func (m *MethodDecl) Name() string {
 	return strings.Join(append(m.NameParts, ""), ":")
 }
 
+// TypeInfo is a named type, or with Kind set a pointer to or array of its
+// Elem. Annots are the qualifiers of each level, so const char *const * is
+// a pointer to a const pointer to const char.
 type TypeInfo struct {
-	Name     string
-	IsPtr    bool
-	IsPtrPtr bool
-	Annots   map[TypeAnnotation]bool
-	Func     *FunctionDecl
-	Params   []TypeInfo
+	Name   string
+	Annots map[TypeAnnotation]bool
+	Func   *FunctionDecl
+	Params []TypeInfo
+
+	Kind TypeKind
+	Elem *TypeInfo
+	Len  string // array length, empty for []
+}
+
+// Base returns the named type at the bottom of pointers and arrays.
+func (t TypeInfo) Base() TypeInfo {
+	for t.Elem != nil {
+		t = *t.Elem
+	}
+	return t
+}
+
+// IsPtr returns true for a pointer type. With Base and IsPtrPtr it is a
+// view of the type as a name with up to two levels of pointers.
+func (t TypeInfo) IsPtr() bool {
+	return t.Kind == TypePointer
+}
+
+// IsPtrPtr returns true for a pointer to a pointer.
+func (t TypeInfo) IsPtrPtr() bool {
+	return t.IsPtr() && t.Elem.IsPtr()
 }
 
 type ArgInfo struct {
@@ -96,6 +141,8 @@ type VariableDecl struct {
 	Name  string
 	Type  TypeInfo
 	Value string
+
+	SwiftName string `json:",omitempty"`
 }
 
 type EnumDecl struct {
@@ -21,31 +21,49 @@ const (
 	PropAttrRetain
 	PropAttrNullable
 	PropAttrNonnull
+	PropAttrNullUnspecified
+	PropAttrNullResettable
 	PropAttrGetter
 	PropAttrSetter
 )
 
 var propAttrs = map[PropAttr]string{
-	PropAttrClass:     "class",
-	PropAttrWeak:      "weak",
-	PropAttrStrong:    "strong",
-	PropAttrReadonly:  "readonly",
-	PropAttrReadwrite: "readwrite",
-	PropAttrNonatomic: "nonatomic",
-	PropAttrAtomic:    "atomic",
-	PropAttrCopy:      "copy",
-	PropAttrAssign:    "assign",
-	PropAttrRetain:    "retain",
-	PropAttrNullable:  "nullable",
-	PropAttrNonnull:   "nonnull",
-	PropAttrGetter:    "getter",
-	PropAttrSetter:    "setter",
+	PropAttrClass:           "class",
+	PropAttrWeak:            "weak",
+	PropAttrStrong:          "strong",
+	PropAttrReadonly:        "readonly",
+	PropAttrReadwrite:       "readwrite",
+	PropAttrNonatomic:       "nonatomic",
+	PropAttrAtomic:          "atomic",
+	PropAttrCopy:            "copy",
+	PropAttrAssign:          "assign",
+	PropAttrRetain:          "retain",
+	PropAttrNullable:        "nullable",
+	PropAttrNonnull:         "nonnull",
+	PropAttrNullUnspecified: "null_unspecified",
+	PropAttrNullResettable:  "null_resettable",
+	PropAttrGetter:          "getter",
+	PropAttrSetter:          "setter",
 }
 
 func (attr PropAttr) String() string {
 	return propAttrs[attr]
 }
 
+func (attr PropAttr) MarshalText() ([]byte, error) {
+	return []byte(attr.String()), nil
+}
+
+func (attr *PropAttr) UnmarshalText(b []byte) error {
+	for a, s := range propAttrs {
+		if s == string(b) {
+			*attr = a
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown property attribute %q", b)
+}
+
 func PropAttrs() []PropAttr {
 	keys := make([]int, len(propAttrs))
 	props := make([]PropAttr, len(propAttrs))
@@ -76,12 +94,16 @@ const (
 	TypeAnnotInout
 	TypeAnnotByCopy
 	TypeAnnotByRef
+	TypeAnnotRestrict
+	TypeAnnotVolatile
+	TypeAnnotAtomic
 
 	annonatedType
 
 	TypeAnnotNullable
 	TypeAnnotNonnull
 	TypeAnnotNullUnspecified
+	TypeAnnotNoEscape
 )
 
 var typeAnnots = map[TypeAnnotation]string{
@@ -95,9 +117,13 @@ var typeAnnots = map[TypeAnnotation]string{
 	TypeAnnotInout:           "inout %s",
 	TypeAnnotByCopy:          "bycopy %s",
 	TypeAnnotByRef:           "byref %s",
+	TypeAnnotRestrict:        "restrict %s",
+	TypeAnnotVolatile:        "volatile %s",
+	TypeAnnotAtomic:          "_Atomic %s",
 	TypeAnnotNullable:        "%s _Nullable",
 	TypeAnnotNonnull:         "%s _Nonnull",
 	TypeAnnotNullUnspecified: "%s _Null_unspecified",
+	TypeAnnotNoEscape:        "NS_NOESCAPE %s",
 }
 
 func (annot TypeAnnotation) Format() string {
@@ -108,6 +134,18 @@ func (annot TypeAnnotation) String() string {
 	return strings.Trim(fmt.Sprintf(typeAnnots[annot], ""), " ")
 }
 
+func (annot TypeAnnotation) MarshalText() ([]byte, error) {
+	return []byte(annot.String()), nil
+}
+
+func (annot *TypeAnnotation) UnmarshalText(b []byte) error {
+	if a, ok := isTypeAnnot(string(b)); ok {
+		*annot = a
+		return nil
+	}
+	return fmt.Errorf("unknown type annotation %q", b)
+}
+
 func TypeAnnotations() []TypeAnnotation {
 	keys := make([]int, len(typeAnnots))
 	annots := make([]TypeAnnotation, len(typeAnnots))
@@ -126,6 +164,9 @@ func TypeAnnotations() []TypeAnnotation {
 }
 
 func isTypeAnnot(s string) (TypeAnnotation, bool) {
+	if annot, ok := qualifierSpellings[s]; ok {
+		return annot, true
+	}
 	for _, annot := range TypeAnnotations() {
 		if s == annot.String() {
 			return annot, true
@@ -133,3 +174,57 @@ func isTypeAnnot(s string) (TypeAnnotation, bool) {
 	}
 	return annonatedType, false
 }
+
+// qualifierSpellings are other spellings of type qualifiers found in
+// system headers.
+var qualifierSpellings = map[string]TypeAnnotation{
+	"__restrict":   TypeAnnotRestrict,
+	"__restrict__": TypeAnnotRestrict,
+	"__volatile":   TypeAnnotVolatile,
+}
+
+// outerQualifiers are the spellings of annotations that come before the
+// type but apply to the declared type as a whole, as in method types:
+// (nullable NSString *) and (NS_NOESCAPE void (^)(void)).
+var outerQualifiers = map[string]TypeAnnotation{
+	"nullable":           TypeAnnotNullable,
+	"nonnull":            TypeAnnotNonnull,
+	"null_unspecified":   TypeAnnotNullUnspecified,
+	"__nullable":         TypeAnnotNullable,
+	"__nonnull":          TypeAnnotNonnull,
+	"__null_unspecified": TypeAnnotNullUnspecified,
+	"CF_NOESCAPE":        TypeAnnotNoEscape,
+}
+
+// TypeKind is the kind of a TypeInfo.
+type TypeKind int
+
+const (
+	TypeNamed TypeKind = iota
+	TypePointer
+	TypeArray
+)
+
+var typeKinds = map[TypeKind]string{
+	TypeNamed:   "named",
+	TypePointer: "pointer",
+	TypeArray:   "array",
+}
+
+func (k TypeKind) String() string {
+	return typeKinds[k]
+}
+
+func (k TypeKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+func (k *TypeKind) UnmarshalText(b []byte) error {
+	for kind, s := range typeKinds {
+		if s == string(b) {
+			*k = kind
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown type kind %q", b)
+}
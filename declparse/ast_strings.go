@@ -7,6 +7,16 @@ import (
 
 func (s Statement) String() string {
 	b := &strings.Builder{}
+	if s.Comment != "" {
+		for _, line := range strings.Split(s.Comment, "\n") {
+			b.WriteString(strings.TrimSpace("// " + line))
+			b.WriteString("\n")
+		}
+	}
+	if s.SwiftName != "" && (s.Interface != nil || s.Protocol != nil) {
+		// a Swift name leads a container, and follows other types
+		fmt.Fprintf(b, "NS_SWIFT_NAME(%s) ", s.SwiftName)
+	}
 	if s.Typedef != "" {
 		b.WriteString("typedef ")
 	}
@@ -34,9 +44,22 @@ func (s Statement) String() string {
 	if s.Struct != nil {
 		b.WriteString(s.Struct.String())
 	}
+	if s.TypeAlias != nil {
+		b.WriteString(s.TypeAlias.Decl(s.Typedef))
+		b.WriteString(swiftName(s.SwiftName))
+		b.WriteString(";")
+		return b.String()
+	}
 	if s.Typedef != "" {
 		fmt.Fprintf(b, " %s", s.Typedef)
 	}
+	if s.Enum != nil || s.Struct != nil {
+		b.WriteString(swiftName(s.SwiftName))
+	}
+	if s.Interface != nil || s.Protocol != nil {
+		// container headers end at the end of the line
+		return b.String()
+	}
 	b.WriteString(";")
 	return b.String()
 }
@@ -47,15 +70,27 @@ func (i ProtocolDecl) String() string {
 	if i.SuperName != "" {
 		_, _ = fmt.Fprintf(b, " : %s", i.SuperName)
 	}
+	if len(i.Protocols) > 0 {
+		_, _ = fmt.Fprintf(b, " <%s>", strings.Join(i.Protocols, ", "))
+	}
 	return b.String()
 }
 
 func (i InterfaceDecl) String() string {
 	b := &strings.Builder{}
 	_, _ = fmt.Fprintf(b, "@interface %s", i.Name)
+	if len(i.TypeParams) > 0 {
+		_, _ = fmt.Fprintf(b, "<%s>", strings.Join(i.TypeParams, ", "))
+	}
 	if i.SuperName != "" {
 		_, _ = fmt.Fprintf(b, " : %s", i.SuperName)
 	}
+	if i.Category != "" || i.Extension {
+		_, _ = fmt.Fprintf(b, " (%s)", i.Category)
+	}
+	if len(i.Protocols) > 0 {
+		_, _ = fmt.Fprintf(b, " <%s>", strings.Join(i.Protocols, ", "))
+	}
 	return b.String()
 }
 
@@ -81,12 +116,8 @@ func (p PropertyDecl) String() string {
 	if p.IsOutlet {
 		b.WriteString("IBOutlet ")
 	}
-	typ := p.Type.String()
-	b.WriteString(typ)
-	if typ[len(typ)-1] != '*' {
-		b.WriteString(" ")
-	}
-	b.WriteString(p.Name)
+	b.WriteString(p.Type.Decl(p.Name))
+	b.WriteString(swiftName(p.SwiftName))
 	return b.String()
 }
 
@@ -98,23 +129,34 @@ func (args FuncArgs) String() string {
 	} else {
 		var str []string
 		for _, arg := range args {
-			str = append(str, strings.Trim(fmt.Sprintf("%s %s", arg.Type, arg.Name), " "))
+			str = append(str, arg.Type.Decl(arg.Name))
 		}
 		return strings.Join(str, ", ")
 	}
 }
 
 func (f FunctionDecl) String() string {
-	b := &strings.Builder{}
-	fmt.Fprintf(b, "%s %s(%s", f.ReturnType, f.Ident(), f.Args)
+	return TypeInfo{Func: &f}.Decl(f.Name) + swiftName(f.SwiftName)
+}
+
+// swiftName returns the NS_SWIFT_NAME annotation following a member or
+// variable with a Swift name.
+func swiftName(name string) string {
+	if name == "" {
+		return ""
+	}
+	return fmt.Sprintf(" NS_SWIFT_NAME(%s)", name)
+}
+
+// params returns the parameter list of a function type.
+func (f FunctionDecl) params() string {
+	if f.Variadic && len(f.Args) == 0 {
+		return "(...)"
+	}
 	if f.Variadic {
-		if len(f.Args) > 0 {
-			b.WriteString(", ")
-		}
-		b.WriteString("...")
+		return fmt.Sprintf("(%s, ...)", f.Args)
 	}
-	b.WriteString(")")
-	return b.String()
+	return fmt.Sprintf("(%s)", f.Args)
 }
 
 func (m MethodDecl) String() string {
@@ -138,12 +180,16 @@ func (m MethodDecl) String() string {
 		}
 		b.WriteString("...")
 	}
+	b.WriteString(swiftName(m.SwiftName))
 	return b.String()
 }
 
 func (t TypeInfo) String() string {
-	if t.Func != nil {
-		return t.Func.String()
+	switch {
+	case t.Kind != TypeNamed:
+		return t.Decl("")
+	case t.Func != nil:
+		return t.Decl(t.Func.Name)
 	}
 	params := ""
 	if len(t.Params) > 0 {
@@ -153,24 +199,86 @@ func (t TypeInfo) String() string {
 		}
 		params = fmt.Sprintf("<%s>", strings.Join(p, ", "))
 	}
-	ptr := ""
-	if t.IsPtr {
-		ptr = "*"
+	str := t.Name + params
+	// apply in reverse so annotations read in declaration order
+	annots := TypeAnnotations()
+	for i := len(annots) - 1; i >= 0; i-- {
+		if t.Annots[annots[i]] {
+			str = fmt.Sprintf(annots[i].Format(), str)
+		}
 	}
-	str := strings.Trim(fmt.Sprintf("%s%s %s", t.Name, params, ptr), " ")
-	for annot, ok := range t.Annots {
-		if !ok {
-			continue
+	return str
+}
+
+// Decl returns the declaration of name with the type, which is built
+// inside out like the type was parsed: array lengths follow the name, as
+// in float values[4], and pointers to functions and arrays are in
+// parentheses, as in void (^name)(int).
+func (t TypeInfo) Decl(name string) string {
+	if t.Annots[TypeAnnotNoEscape] && (t.Kind != TypeNamed || t.Func != nil) {
+		// NS_NOESCAPE starts the declaration whatever level it applies to
+		annots := make(map[TypeAnnotation]bool)
+		for annot := range t.Annots {
+			annots[annot] = annot != TypeAnnotNoEscape
+		}
+		t.Annots = annots
+		return "NS_NOESCAPE " + t.Decl(name)
+	}
+
+	switch {
+	case t.Kind == TypeArray:
+		return t.Elem.Decl(fmt.Sprintf("%s[%s]", name, t.Len))
+	case t.Kind == TypePointer:
+		d := "*" + t.qualifiers()
+		if name != "" && d != "*" {
+			d += " "
 		}
-		str = fmt.Sprintf(annot.Format(), str)
+		d += name
+		if t.Elem.Kind == TypeArray || t.Elem.isFunction() {
+			d = "(" + d + ")"
+		}
+		return t.Elem.Decl(d)
+	case t.Func != nil:
+		d := name
+		if t.Func.IsBlock || t.Func.IsPtr {
+			op := "*"
+			if t.Func.IsBlock {
+				op = "^"
+			}
+			d = op + t.qualifiers()
+			if name != "" && d != op {
+				d += " "
+			}
+			d = "(" + d + name + ")"
+		}
+		return t.Func.ReturnType.Decl(d + t.Func.params())
+	}
+
+	typ := t.String()
+	if name == "" || strings.HasPrefix(name, "[") {
+		return typ + name
 	}
+	return typ + " " + name
+}
+
+// isFunction returns true for function types that are not pointers.
+func (t TypeInfo) isFunction() bool {
+	return t.Kind == TypeNamed && t.Func != nil && !t.Func.IsBlock && !t.Func.IsPtr
+}
+
+// qualifiers returns the annotations of a pointer or block level, which
+// follow the * or ^. Type qualifiers bind to it, as in char *const and
+// int *restrict, and nullability is separated by a space.
+func (t TypeInfo) qualifiers() string {
 	b := &strings.Builder{}
-	b.WriteString(str)
-	if t.IsPtrPtr {
-		if str[len(str)-1] != '*' {
+	for _, annot := range TypeAnnotations() {
+		if !t.Annots[annot] || annot == TypeAnnotNoEscape {
+			continue
+		}
+		if b.Len() > 0 || annot > annonatedType {
 			b.WriteString(" ")
 		}
-		b.WriteString("*")
+		b.WriteString(annot.String())
 	}
 	return b.String()
 }
@@ -181,7 +289,12 @@ func (arg ArgInfo) String() string {
 
 func (v VariableDecl) String() string {
 	b := &strings.Builder{}
-	fmt.Fprintf(b, "%s %s", v.Type, v.Name)
+	if v.Type.Name != "" || v.Type.Elem != nil || v.Type.Func != nil {
+		b.WriteString(v.Type.Decl(v.Name))
+	} else {
+		b.WriteString(v.Name)
+	}
+	b.WriteString(swiftName(v.SwiftName))
 	if v.Value != "" {
 		fmt.Fprintf(b, " = %s", v.Value)
 	}
@@ -190,20 +303,21 @@ func (v VariableDecl) String() string {
 
 func (e EnumDecl) String() string {
 	b := &strings.Builder{}
+	b.WriteString("enum ")
 	if e.Name != "" {
-		if e.Type.Name != "" {
-			fmt.Fprintf(b, "enum %s : %s { ", e.Name, e.Type.String())
-		} else {
-			fmt.Fprintf(b, "enum %s { ", e.Name)
-		}
-	} else {
-		b.WriteString("enum { ")
+		fmt.Fprintf(b, "%s ", e.Name)
+	}
+	if e.Type.Name != "" {
+		fmt.Fprintf(b, ": %s ", e.Type.String())
+	}
+	b.WriteString("{ ")
+	if len(e.Cases) == 0 {
+		b.WriteString("... ")
 	}
 	for idx, c := range e.Cases {
+		b.WriteString(c.Name + swiftName(c.SwiftName))
 		if c.Value != "" {
-			fmt.Fprintf(b, "%s = %s", c.Name, c.Value)
-		} else {
-			fmt.Fprintf(b, "%s", c.Name)
+			fmt.Fprintf(b, " = %s", c.Value)
 		}
 		if idx == len(e.Cases)-1 {
 			b.WriteString(" ")
@@ -3,6 +3,8 @@ package declparse
 import (
 	"regexp"
 	"testing"
+
+	"github.com/go-test/deep"
 )
 
 func TestAST_Strings(t *testing.T) {
@@ -24,3 +26,117 @@ func normalizeWhitespace(s string) string {
 	space := regexp.MustCompile(`\s+`)
 	return space.ReplaceAllString(s, " ")
 }
+
+// roundTrips are declarations that only need to survive a round trip
+// through String(), in addition to everything in tests, and print as want
+// when it is set.
+var roundTrips = []struct {
+	s    string
+	Hint Hint
+	want string
+}{
+	{s: `typedef void (^NSWindowCompletionHandler)(NSModalResponse returnCode);`},
+	{s: `typedef NSComparisonResult (*NSSortFunction)(id a, id b, void *context);`},
+	{s: `typedef enum NSEmpty : NSUInteger { } NSEmpty;`},
+	{s: `enum : NSUInteger { NSOptionA = 1UL << 0, NSOptionB = (NSOptionA | 0x2), NSOptionC = 'icns', NSOptionD };`},
+	{s: `NSOptionAll = ~0ULL`, Hint: HintEnumCase},
+	{s: `const float NSScale = 0x1p-3f;`, Hint: HintVariable},
+	{s: `- (const unsigned char *)bytes;`},
+	{s: `- (unsigned long long)unsignedLongLongValue;`},
+	{s: `- (void)setOptions:(NSDictionary<NSString *, id<NSCopying>> *)options;`},
+	{s: `@property(nonatomic, copy, nullable) NSArray<__kindof NSView *> *views;`},
+	{s: `const char *const NSTitle = "title \"quoted\"";`, Hint: HintVariable},
+	{s: `NSInteger const NSMask = sizeof (int) << 2;`, Hint: HintVariable},
+	{s: `int printf(const char *format, ...);`, Hint: HintFunction},
+	{s: `NSString *const NSWindowDidMoveNotification = @"NSWindowDidMoveNotification";`, Hint: HintVariable},
+	{s: `BOOL const NSFlag = !NO && (YES || 1 ? 2 : ~3);`, Hint: HintVariable},
+	{s: `void NSLogv(...);`, Hint: HintFunction},
+	{s: `- (nullable NSString *)titleForItem:(nonnull id)item error:(NSError * _Nullable *)error;`},
+	{s: `@property(copy, null_resettable) NSColor *backgroundColor;`},
+	{s: `@interface NSWindow () <NSWindowDelegate>`},
+	{s: `@interface NSDictionary<KeyType, __contravariant ObjectType> : NSObject`},
+	{s: `typedef float simd_float4[4];`},
+	{s: `void NSFree(void *ptr);`, Hint: HintFunction},
+	{s: `int main(int argc, const char *argv[]);`, Hint: HintFunction},
+	{s: `- (void)setBuffer:(const char *const _Nullable *)buffer;`},
+	{s: `- (void)getValues:(NSInteger *[NSMaxValues + 1])values;`},
+	{s: `typedef void (^(^NSBlockFactory)(int))(void);`},
+	{s: `void (*(*NSHandlers)[4])(int);`, Hint: HintVariable},
+	{s: `@property(copy) void (^ _Nullable handler)(NSString *s);`},
+	{s: `- (void)performBlock:(CF_NOESCAPE void (^ _Nonnull)(void))block;`},
+	{s: `typedef int (*NSTable)[8];`},
+	{s: `void f(int * restrict p);`, Hint: HintFunction},
+	{s: `void *memcpy(void *__restrict dst, const void *__restrict src, size_t n);`, Hint: HintFunction},
+	{s: `_Atomic int *volatile const _Nullable NSCounter;`, Hint: HintVariable},
+	{s: `- (void)setFlag:(volatile int *)flag;`},
+	{s: `- (void)close NS_SWIFT_NAME(close());`},
+	{s: `@property(readonly) BOOL visible NS_SWIFT_NAME(isVisible);`},
+	{s: `CGRect CGRectMake(CGFloat x, CGFloat y) NS_SWIFT_NAME(CGRect.init(x:y:));`, Hint: HintFunction},
+	{s: `extern NSString *const NSFoo NS_SWIFT_NAME(foo);`, Hint: HintVariable},
+	{s: `NSFooA NS_SWIFT_NAME(a) = 1`, Hint: HintEnumCase},
+	{s: `typedef enum NSFoo : NSInteger { NSFooA NS_SWIFT_NAME(a) = 1, NSFooB } NSFoo NS_SWIFT_NAME(Foo);`},
+	{s: `typedef NSString *NSFooKey NS_SWIFT_NAME(FooKey);`},
+	{s: `NS_SWIFT_NAME(Bar) @interface NSBar : NSObject`, want: `NS_SWIFT_NAME(Bar) @interface NSBar : NSObject`},
+	{s: `@interface NSBaz : NSObject`, want: `@interface NSBaz : NSObject`},
+	{s: `@protocol NSBazDelegate <NSObject>`, want: `@protocol NSBazDelegate <NSObject>`},
+}
+
+// Parse(s).String() must parse back to the same AST.
+func TestAST_RoundTrip(t *testing.T) {
+	deep.NilMapsAreEmpty = true
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			testRoundTrip(t, normalizeStmntString(tt.s), tt.Hint)
+		})
+	}
+	for _, tt := range roundTrips {
+		t.Run(tt.s, func(t *testing.T) {
+			if out := testRoundTrip(t, tt.s, tt.Hint); tt.want != "" && out != tt.want {
+				t.Errorf("String()\n  got: %s\n want: %s", out, tt.want)
+			}
+		})
+	}
+}
+
+func FuzzRoundTrip(f *testing.F) {
+	for _, tt := range tests {
+		f.Add(normalizeStmntString(tt.s), int(tt.Hint))
+	}
+	for _, tt := range roundTrips {
+		f.Add(tt.s, int(tt.Hint))
+	}
+	f.Fuzz(func(t *testing.T, s string, hint int) {
+		p := NewStringParser(s)
+		p.Hint = Hint(uint(hint) % uint(len(hints)))
+		stmt, err := p.Parse()
+		if err != nil {
+			return
+		}
+		testRoundTrip(t, stmt.String(), p.Hint)
+	})
+}
+
+func testRoundTrip(t *testing.T, s string, hint Hint) string {
+	t.Helper()
+	p := NewStringParser(s)
+	p.Hint = hint
+	want, err := p.Parse()
+	if err != nil {
+		t.Fatal("parse:", err)
+	}
+	out := want.String()
+	p = NewStringParser(out)
+	p.Hint = hint
+	got, err := p.Parse()
+	if err != nil {
+		t.Fatalf("reparse %q: %s", out, err)
+	}
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Errorf("reparse %q: %v", out, diff)
+	}
+	if again := got.String(); again != out {
+		t.Errorf("String() not canonical:\n  got: %s\n want: %s", again, out)
+	}
+	return out
+}
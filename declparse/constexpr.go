@@ -0,0 +1,388 @@
+package declparse
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/progrium/macschema/lexer"
+)
+
+// EvalInt evaluates an integer constant expression such as an enum value.
+// Identifiers are resolved with lookup, which may be nil. Operands are
+// 64-bit as on Apple platforms: an operation is unsigned if either operand
+// is, and unsigned results wrap around. Casts to unsigned types like
+// (NSUInteger) make their operand unsigned; other casts are ignored.
+func EvalInt(expr string, lookup func(name string) (*big.Int, bool)) (*big.Int, error) {
+	toks, err := constTokens(expr)
+	if err != nil {
+		return nil, err
+	}
+	if len(toks) == 0 {
+		return nil, fmt.Errorf("empty expression")
+	}
+	e := &constEval{toks: toks, lookup: lookup}
+	v, err := e.expr()
+	if err != nil {
+		return nil, err
+	}
+	if e.i < len(e.toks) {
+		return nil, fmt.Errorf("unexpected %q in %q", e.toks[e.i], expr)
+	}
+	return v.v, nil
+}
+
+// constValue is an integer operand and whether it has an unsigned type.
+type constValue struct {
+	v        *big.Int
+	unsigned bool
+}
+
+var (
+	maxUint64 = new(big.Int).SetUint64(1<<64 - 1)
+	maxInt64  = big.NewInt(1<<63 - 1)
+	minInt64  = big.NewInt(-1 << 63)
+	modUint64 = new(big.Int).Lsh(big.NewInt(1), 64)
+)
+
+// wrap reduces a value to the range of its 64-bit type, wrapping around
+// like two's complement arithmetic.
+func (c constValue) wrap() constValue {
+	if c.unsigned && (c.v.Sign() < 0 || c.v.Cmp(maxUint64) > 0) {
+		c.v = new(big.Int).Mod(c.v, modUint64)
+	} else if !c.unsigned && (c.v.Cmp(minInt64) < 0 || c.v.Cmp(maxInt64) > 0) {
+		c.v = new(big.Int).Mod(c.v, modUint64)
+		if c.v.Cmp(maxInt64) > 0 {
+			c.v.Sub(c.v, modUint64)
+		}
+	}
+	return c
+}
+
+// constToken is a token of a constant expression. Operators are kept as
+// their source text in lit.
+type constToken struct {
+	tok lexer.Token
+	lit string
+}
+
+func (t constToken) String() string { return t.lit }
+
+// constTokens splits expr into tokens, splitting signs off numbers that
+// follow an operand.
+func constTokens(expr string) ([]constToken, error) {
+	s := lexer.NewScanner(strings.NewReader(expr))
+	var toks []constToken
+	operand := func() bool {
+		if len(toks) == 0 {
+			return false
+		}
+		switch toks[len(toks)-1].tok {
+		case lexer.IDENT, lexer.INTEGER, lexer.DECIMAL, lexer.CHAR, lexer.RPAREN:
+			return true
+		}
+		return false
+	}
+	for {
+		tok, pos, lit := s.Scan()
+		switch tok {
+		case lexer.EOF:
+			return toks, nil
+		case lexer.WS, lexer.COMMENT:
+			continue
+		case lexer.INTEGER, lexer.DECIMAL:
+			if (lit[0] == '+' || lit[0] == '-') && operand() {
+				sign := lexer.PLUS
+				if lit[0] == '-' {
+					sign = lexer.MINUS
+				}
+				toks = append(toks, constToken{tok: sign, lit: sign.String()})
+				lit = lit[1:]
+			}
+		case lexer.IDENT, lexer.CHAR:
+		case lexer.ILLEGAL, lexer.STRING, lexer.ATSTRING, lexer.BADSTRING, lexer.BADESCAPE, lexer.DIRECTIVE:
+			return nil, fmt.Errorf("found %s in expression at %v", tok, pos)
+		default:
+			lit = tok.String()
+		}
+		toks = append(toks, constToken{tok: tok, lit: lit})
+	}
+}
+
+type constEval struct {
+	toks   []constToken
+	i      int
+	lookup func(name string) (*big.Int, bool)
+}
+
+func (e *constEval) peek() constToken {
+	if e.i < len(e.toks) {
+		return e.toks[e.i]
+	}
+	return constToken{tok: lexer.EOF}
+}
+
+func (e *constEval) next() constToken {
+	t := e.peek()
+	if e.i < len(e.toks) {
+		e.i++
+	}
+	return t
+}
+
+func (e *constEval) expect(lit string) error {
+	if t := e.next(); t.lit != lit {
+		return fmt.Errorf("found %q, expected %q", t.lit, lit)
+	}
+	return nil
+}
+
+// expr evaluates a conditional expression.
+func (e *constEval) expr() (constValue, error) {
+	cond, err := e.binary(1)
+	if err != nil || e.peek().lit != "?" {
+		return cond, err
+	}
+	e.next()
+	a, err := e.expr()
+	if err != nil {
+		return a, err
+	}
+	if err := e.expect(":"); err != nil {
+		return a, err
+	}
+	b, err := e.expr()
+	if err != nil {
+		return b, err
+	}
+	if cond.v.Sign() != 0 {
+		return a, nil
+	}
+	return b, nil
+}
+
+// binary evaluates binary operators of at least the given precedence.
+func (e *constEval) binary(prec int) (constValue, error) {
+	x, err := e.unary()
+	if err != nil {
+		return x, err
+	}
+	for {
+		op := e.peek()
+		p := op.tok.Precedence()
+		if p == 0 || p < prec {
+			return x, nil
+		}
+		e.next()
+		y, err := e.binary(p + 1)
+		if err != nil {
+			return y, err
+		}
+		if x, err = applyBinary(op.lit, x, y); err != nil {
+			return x, err
+		}
+	}
+}
+
+func applyBinary(op string, x, y constValue) (constValue, error) {
+	if op == "<<" || op == ">>" {
+		// the type of a shift is the type of its left operand
+		if y.v.Sign() < 0 || y.v.Cmp(big.NewInt(64)) >= 0 {
+			return x, fmt.Errorf("shift count %s out of range", y.v)
+		}
+		z := new(big.Int)
+		if op == "<<" {
+			z.Lsh(x.v, uint(y.v.Uint64()))
+		} else {
+			z.Rsh(x.v, uint(y.v.Uint64()))
+		}
+		return constValue{v: z, unsigned: x.unsigned}.wrap(), nil
+	}
+
+	unsigned := x.unsigned || y.unsigned
+	if unsigned {
+		x.unsigned, y.unsigned = true, true
+		x, y = x.wrap(), y.wrap()
+	}
+	a, b := x.v, y.v
+	z := new(big.Int)
+	switch op {
+	case "*":
+		z.Mul(a, b)
+	case "/", "%":
+		if b.Sign() == 0 {
+			return x, fmt.Errorf("division by zero")
+		}
+		if op == "/" {
+			z.Quo(a, b)
+		} else {
+			z.Rem(a, b)
+		}
+	case "+":
+		z.Add(a, b)
+	case "-":
+		z.Sub(a, b)
+	case "&":
+		z.And(a, b)
+	case "^":
+		z.Xor(a, b)
+	case "|":
+		z.Or(a, b)
+	default:
+		var t bool
+		switch c := a.Cmp(b); op {
+		case "<":
+			t = c < 0
+		case "<=":
+			t = c <= 0
+		case ">":
+			t = c > 0
+		case ">=":
+			t = c >= 0
+		case "==":
+			t = c == 0
+		case "!=":
+			t = c != 0
+		case "&&":
+			t = a.Sign() != 0 && b.Sign() != 0
+		case "||":
+			t = a.Sign() != 0 || b.Sign() != 0
+		}
+		return constBool(t), nil
+	}
+	return constValue{v: z, unsigned: unsigned}.wrap(), nil
+}
+
+func constBool(t bool) constValue {
+	if t {
+		return constValue{v: big.NewInt(1)}
+	}
+	return constValue{v: big.NewInt(0)}
+}
+
+// unary evaluates unary operators, casts and primary expressions.
+func (e *constEval) unary() (constValue, error) {
+	t := e.peek()
+	switch t.lit {
+	case "-", "+", "~", "!":
+		e.next()
+		x, err := e.unary()
+		if err != nil {
+			return x, err
+		}
+		z := new(big.Int)
+		switch t.lit {
+		case "-":
+			z.Neg(x.v)
+		case "+":
+			z.Set(x.v)
+		case "~":
+			z.Not(x.v)
+		case "!":
+			return constBool(x.v.Sign() == 0), nil
+		}
+		return constValue{v: z, unsigned: x.unsigned}.wrap(), nil
+	case "(":
+		if names, ok := e.cast(); ok {
+			x, err := e.unary()
+			if err != nil {
+				return x, err
+			}
+			if isUnsignedType(names) {
+				x.unsigned = true
+			}
+			return x.wrap(), nil
+		}
+		e.next()
+		x, err := e.expr()
+		if err != nil {
+			return x, err
+		}
+		return x, e.expect(")")
+	}
+	return e.primary()
+}
+
+// cast consumes a parenthesized type name if one comes next. A name
+// counts as a type if it can't be resolved as a constant and an operand
+// follows the closing parenthesis.
+func (e *constEval) cast() (names []string, ok bool) {
+	i := e.i + 1
+	for ; i < len(e.toks) && e.toks[i].lit != ")"; i++ {
+		t := e.toks[i]
+		if t.tok == lexer.IDENT {
+			if _, found := e.resolve(t.lit); found {
+				return nil, false
+			}
+		} else if t.lit != "*" {
+			return nil, false
+		}
+		names = append(names, t.lit)
+	}
+	if len(names) == 0 || i+1 >= len(e.toks) {
+		return nil, false
+	}
+	switch next := e.toks[i+1]; {
+	case next.tok == lexer.IDENT, next.tok == lexer.INTEGER, next.tok == lexer.CHAR:
+	case next.lit == "(", next.lit == "~", next.lit == "!", next.lit == "-", next.lit == "+":
+	default:
+		return nil, false
+	}
+	e.i = i + 1
+	return names, true
+}
+
+// isUnsignedType returns true if the type named by a cast is unsigned.
+func isUnsignedType(names []string) bool {
+	for _, name := range names {
+		switch {
+		case name == "unsigned", name == "NSUInteger", name == "size_t", name == "CFOptionFlags",
+			strings.HasPrefix(name, "uint"), strings.HasPrefix(name, "UInt"):
+			return true
+		}
+	}
+	return false
+}
+
+func (e *constEval) resolve(name string) (*big.Int, bool) {
+	if e.lookup == nil {
+		return nil, false
+	}
+	return e.lookup(name)
+}
+
+func (e *constEval) primary() (constValue, error) {
+	t := e.next()
+	switch t.tok {
+	case lexer.INTEGER:
+		n, err := lexer.ParseNumber(t.lit)
+		if err != nil {
+			return constValue{}, err
+		}
+		v := new(big.Int).SetUint64(n.Int)
+		if n.Neg {
+			v.Neg(v)
+		}
+		// like C, a decimal constant too big for a signed type is unsigned
+		return constValue{v: v, unsigned: n.Unsigned() || v.Cmp(maxInt64) > 0}.wrap(), nil
+	case lexer.CHAR:
+		v, err := lexer.CharValue(t.lit)
+		if err != nil {
+			return constValue{}, err
+		}
+		return constValue{v: big.NewInt(v)}, nil
+	case lexer.IDENT:
+		v, ok := e.resolve(t.lit)
+		if !ok {
+			return constValue{}, fmt.Errorf("undefined: %s", t.lit)
+		}
+		return constValue{v: new(big.Int).Set(v), unsigned: v.Cmp(maxInt64) > 0}, nil
+	case lexer.TRUE, lexer.FALSE:
+		return constBool(t.tok == lexer.TRUE), nil
+	case lexer.DECIMAL:
+		return constValue{}, fmt.Errorf("%s is not an integer constant", t.lit)
+	case lexer.EOF:
+		return constValue{}, fmt.Errorf("unexpected end of expression")
+	}
+	return constValue{}, fmt.Errorf("unexpected %q", t.lit)
+}
@@ -0,0 +1,89 @@
+package declparse
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestEvalInt(t *testing.T) {
+	consts := map[string]int64{
+		"NSFoo": 4,
+		"NSBar": 1 << 3,
+	}
+	lookup := func(name string) (*big.Int, bool) {
+		v, ok := consts[name]
+		if !ok {
+			return nil, false
+		}
+		return big.NewInt(v), true
+	}
+
+	tests := []struct {
+		expr string
+		v    string
+		err  bool
+	}{
+		{expr: "0", v: "0"},
+		{expr: "-1", v: "-1"},
+		{expr: "0xFFFFFFFF", v: "4294967295"},
+		{expr: "1U << 0", v: "1"},
+		{expr: "1UL<<3", v: "8"},
+		{expr: "1ULL << 63", v: "9223372036854775808"},
+		{expr: "1LL << 63", v: "-9223372036854775808"},
+		{expr: "~0UL", v: "18446744073709551615"},
+		{expr: "~0", v: "-1"},
+		{expr: "-1 | 0u", v: "18446744073709551615"},
+		{expr: "(NSUInteger)-1", v: "18446744073709551615"},
+		{expr: "(NSInteger)-1", v: "-1"},
+		{expr: "18446744073709551615", v: "18446744073709551615"},
+		{expr: "0777", v: "511"},
+		{expr: "0b1010", v: "10"},
+		{expr: "'icns'", v: "1768124019"},
+		{expr: "NSFoo | NSBar", v: "12"},
+		{expr: "(NSFoo)|(NSBar)", v: "12"},
+		{expr: "NSBar-1", v: "7"},
+		{expr: "NSBar - -1", v: "9"},
+		{expr: "2 + 3 * 4", v: "14"},
+		{expr: "(2 + 3) * 4", v: "20"},
+		{expr: "1 << 2 | 1", v: "5"},
+		{expr: "7 / 2 % 2", v: "1"},
+		{expr: "1 < 2 && 2 <= 2 || 0", v: "1"},
+		{expr: "!1", v: "0"},
+		{expr: "NSFoo == 4 ? 10 : 20", v: "10"},
+		{expr: "-1 < 0u", v: "0"},
+		{expr: "", err: true},
+		{expr: "1 +", err: true},
+		{expr: "(1", err: true},
+		{expr: "1 2", err: true},
+		{expr: "1 / 0", err: true},
+		{expr: "1 << 64", err: true},
+		{expr: "NSBaz", err: true},
+		{expr: "1.5", err: true},
+		{expr: `"foo"`, err: true},
+		{expr: "09", err: true},
+	}
+
+	for i, tt := range tests {
+		v, err := EvalInt(tt.expr, lookup)
+		if tt.err != (err != nil) {
+			t.Errorf("%d. %q: error: %v", i, tt.expr, err)
+		} else if err == nil && v.String() != tt.v {
+			t.Errorf("%d. %q: exp=%s got=%s", i, tt.expr, tt.v, v)
+		}
+	}
+}
+
+func FuzzEvalInt(f *testing.F) {
+	for _, s := range []string{"1ULL << 63", "(NSUInteger)-1", "~0 | 'icns'", "A ? 1 : 2 * (3 - B)"} {
+		f.Add(s)
+	}
+	lookup := func(name string) (*big.Int, bool) {
+		return big.NewInt(int64(len(name))), len(name) == 1
+	}
+	f.Fuzz(func(t *testing.T, expr string) {
+		v, err := EvalInt(expr, lookup)
+		if err == nil && (v.Cmp(minInt64) < 0 || v.Cmp(maxUint64) > 0) {
+			t.Errorf("%q: %s out of 64-bit range", expr, v)
+		}
+	})
+}
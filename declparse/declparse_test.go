@@ -15,6 +15,33 @@ var tests = []struct {
 		},
 	},
 
+	{
+		s: `@interface NSArray<__covariant ObjectType> : NSObject <NSCopying, NSFastEnumeration>`,
+		n: &InterfaceDecl{
+			Name:       "NSArray",
+			TypeParams: []string{"__covariant ObjectType"},
+			SuperName:  "NSObject",
+			Protocols:  []string{"NSCopying", "NSFastEnumeration"},
+		},
+	},
+
+	{
+		s: `@interface NSWindow (NSDrag) <NSDraggingDestination>`,
+		n: &InterfaceDecl{
+			Name:      "NSWindow",
+			Category:  "NSDrag",
+			Protocols: []string{"NSDraggingDestination"},
+		},
+	},
+
+	{
+		s: `@protocol NSCoding <NSObject>`,
+		n: &ProtocolDecl{
+			Name:      "NSCoding",
+			Protocols: []string{"NSObject"},
+		},
+	},
+
 	{
 		s: `+ (BOOL)menuBarVisible`,
 		n: &MethodDecl{
@@ -61,20 +88,28 @@ var tests = []struct {
 					{
 						Name: "withData",
 						Type: TypeInfo{
-							Name:     "NSArray",
-							IsPtr:    true,
-							IsPtrPtr: true,
-							Annots: map[TypeAnnotation]bool{
-								TypeAnnotNullable: true,
+							Kind: TypePointer,
+							Elem: &TypeInfo{
+								Kind: TypePointer,
+								Elem: &TypeInfo{
+									Name: "NSArray",
+								},
+								Annots: map[TypeAnnotation]bool{
+									TypeAnnotNullable: true,
+								},
 							},
 						},
 					},
 					{
 						Name: "error",
 						Type: TypeInfo{
-							Name:     "NSError",
-							IsPtr:    true,
-							IsPtrPtr: true,
+							Kind: TypePointer,
+							Elem: &TypeInfo{
+								Kind: TypePointer,
+								Elem: &TypeInfo{
+									Name: "NSError",
+								},
+							},
 						},
 					},
 				},
@@ -88,14 +123,18 @@ var tests = []struct {
 			Method: &MethodDecl{
 				TypeMethod: true,
 				ReturnType: TypeInfo{
-					Name: "NSArray",
-					Params: []TypeInfo{
-						{
-							Name:  "NSView",
-							IsPtr: true,
+					Kind: TypePointer,
+					Elem: &TypeInfo{
+						Name: "NSArray",
+						Params: []TypeInfo{
+							{
+								Kind: TypePointer,
+								Elem: &TypeInfo{
+									Name: "NSView",
+								},
+							},
 						},
 					},
-					IsPtr: true,
 					Annots: map[TypeAnnotation]bool{
 						TypeAnnotNullable: true,
 					},
@@ -105,8 +144,10 @@ var tests = []struct {
 					{
 						Name: "key",
 						Type: TypeInfo{
-							Name:  "NSString",
-							IsPtr: true,
+							Kind: TypePointer,
+							Elem: &TypeInfo{
+								Name: "NSString",
+							},
 							Annots: map[TypeAnnotation]bool{
 								TypeAnnotNonnull: true,
 							},
@@ -191,8 +232,10 @@ var tests = []struct {
 					{
 						Name: "sheetWindow",
 						Type: TypeInfo{
-							Name:  "NSWindow",
-							IsPtr: true,
+							Kind: TypePointer,
+							Elem: &TypeInfo{
+								Name: "NSWindow",
+							},
 						},
 					},
 					{
@@ -239,26 +282,32 @@ var tests = []struct {
 								Args: FuncArgs{
 									{
 										Type: TypeInfo{
-											Name:  "NSView",
-											IsPtr: true,
-											Annots: map[TypeAnnotation]bool{
-												TypeAnnotKindOf: true,
+											Kind: TypePointer,
+											Elem: &TypeInfo{
+												Name: "NSView",
+												Annots: map[TypeAnnotation]bool{
+													TypeAnnotKindOf: true,
+												},
 											},
 										},
 									},
 									{
 										Type: TypeInfo{
-											Name:  "NSView",
-											IsPtr: true,
-											Annots: map[TypeAnnotation]bool{
-												TypeAnnotKindOf: true,
+											Kind: TypePointer,
+											Elem: &TypeInfo{
+												Name: "NSView",
+												Annots: map[TypeAnnotation]bool{
+													TypeAnnotKindOf: true,
+												},
 											},
 										},
 									},
 									{
 										Type: TypeInfo{
-											Name:  "void",
-											IsPtr: true,
+											Kind: TypePointer,
+											Elem: &TypeInfo{
+												Name: "void",
+											},
 										},
 									},
 								},
@@ -323,11 +372,15 @@ var tests = []struct {
 					{
 						Name: "topLevelObjects",
 						Type: TypeInfo{
-							Name:     "NSArray",
-							IsPtr:    true,
-							IsPtrPtr: true,
-							Annots: map[TypeAnnotation]bool{
-								TypeAnnotNullUnspecified: true,
+							Kind: TypePointer,
+							Elem: &TypeInfo{
+								Kind: TypePointer,
+								Elem: &TypeInfo{
+									Name: "NSArray",
+								},
+								Annots: map[TypeAnnotation]bool{
+									TypeAnnotNullUnspecified: true,
+								},
 							},
 						},
 					},
@@ -341,8 +394,10 @@ var tests = []struct {
 		n: &Statement{
 			Method: &MethodDecl{
 				ReturnType: TypeInfo{
-					Name:  "NSColor",
-					IsPtr: true,
+					Kind: TypePointer,
+					Elem: &TypeInfo{
+						Name: "NSColor",
+					},
 				},
 				NameParts: []string{"blendedColorWithFraction", "ofColor"},
 				Args: []ArgInfo{
@@ -355,8 +410,10 @@ var tests = []struct {
 					{
 						Name: "color",
 						Type: TypeInfo{
-							Name:  "NSColor",
-							IsPtr: true,
+							Kind: TypePointer,
+							Elem: &TypeInfo{
+								Name: "NSColor",
+							},
 						},
 					},
 				},
@@ -423,8 +480,10 @@ var tests = []struct {
 					{
 						Name: "item",
 						Type: TypeInfo{
-							Name:  "NSStatusItem",
-							IsPtr: true,
+							Kind: TypePointer,
+							Elem: &TypeInfo{
+								Name: "NSStatusItem",
+							},
 						},
 					},
 				},
@@ -471,8 +530,10 @@ var tests = []struct {
 					PropAttrStrong:   "",
 				},
 				Type: TypeInfo{
-					Name:  "NSStatusBar",
-					IsPtr: true,
+					Kind: TypePointer,
+					Elem: &TypeInfo{
+						Name: "NSStatusBar",
+					},
 				},
 			},
 		},
@@ -487,10 +548,12 @@ var tests = []struct {
 					PropAttrReadonly: "",
 				},
 				Type: TypeInfo{
-					Name:  "void",
-					IsPtr: true,
-					Annots: map[TypeAnnotation]bool{
-						TypeAnnotConst: true,
+					Kind: TypePointer,
+					Elem: &TypeInfo{
+						Name: "void",
+						Annots: map[TypeAnnotation]bool{
+							TypeAnnotConst: true,
+						},
 					},
 				},
 			},
@@ -527,14 +590,18 @@ var tests = []struct {
 					PropAttrReadonly: "",
 				},
 				Type: TypeInfo{
-					Name:  "NSArray",
-					IsPtr: true,
-					Params: []TypeInfo{
-						{
-							Name:  "NSWindow",
-							IsPtr: true,
-							Annots: map[TypeAnnotation]bool{
-								TypeAnnotKindOf: true,
+					Kind: TypePointer,
+					Elem: &TypeInfo{
+						Name: "NSArray",
+						Params: []TypeInfo{
+							{
+								Kind: TypePointer,
+								Elem: &TypeInfo{
+									Name: "NSWindow",
+									Annots: map[TypeAnnotation]bool{
+										TypeAnnotKindOf: true,
+									},
+								},
 							},
 						},
 					},
@@ -552,10 +619,12 @@ var tests = []struct {
 					PropAttrWeak: "",
 				},
 				Type: TypeInfo{
-					Name:  "NSWindowController",
-					IsPtr: true,
-					Annots: map[TypeAnnotation]bool{
-						TypeAnnotKindOf: true,
+					Kind: TypePointer,
+					Elem: &TypeInfo{
+						Name: "NSWindowController",
+						Annots: map[TypeAnnotation]bool{
+							TypeAnnotKindOf: true,
+						},
 					},
 				},
 			},
@@ -573,8 +642,10 @@ var tests = []struct {
 					PropAttrNullable: "",
 				},
 				Type: TypeInfo{
-					Name:  "NSScreen",
-					IsPtr: true,
+					Kind: TypePointer,
+					Elem: &TypeInfo{
+						Name: "NSScreen",
+					},
 				},
 			},
 		},
@@ -589,8 +660,10 @@ var tests = []struct {
 					PropAttrStrong: "",
 				},
 				Type: TypeInfo{
-					Name:  "NSView",
-					IsPtr: true,
+					Kind: TypePointer,
+					Elem: &TypeInfo{
+						Name: "NSView",
+					},
 				},
 				IsOutlet: true,
 			},
@@ -609,14 +682,16 @@ var tests = []struct {
 					{
 						Name: "objects",
 						Type: TypeInfo{
-							Name:  "NSArray",
-							IsPtr: true,
-							Params: []TypeInfo{
-								{
-									Name: "id",
-									Params: []TypeInfo{
-										{
-											Name: "NSPasteboardWriting",
+							Kind: TypePointer,
+							Elem: &TypeInfo{
+								Name: "NSArray",
+								Params: []TypeInfo{
+									{
+										Name: "id",
+										Params: []TypeInfo{
+											{
+												Name: "NSPasteboardWriting",
+											},
 										},
 									},
 								},
@@ -629,7 +704,7 @@ var tests = []struct {
 	},
 
 	{
-		s: `+ (id)addLocalMonitorForEventsMatchingMask:(NSEventMask)mask handler:(NSEvent * _Nullable (^)(NSEvent * event))block;`,
+		s: `+ (id)addLocalMonitorForEventsMatchingMask:(NSEventMask)mask handler:(NSEvent * _Nullable (^)(NSEvent *event))block;`,
 		n: &Statement{
 			Method: &MethodDecl{
 				TypeMethod: true,
@@ -650,8 +725,10 @@ var tests = []struct {
 							Func: &FunctionDecl{
 								IsBlock: true,
 								ReturnType: TypeInfo{
-									Name:  "NSEvent",
-									IsPtr: true,
+									Kind: TypePointer,
+									Elem: &TypeInfo{
+										Name: "NSEvent",
+									},
 									Annots: map[TypeAnnotation]bool{
 										TypeAnnotNullable: true,
 									},
@@ -660,8 +737,10 @@ var tests = []struct {
 									{
 										Name: "event",
 										Type: TypeInfo{
-											Name:  "NSEvent",
-											IsPtr: true,
+											Kind: TypePointer,
+											Elem: &TypeInfo{
+												Name: "NSEvent",
+											},
 										},
 									},
 								},
@@ -712,6 +791,22 @@ var tests = []struct {
 		},
 	},
 
+	{
+		ParseOnly: true,
+		s:         `extern const NSURLResourceKey NSURLVolumeIsLocalKey;`,
+		n: &Statement{
+			Variable: &VariableDecl{
+				Name: "NSURLVolumeIsLocalKey",
+				Type: TypeInfo{
+					Name: "NSURLResourceKey",
+					Annots: map[TypeAnnotation]bool{
+						TypeAnnotConst: true,
+					},
+				},
+			},
+		},
+	},
+
 	{
 		ParseOnly: true,
 		Hint:      HintVariable,
@@ -720,8 +815,10 @@ var tests = []struct {
 			Variable: &VariableDecl{
 				Name: "NSAssertionHandlerKey",
 				Type: TypeInfo{
-					Name:  "NSString",
-					IsPtr: true,
+					Kind: TypePointer,
+					Elem: &TypeInfo{
+						Name: "NSString",
+					},
 					Annots: map[TypeAnnotation]bool{
 						TypeAnnotConst: true,
 					},
@@ -730,6 +827,142 @@ var tests = []struct {
 		},
 	},
 
+	{
+		Hint: HintVariable,
+		s:    `const char *const *NSArgv;`,
+		n: &Statement{
+			Variable: &VariableDecl{
+				Name: "NSArgv",
+				Type: TypeInfo{
+					Kind: TypePointer,
+					Elem: &TypeInfo{
+						Kind: TypePointer,
+						Elem: &TypeInfo{
+							Name: "char",
+							Annots: map[TypeAnnotation]bool{
+								TypeAnnotConst: true,
+							},
+						},
+						Annots: map[TypeAnnotation]bool{
+							TypeAnnotConst: true,
+						},
+					},
+				},
+			},
+		},
+	},
+
+	{
+		s:    `void f(int *restrict p);`,
+		Hint: HintFunction,
+		n: &Statement{
+			Function: &FunctionDecl{
+				Name: "f",
+				ReturnType: TypeInfo{
+					Name: "void",
+				},
+				Args: FuncArgs{
+					{
+						Name: "p",
+						Type: TypeInfo{
+							Kind: TypePointer,
+							Elem: &TypeInfo{
+								Name: "int",
+							},
+							Annots: map[TypeAnnotation]bool{
+								TypeAnnotRestrict: true,
+							},
+						},
+					},
+				},
+			},
+		},
+	},
+
+	{
+		s:    `void f(volatile int *p);`,
+		Hint: HintFunction,
+		n: &Statement{
+			Function: &FunctionDecl{
+				Name: "f",
+				ReturnType: TypeInfo{
+					Name: "void",
+				},
+				Args: FuncArgs{
+					{
+						Name: "p",
+						Type: TypeInfo{
+							Kind: TypePointer,
+							Elem: &TypeInfo{
+								Name: "int",
+								Annots: map[TypeAnnotation]bool{
+									TypeAnnotVolatile: true,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	},
+
+	{
+		Hint: HintVariable,
+		s:    `float NSMatrix[4][2];`,
+		n: &Statement{
+			Variable: &VariableDecl{
+				Name: "NSMatrix",
+				Type: TypeInfo{
+					Kind: TypeArray,
+					Len:  "4",
+					Elem: &TypeInfo{
+						Kind: TypeArray,
+						Len:  "2",
+						Elem: &TypeInfo{
+							Name: "float",
+						},
+					},
+				},
+			},
+		},
+	},
+
+	{
+		s: `- (void)getComponents:(CGFloat[4])components names:(char *[])names;`,
+		n: &Statement{
+			Method: &MethodDecl{
+				ReturnType: TypeInfo{
+					Name: "void",
+				},
+				NameParts: []string{"getComponents", "names"},
+				Args: []ArgInfo{
+					{
+						Name: "components",
+						Type: TypeInfo{
+							Kind: TypeArray,
+							Len:  "4",
+							Elem: &TypeInfo{
+								Name: "CGFloat",
+							},
+						},
+					},
+					{
+						Name: "names",
+						Type: TypeInfo{
+							Kind: TypeArray,
+							Elem: &TypeInfo{
+								Kind: TypePointer,
+								Elem: &TypeInfo{
+									Name: "char",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	},
+
 	{
 		ParseOnly: true,
 		Hint:      HintEnumCase,
@@ -742,6 +975,43 @@ var tests = []struct {
 		},
 	},
 
+	{
+		ParseOnly: true,
+		s: `typedef enum NSBitmapFormat : NSUInteger {
+			NSBitmapFormatAlphaFirst = 1 << 0,
+			NSBitmapFormatFloatingPointSamples = (1 << 2),
+			NSBitmapFormatSixteenBitBigEndian = 0x200UL,
+			NSBitmapFormatThirtyTwoBit = NSBitmapFormatAlphaFirst | 1ULL << 63
+		} NSBitmapFormat;`,
+		n: &Statement{
+			Enum: &EnumDecl{
+				Name: "NSBitmapFormat",
+				Type: TypeInfo{
+					Name: "NSUInteger",
+				},
+				Cases: []VariableDecl{
+					{
+						Name:  "NSBitmapFormatAlphaFirst",
+						Value: "1<<0",
+					},
+					{
+						Name:  "NSBitmapFormatFloatingPointSamples",
+						Value: "(1<<2)",
+					},
+					{
+						Name:  "NSBitmapFormatSixteenBitBigEndian",
+						Value: "0x200UL",
+					},
+					{
+						Name:  "NSBitmapFormatThirtyTwoBit",
+						Value: "NSBitmapFormatAlphaFirst|1ULL<<63",
+					},
+				},
+			},
+			Typedef: "NSBitmapFormat",
+		},
+	},
+
 	{
 		ParseOnly: true,
 		s: `typedef enum WKUserScriptInjectionTime : NSInteger {
@@ -758,13 +1028,42 @@ var tests = []struct {
 		},
 	},
 
+	{
+		ParseOnly: true,
+		s: `typedef enum NSWindowTitleVisibility : NSInteger {
+			NSWindowTitleVisible = 0,
+			NSWindowTitleHidden = 1,
+		};`,
+		n: &Statement{
+			Enum: &EnumDecl{
+				Name: "NSWindowTitleVisibility",
+				Type: TypeInfo{
+					Name: "NSInteger",
+				},
+				Cases: []VariableDecl{
+					{
+						Name:  "NSWindowTitleVisible",
+						Value: "0",
+					},
+					{
+						Name:  "NSWindowTitleHidden",
+						Value: "1",
+					},
+				},
+			},
+			Typedef: "NSWindowTitleVisibility",
+		},
+	},
+
 	{
 		ParseOnly: true,
 		s:         `typedef NSString *NSDeviceDescriptionKey;`,
 		n: &Statement{
 			TypeAlias: &TypeInfo{
-				Name:  "NSString",
-				IsPtr: true,
+				Kind: TypePointer,
+				Elem: &TypeInfo{
+					Name: "NSString",
+				},
 			},
 			Typedef: "NSDeviceDescriptionKey",
 		},
@@ -834,4 +1133,143 @@ var tests = []struct {
 			},
 		},
 	},
+
+	{
+		s:    `int (*signal(int sig, void (*func)(int)))(int);`,
+		Hint: HintFunction,
+		n: &Statement{
+			Function: &FunctionDecl{
+				Name: "signal",
+				ReturnType: TypeInfo{
+					Func: &FunctionDecl{
+						IsPtr: true,
+						ReturnType: TypeInfo{
+							Name: "int",
+						},
+						Args: FuncArgs{
+							{
+								Type: TypeInfo{
+									Name: "int",
+								},
+							},
+						},
+					},
+				},
+				Args: FuncArgs{
+					{
+						Name: "sig",
+						Type: TypeInfo{
+							Name: "int",
+						},
+					},
+					{
+						Name: "func",
+						Type: TypeInfo{
+							Func: &FunctionDecl{
+								IsPtr: true,
+								ReturnType: TypeInfo{
+									Name: "void",
+								},
+								Args: FuncArgs{
+									{
+										Type: TypeInfo{
+											Name: "int",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	},
+
+	{
+		s: `- (void)enumerateObjectsUsingBlock:(NS_NOESCAPE void (^)(id obj, BOOL *stop))block completion:(void (^ _Nullable)(void))completion;`,
+		n: &Statement{
+			Method: &MethodDecl{
+				ReturnType: TypeInfo{
+					Name: "void",
+				},
+				NameParts: []string{"enumerateObjectsUsingBlock", "completion"},
+				Args: []ArgInfo{
+					{
+						Name: "block",
+						Type: TypeInfo{
+							Annots: map[TypeAnnotation]bool{
+								TypeAnnotNoEscape: true,
+							},
+							Func: &FunctionDecl{
+								IsBlock: true,
+								ReturnType: TypeInfo{
+									Name: "void",
+								},
+								Args: FuncArgs{
+									{
+										Name: "obj",
+										Type: TypeInfo{
+											Name: "id",
+										},
+									},
+									{
+										Name: "stop",
+										Type: TypeInfo{
+											Kind: TypePointer,
+											Elem: &TypeInfo{
+												Name: "BOOL",
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+					{
+						Name: "completion",
+						Type: TypeInfo{
+							Annots: map[TypeAnnotation]bool{
+								TypeAnnotNullable: true,
+							},
+							Func: &FunctionDecl{
+								IsBlock: true,
+								ReturnType: TypeInfo{
+									Name: "void",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	},
+	{
+		s: `- (void)beginSheet:(NSWindow *)sheet completionHandler:(id)handler NS_SWIFT_NAME(beginSheet(_:completionHandler:));`,
+		n: &Statement{
+			Method: &MethodDecl{
+				ReturnType: TypeInfo{
+					Name: "void",
+				},
+				NameParts: []string{"beginSheet", "completionHandler"},
+				Args: []ArgInfo{
+					{
+						Name: "sheet",
+						Type: TypeInfo{
+							Kind: TypePointer,
+							Elem: &TypeInfo{
+								Name: "NSWindow",
+							},
+						},
+					},
+					{
+						Name: "handler",
+						Type: TypeInfo{
+							Name: "id",
+						},
+					},
+				},
+				SwiftName: "beginSheet(_:completionHandler:)",
+			},
+		},
+	},
 }
@@ -4,8 +4,9 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"strings"
+	"unicode"
 
-	"github.com/progrium/macschema/declparse/keywords"
 	"github.com/progrium/macschema/lexer"
 )
 
@@ -18,10 +19,39 @@ const (
 	HintFunction
 )
 
+var hints = map[Hint]string{
+	HintNone:     "none",
+	HintVariable: "variable",
+	HintEnumCase: "enumcase",
+	HintFunction: "function",
+}
+
+func (h Hint) String() string {
+	return hints[h]
+}
+
+// ParseHint returns the Hint named by s.
+func ParseHint(s string) (Hint, error) {
+	for h, name := range hints {
+		if s == name {
+			return h, nil
+		}
+	}
+	return HintNone, fmt.Errorf("unknown hint %q", s)
+}
+
 type Parser struct {
 	tb      *lexer.TokenBuffer
 	typedef bool
 	Hint    Hint
+
+	// DocComments attaches comments preceding a declaration to the
+	// Statement. Comments separated from it by a blank line are dropped.
+	DocComments bool
+
+	// AssumeNonnull is true inside an assume_nonnull region. It is updated
+	// by the pragmas NS_ASSUME_NONNULL_BEGIN and END expand to.
+	AssumeNonnull bool
 }
 
 func NewParser(r io.Reader) *Parser {
@@ -34,9 +64,77 @@ func NewStringParser(s string) *Parser {
 
 func (p *Parser) Parse() (*Statement, error) {
 	p.tb.IgnoreWhitespace = true
+	p.tb.IgnoreComments = true
+	p.typedef = false
+
+	// skip empty statements and directives left before the declaration.
+	// Only comments before its first token lead the declaration.
+	var comments []string
+	for {
+		tok, _, lit := p.tb.Scan()
+		comments = append(comments, p.tb.Comments()...)
+		if tok != lexer.SEMICOLON && tok != lexer.DIRECTIVE {
+			p.tb.Unscan()
+			break
+		}
+		if tok == lexer.DIRECTIVE {
+			p.pragma(lit)
+		}
+	}
+
+	stmt, err := p.parseStatement()
+	if err != nil {
+		return nil, err
+	}
+	if p.DocComments {
+		stmt.Comment = docComment(comments)
+	}
+	stmt.AssumeNonnull = p.AssumeNonnull
+	return stmt, nil
+}
+
+// pragma tracks assume_nonnull regions from a directive.
+func (p *Parser) pragma(directive string) {
+	f := strings.Fields(strings.TrimPrefix(directive, "#"))
+	if len(f) == 4 && f[0] == "pragma" && f[1] == "clang" && f[2] == "assume_nonnull" {
+		switch f[3] {
+		case "begin":
+			p.AssumeNonnull = true
+		case "end":
+			p.AssumeNonnull = false
+		}
+	}
+}
 
+func (p *Parser) parseStatement() (*Statement, error) {
+	// a Swift name may lead a type declaration
+	swiftName, err := p.parseSwiftName()
+	if err != nil {
+		return nil, err
+	}
+	stmt, err := p.parseDecl()
+	if err != nil {
+		return nil, err
+	}
+	if stmt.Enum != nil || stmt.Struct != nil || stmt.TypeAlias != nil {
+		// or follow it, as in typedef NS_ENUM(...) { ... } NS_SWIFT_NAME(Name);
+		if stmt.SwiftName, err = p.parseSwiftName(); err != nil {
+			return nil, err
+		}
+	}
+	if stmt.SwiftName == "" {
+		stmt.SwiftName = swiftName
+	}
+	return stmt, nil
+}
+
+func (p *Parser) parseDecl() (*Statement, error) {
+	// storage classes don't change the declaration
 	tok, _, lit := p.tb.Scan()
-	if tok == keywords.TYPEDEF {
+	for isStorageClass(tok) {
+		tok, _, lit = p.tb.Scan()
+	}
+	if tok == lexer.TYPEDEF {
 		p.typedef = true
 	} else {
 		p.tb.Unscan()
@@ -50,42 +148,50 @@ func (p *Parser) Parse() (*Statement, error) {
 			return nil, err
 		}
 		return &Statement{Method: decl.(*MethodDecl)}, nil
-	case keywords.PROPERTY:
+	case lexer.PROPERTY:
 		decl, err := p.parse(parseProperty)
 		if err != nil {
 			return nil, err
 		}
 		return &Statement{Property: decl.(*PropertyDecl)}, nil
-	case keywords.INTERFACE:
+	case lexer.INTERFACE:
 		decl, err := p.parse(parseInterface)
 		if err != nil {
 			return nil, err
 		}
 		return &Statement{Interface: decl.(*InterfaceDecl)}, nil
-	case keywords.PROTOCOL:
+	case lexer.PROTOCOL:
 		decl, err := p.parse(parseProtocol)
 		if err != nil {
 			return nil, err
 		}
 		return &Statement{Protocol: decl.(*ProtocolDecl)}, nil
-	case keywords.ENUM:
+	case lexer.ENUM:
 		decl, err := p.parse(parseEnum)
 		if err != nil {
 			return nil, err
 		}
-		return &Statement{Enum: decl.(*EnumDecl), Typedef: p.finishTypedef()}, nil
-	case keywords.CONST:
+		name, err := p.finishTypedef(decl.(*EnumDecl).Name)
+		if err != nil {
+			return nil, err
+		}
+		return &Statement{Enum: decl.(*EnumDecl), Typedef: name}, nil
+	case lexer.CONST:
 		decl, err := p.parse(parseVariable)
 		if err != nil {
 			return nil, err
 		}
 		return &Statement{Variable: decl.(*VariableDecl)}, nil
-	case keywords.STRUCT:
+	case lexer.STRUCT:
 		decl, err := p.parse(parseStruct)
 		if err != nil {
 			return nil, err
 		}
-		return &Statement{Struct: decl.(*StructDecl), Typedef: p.finishTypedef()}, nil
+		name, err := p.finishTypedef(decl.(*StructDecl).Name)
+		if err != nil {
+			return nil, err
+		}
+		return &Statement{Struct: decl.(*StructDecl), Typedef: name}, nil
 	default:
 		if p.Hint == HintVariable {
 			decl, err := p.parse(parseVariable)
@@ -109,16 +215,63 @@ func (p *Parser) Parse() (*Statement, error) {
 			return &Statement{Function: decl.(*FunctionDecl)}, nil
 		}
 		if p.typedef {
-			ti, err := p.expectType(false)
+			name, ti, err := p.expectDecl(true)
 			if err != nil {
 				return nil, err
 			}
-			return &Statement{TypeAlias: ti, Typedef: p.finishTypedef()}, nil
+			if name == "" {
+				return nil, fmt.Errorf("expected typedef name after type %s", ti)
+			}
+			if ti.Func != nil {
+				// typedef void (^Name)(void);
+				ti.Func.Name = name
+			}
+			return &Statement{TypeAlias: ti, Typedef: name}, nil
 		}
 		return nil, fmt.Errorf("unable to parse start token: %s %s", tok, lit)
 	}
 }
 
+func isStorageClass(tok lexer.Token) bool {
+	switch tok {
+	case lexer.AUTO, lexer.EXTERN, lexer.INLINE, lexer.REGISTER, lexer.STATIC, lexer.THREADLOCAL:
+		return true
+	}
+	return false
+}
+
+// docComment returns the text of comments without comment markers.
+func docComment(comments []string) string {
+	var lines []string
+	for _, c := range comments {
+		if strings.HasPrefix(c, "//") {
+			c = strings.TrimLeft(c, "/")
+			lines = append(lines, strings.TrimPrefix(c, " "))
+			continue
+		}
+		c = strings.TrimSuffix(strings.TrimPrefix(c, "/*"), "*/")
+		c = strings.TrimLeft(c, "*!")
+		for _, line := range strings.Split(c, "\n") {
+			line = strings.TrimSpace(line)
+			line = strings.TrimSpace(strings.Trim(line, "*"))
+			lines = append(lines, line)
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// Normalize parses a declaration and renders it in canonical form.
+// Normalizing the result again gives the same string.
+func Normalize(decl string, hint Hint) (string, error) {
+	p := NewStringParser(decl)
+	p.Hint = hint
+	stmt, err := p.Parse()
+	if err != nil {
+		return "", err
+	}
+	return stmt.String(), nil
+}
+
 type stateFn func(*Parser) (stateFn, Node, error)
 
 func (p *Parser) parse(startState stateFn) (n Node, err error) {
@@ -128,12 +281,17 @@ func (p *Parser) parse(startState stateFn) (n Node, err error) {
 	return
 }
 
-func (p *Parser) finishTypedef() string {
+// finishTypedef returns the name declared by a typedef. A typedef of a
+// named enum or struct may leave the name out, which is how NS_ENUM
+// expands: typedef enum Name : Type { ... };
+func (p *Parser) finishTypedef(fallback string) (string, error) {
 	if p.typedef == false {
-		return ""
+		return "", nil
 	}
-	id, _ := p.expectIdent()
-	return id
+	if tok, _, lit := p.tb.Peek(); fallback != "" && (tok == lexer.SEMICOLON || tok == lexer.EOF || tok == lexer.IDENT && isSwiftName(lit)) {
+		return fallback, nil
+	}
+	return p.expectIdent()
 }
 
 func (p *Parser) expectToken(t lexer.Token) error {
@@ -144,10 +302,52 @@ func (p *Parser) expectToken(t lexer.Token) error {
 	return nil
 }
 
+// parseSwiftName parses NS_SWIFT_NAME(name) or CF_SWIFT_NAME(name), if it
+// comes next, and returns the name.
+func (p *Parser) parseSwiftName() (string, error) {
+	if tok, _, lit := p.tb.Scan(); tok != lexer.IDENT || !isSwiftName(lit) {
+		p.tb.Unscan()
+		return "", nil
+	}
+	if err := p.expectToken(lexer.LPAREN); err != nil {
+		return "", err
+	}
+	name, err := p.scanExpr(lexer.RPAREN)
+	if err != nil {
+		return "", err
+	}
+	if err := p.expectToken(lexer.RPAREN); err != nil {
+		return "", err
+	}
+	if name == "" {
+		return "", fmt.Errorf("expected name in NS_SWIFT_NAME")
+	}
+	return name, nil
+}
+
+func isSwiftName(lit string) bool {
+	return lit == "NS_SWIFT_NAME" || lit == "CF_SWIFT_NAME"
+}
+
 func (p *Parser) expectIdent() (string, error) {
 	tok, pos, lit := p.tb.Scan()
-	if tok != lexer.IDENT {
+	if tok != lexer.IDENT || !isIdent(lit) {
 		return "", fmt.Errorf("found %q, expected identifier at %v", lit, pos)
 	}
 	return lit, nil
 }
+
+// isIdent reports whether s is a C identifier. The scanner also produces
+// IDENT tokens for quoted names, which are not valid in declarations.
+func isIdent(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, ch := range s {
+		if ch == '_' || unicode.IsLetter(ch) || i > 0 && unicode.IsDigit(ch) {
+			continue
+		}
+		return false
+	}
+	return true
+}
@@ -3,14 +3,13 @@ package declparse
 import (
 	"fmt"
 
-	"github.com/progrium/macschema/declparse/keywords"
 	"github.com/progrium/macschema/lexer"
 )
 
 func parseEnum(p *Parser) (next stateFn, node Node, err error) {
 	decl := &EnumDecl{}
 
-	if err := p.expectToken(keywords.ENUM); err != nil {
+	if err := p.expectToken(lexer.ENUM); err != nil {
 		return nil, nil, err
 	}
 
@@ -33,22 +32,25 @@ func parseEnum(p *Parser) (next stateFn, node Node, err error) {
 		return nil, nil, err
 	}
 
-	if err = p.expectToken(lexer.VARARG); err != nil {
-		p.tb.Unscan()
-
+	if tok, _, _ := p.tb.Peek(); tok != lexer.VARARG && tok != lexer.RCURLY {
 		for {
 			enum := VariableDecl{}
 
 			if enum.Name, err = p.expectIdent(); err != nil {
-				p.tb.Unscan()
+				return nil, nil, err
+			}
+			if enum.SwiftName, err = p.parseSwiftName(); err != nil {
+				return nil, nil, err
 			}
 
 			if err := p.expectToken(lexer.EQ); err == nil {
-				tok, pos, lit := p.tb.Scan()
-				if tok != lexer.INTEGER && tok != lexer.DECIMAL {
-					return nil, nil, fmt.Errorf("found %q, expected numeric at %v", lit, pos)
+				if enum.Value, err = p.scanExpr(lexer.COMMA, lexer.RCURLY); err != nil {
+					return nil, nil, err
+				}
+				if enum.Value == "" {
+					tok, pos, lit := p.tb.Scan()
+					return nil, nil, fmt.Errorf("found %s (%q), expected value at %v", tok, lit, pos)
 				}
-				enum.Value = lit
 			} else {
 				p.tb.Unscan()
 			}
@@ -59,7 +61,13 @@ func parseEnum(p *Parser) (next stateFn, node Node, err error) {
 				p.tb.Unscan()
 				break
 			}
+			// the last case may have a trailing comma
+			if tok, _, _ := p.tb.Peek(); tok == lexer.RCURLY {
+				break
+			}
 		}
+	} else if tok == lexer.VARARG {
+		p.tb.Scan()
 	}
 
 	if err := p.expectToken(lexer.RCURLY); err != nil {
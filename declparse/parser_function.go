@@ -1,14 +1,18 @@
 package declparse
 
+import "fmt"
+
 func parseFunction(p *Parser) (next stateFn, node Node, err error) {
-	typ, err := p.expectType(false)
+	name, typ, err := p.expectDecl(true)
 	if err != nil {
 		return nil, nil, err
 	}
-
-	decl, err := p.expectFuncType(typ, true)
-	if err != nil {
+	if typ.Func == nil || typ.Func.IsBlock || typ.Func.IsPtr || name == "" {
+		return nil, nil, fmt.Errorf("expected function declaration, found %s", typ.Decl(name))
+	}
+	typ.Func.Name = name
+	if typ.Func.SwiftName, err = p.parseSwiftName(); err != nil {
 		return nil, nil, err
 	}
-	return nil, decl, nil
+	return nil, typ.Func, nil
 }
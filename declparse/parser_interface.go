@@ -1,14 +1,13 @@
 package declparse
 
 import (
-	"github.com/progrium/macschema/declparse/keywords"
 	"github.com/progrium/macschema/lexer"
 )
 
 func parseInterface(p *Parser) (next stateFn, node Node, err error) {
 	decl := &InterfaceDecl{}
 
-	if err := p.expectToken(keywords.INTERFACE); err != nil {
+	if err := p.expectToken(lexer.INTERFACE); err != nil {
 		return nil, nil, err
 	}
 
@@ -17,6 +16,33 @@ func parseInterface(p *Parser) (next stateFn, node Node, err error) {
 		return nil, nil, err
 	}
 
+	// lightweight generics
+	if tok, _, _ := p.tb.Scan(); tok == lexer.LT {
+		for {
+			param, err := p.expectIdent()
+			if err != nil {
+				return nil, nil, err
+			}
+			if param == "__covariant" || param == "__contravariant" {
+				name, err := p.expectIdent()
+				if err != nil {
+					return nil, nil, err
+				}
+				param += " " + name
+			}
+			decl.TypeParams = append(decl.TypeParams, param)
+			if tok, _, _ := p.tb.Scan(); tok != lexer.COMMA {
+				p.tb.Unscan()
+				break
+			}
+		}
+		if err := p.expectToken(lexer.GT); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		p.tb.Unscan()
+	}
+
 	if tok, _, _ := p.tb.Scan(); tok == lexer.COLON {
 		if decl.SuperName, err = p.expectIdent(); err != nil {
 			return nil, nil, err
@@ -25,5 +51,23 @@ func parseInterface(p *Parser) (next stateFn, node Node, err error) {
 		p.tb.Unscan()
 	}
 
+	// category or extension
+	if tok, _, _ := p.tb.Scan(); tok == lexer.LPAREN {
+		if tok, _, _ := p.tb.Peek(); tok == lexer.RPAREN {
+			decl.Extension = true
+		} else if decl.Category, err = p.expectIdent(); err != nil {
+			return nil, nil, err
+		}
+		if err := p.expectToken(lexer.RPAREN); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		p.tb.Unscan()
+	}
+
+	if decl.Protocols, err = p.expectProtocols(); err != nil {
+		return nil, nil, err
+	}
+
 	return nil, decl, nil
 }
@@ -57,7 +57,7 @@ func parseMethod(p *Parser) (next stateFn, node Node, err error) {
 				p.tb.Unscan()
 			}
 
-			if tok, pos, lit = p.tb.Scan(); tok == lexer.IDENT {
+			if tok, pos, lit = p.tb.Scan(); tok == lexer.IDENT && !isSwiftName(lit) {
 				decl.NameParts = append(decl.NameParts, lit)
 
 				if err := p.expectToken(lexer.COLON); err != nil {
@@ -72,5 +72,9 @@ func parseMethod(p *Parser) (next stateFn, node Node, err error) {
 		p.tb.Unscan()
 	}
 
+	if decl.SwiftName, err = p.parseSwiftName(); err != nil {
+		return nil, nil, err
+	}
+
 	return nil, decl, nil
 }
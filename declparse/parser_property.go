@@ -3,14 +3,13 @@ package declparse
 import (
 	"fmt"
 
-	"github.com/progrium/macschema/declparse/keywords"
 	"github.com/progrium/macschema/lexer"
 )
 
 func parseProperty(p *Parser) (next stateFn, node Node, err error) {
 	decl := &PropertyDecl{Attrs: make(map[PropAttr]string)}
 
-	if err := p.expectToken(keywords.PROPERTY); err != nil {
+	if err := p.expectToken(lexer.PROPERTY); err != nil {
 		return nil, nil, err
 	}
 
@@ -57,14 +56,16 @@ func parseProperty(p *Parser) (next stateFn, node Node, err error) {
 		p.tb.Unscan()
 	}
 
-	typ, err := p.expectType(false)
+	name, typ, err := p.expectDecl(true)
 	if err != nil {
 		return nil, nil, err
 	}
+	if decl.Name = name; name == "" {
+		return nil, nil, fmt.Errorf("expected property name after type %s", typ)
+	}
 	decl.Type = *typ
 
-	decl.Name, err = p.expectIdent()
-	if err != nil {
+	if decl.SwiftName, err = p.parseSwiftName(); err != nil {
 		return nil, nil, err
 	}
 
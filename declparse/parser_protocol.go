@@ -1,14 +1,13 @@
 package declparse
 
 import (
-	"github.com/progrium/macschema/declparse/keywords"
 	"github.com/progrium/macschema/lexer"
 )
 
 func parseProtocol(p *Parser) (next stateFn, node Node, err error) {
 	decl := &ProtocolDecl{}
 
-	if err := p.expectToken(keywords.PROTOCOL); err != nil {
+	if err := p.expectToken(lexer.PROTOCOL); err != nil {
 		return nil, nil, err
 	}
 
@@ -25,5 +24,32 @@ func parseProtocol(p *Parser) (next stateFn, node Node, err error) {
 		p.tb.Unscan()
 	}
 
+	if decl.Protocols, err = p.expectProtocols(); err != nil {
+		return nil, nil, err
+	}
+
 	return nil, decl, nil
 }
+
+// expectProtocols parses an optional list of adopted protocols: <A, B>.
+func (p *Parser) expectProtocols() (protocols []string, err error) {
+	if tok, _, _ := p.tb.Scan(); tok != lexer.LT {
+		p.tb.Unscan()
+		return nil, nil
+	}
+	for {
+		name, err := p.expectIdent()
+		if err != nil {
+			return nil, err
+		}
+		protocols = append(protocols, name)
+		if tok, _, _ := p.tb.Scan(); tok != lexer.COMMA {
+			p.tb.Unscan()
+			break
+		}
+	}
+	if err := p.expectToken(lexer.GT); err != nil {
+		return nil, err
+	}
+	return protocols, nil
+}
@@ -1,14 +1,13 @@
 package declparse
 
 import (
-	"github.com/progrium/macschema/declparse/keywords"
 	"github.com/progrium/macschema/lexer"
 )
 
 func parseStruct(p *Parser) (next stateFn, node Node, err error) {
 	decl := &StructDecl{}
 
-	if err := p.expectToken(keywords.STRUCT); err != nil {
+	if err := p.expectToken(lexer.STRUCT); err != nil {
 		return nil, nil, err
 	}
 
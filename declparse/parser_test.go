@@ -55,3 +55,90 @@ func normalizeStmntNode(n Node) *Statement {
 	}
 	return stmt
 }
+
+// Parsing arbitrary input under any hint must return an error rather than
+// panic, and whatever parses must render.
+func FuzzParser(f *testing.F) {
+	for _, tt := range tests {
+		f.Add(normalizeStmntString(tt.s))
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		for hint := range hints {
+			p := NewStringParser(s)
+			p.Hint = hint
+			stmt, err := p.Parse()
+			if err != nil {
+				continue
+			}
+			_ = stmt.String()
+		}
+	})
+}
+
+func TestParser_DocComments(t *testing.T) {
+	src := `#import <AppKit/NSWindow.h>
+
+// Dropped, since a blank line follows.
+
+/// Moves the window to the front.
+/// Does not make it key.
+- (void)orderFront:(id)sender;
+/*!
+ * @abstract The window title.
+ */
+@property(copy) NSString *title; // trailing comments are dropped
+- (void)orderBack:(id)sender /* and so are comments inside a declaration */
+    // even on their own line
+    animate:(BOOL)flag;
+// Only comments before the first token lead a declaration.
+const NSInteger NSKeyCode = 'a';
+`
+	want := []struct {
+		comment string
+		str     string
+	}{
+		{"Moves the window to the front.\nDoes not make it key.", "- (void)orderFront:(id)sender;"},
+		{"@abstract The window title.", "@property(copy) NSString *title;"},
+		{"", "- (void)orderBack:(id)sender animate:(BOOL)flag;"},
+		{"Only comments before the first token lead a declaration.", "const NSInteger NSKeyCode = 'a';"},
+	}
+
+	p := NewStringParser(src)
+	p.DocComments = true
+	for _, w := range want {
+		stmt, err := p.Parse()
+		if err != nil {
+			t.Fatal("parse:", err)
+		}
+		if stmt.Comment != w.comment {
+			t.Errorf("comment:\n  got: %q\n want: %q", stmt.Comment, w.comment)
+		}
+		stmt.Comment = ""
+		if got := stmt.String(); got != w.str {
+			t.Errorf("String()\n  got: %s\n want: %s", got, w.str)
+		}
+	}
+}
+
+func TestParser_AssumeNonnull(t *testing.T) {
+	src := `@property(copy) NSString *before;
+#pragma clang assume_nonnull begin
+#pragma mark - Properties
+@property(copy) NSString *inside;
+- (void)close;
+#pragma clang assume_nonnull end
+@property(copy) NSString *after;
+`
+	want := []bool{false, true, true, false}
+
+	p := NewStringParser(src)
+	for i, w := range want {
+		stmt, err := p.Parse()
+		if err != nil {
+			t.Fatal("parse:", err)
+		}
+		if stmt.AssumeNonnull != w {
+			t.Errorf("%d: %s: AssumeNonnull=%v", i, stmt, stmt.AssumeNonnull)
+		}
+	}
+}
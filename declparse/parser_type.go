@@ -1,27 +1,53 @@
 package declparse
 
 import (
+	"fmt"
+
 	"github.com/progrium/macschema/lexer"
 )
 
+// expectType parses a type without a declared name, like a method argument
+// type: NSString *, void (^)(NSError *) or float[4].
 func (p *Parser) expectType(parens bool) (ti *TypeInfo, err error) {
-	ti = &TypeInfo{Annots: make(map[TypeAnnotation]bool)}
-
 	if parens {
 		if err := p.expectToken(lexer.LPAREN); err != nil {
 			return nil, err
 		}
 	}
 
-	// type annotations before name
+	if _, ti, err = p.expectDecl(false); err != nil {
+		return nil, err
+	}
+
+	if parens {
+		if err := p.expectToken(lexer.RPAREN); err != nil {
+			return nil, err
+		}
+	}
+
+	return ti, nil
+}
+
+// expectDecl parses a type and a declarator that may declare a name, as
+// in int (*handler)(int). With named unset the declarator is abstract.
+func (p *Parser) expectDecl(named bool) (name string, ti *TypeInfo, err error) {
+	ti = &TypeInfo{Annots: make(map[TypeAnnotation]bool)}
+
+	// type annotations before name. Nullability qualifiers and NS_NOESCAPE
+	// apply to the declared type, which isn't known yet.
+	outer := make(map[TypeAnnotation]bool)
 	for {
 		tok, _, lit := p.tb.Scan()
 		if lit == "" {
 			lit = tok.String()
 		}
 
-		if annot, ok := isTypeAnnot(lit); ok {
+		if annot, ok := isTypeAnnot(lit); ok && annot != TypeAnnotNoEscape {
 			ti.Annots[annot] = true
+		} else if ok {
+			outer[annot] = true
+		} else if annot, ok := outerQualifiers[lit]; ok {
+			outer[annot] = true
 		} else {
 			p.tb.Unscan()
 			break
@@ -30,7 +56,7 @@ func (p *Parser) expectType(parens bool) (ti *TypeInfo, err error) {
 
 	// type name
 	if ti.Name, err = p.expectIdent(); err != nil {
-		return nil, err
+		return "", nil, err
 	}
 	if ti.Name == "long" {
 		if _, _, lit := p.tb.Scan(); lit == "long" {
@@ -47,7 +73,7 @@ func (p *Parser) expectType(parens bool) (ti *TypeInfo, err error) {
 		for {
 			typ, err := p.expectType(false)
 			if err != nil {
-				return nil, err
+				return "", nil, err
 			}
 			ti.Params = append(ti.Params, *typ)
 
@@ -58,7 +84,7 @@ func (p *Parser) expectType(parens bool) (ti *TypeInfo, err error) {
 		}
 
 		if err := p.expectToken(lexer.GT); err != nil {
-			return nil, err
+			return "", nil, err
 		}
 
 		p.tb.OneRuneOperators(false)
@@ -66,58 +92,199 @@ func (p *Parser) expectType(parens bool) (ti *TypeInfo, err error) {
 		p.tb.Unscan()
 	}
 
-	// pointer
-	if tok, _, _ := p.tb.Scan(); tok == lexer.MUL {
-		ti.IsPtr = true
-	} else if tok == lexer.POW {
-		ti.IsPtr = true
-		ti.IsPtrPtr = true
-	} else {
-		p.tb.Unscan()
+	// type annotations after name
+	p.expectAnnots(ti.Annots)
+
+	name, apply, err := p.declarator(named)
+	if err != nil {
+		return "", nil, err
 	}
+	if ti, err = apply(ti); err != nil {
+		return "", nil, err
+	}
+	if ti.Annots == nil {
+		ti.Annots = make(map[TypeAnnotation]bool)
+	}
+	for annot := range outer {
+		ti.Annots[annot] = true
+	}
+	return name, ti, nil
+}
 
-	// type annotations after name
+// expectAnnots adds the type annotations that come next to annots.
+func (p *Parser) expectAnnots(annots map[TypeAnnotation]bool) {
 	for {
 		tok, _, lit := p.tb.Scan()
 		if lit == "" {
 			lit = tok.String()
 		}
 
-		if annot, ok := isTypeAnnot(lit); ok {
-			ti.Annots[annot] = true
+		if annot, ok := isTypeAnnot(lit); ok && annot != TypeAnnotNoEscape {
+			annots[annot] = true
 		} else {
+			p.tb.Unscan()
+			return
+		}
+	}
+}
+
+// declarator parses the part of a declaration after the base type: pointers
+// and blocks with their qualifiers, then a name or a declarator nested in
+// parentheses, then array and parameter list suffixes. It returns a
+// function that applies the declarator to the base type, inside out, so in
+// int *(*name)[4] name is a pointer to an array of pointers to int.
+func (p *Parser) declarator(named bool) (name string, apply func(*TypeInfo) (*TypeInfo, error), err error) {
+	type ptr struct {
+		tok    lexer.Token
+		annots map[TypeAnnotation]bool
+	}
+	var ptrs []ptr
+	for {
+		tok, _, _ := p.tb.Scan()
+		if tok != lexer.MUL && tok != lexer.XOR {
 			p.tb.Unscan()
 			break
 		}
+		level := ptr{tok: tok, annots: make(map[TypeAnnotation]bool)}
+		p.expectAnnots(level.annots)
+		ptrs = append(ptrs, level)
 	}
 
-	// detect function type
-	if tok, _, _ := p.tb.Scan(); tok == lexer.LPAREN {
-		p.tb.Unscan()
-		ti.Func, err = p.expectFuncType(ti, false)
-		if err != nil {
-			return nil, err
+	inner := func(t *TypeInfo) (*TypeInfo, error) { return t, nil }
+	switch tok, _, lit := p.tb.Scan(); {
+	case tok == lexer.LPAREN && p.isNestedDeclarator():
+		if name, inner, err = p.declarator(named); err != nil {
+			return "", nil, err
 		}
-		ti.Name = ""
-		ti.IsPtr = false
-		ti.IsPtrPtr = false
-		ti.Annots = nil
-	} else {
+		if err := p.expectToken(lexer.RPAREN); err != nil {
+			return "", nil, err
+		}
+	case tok == lexer.IDENT && named && isIdent(lit):
+		name = lit
+	default:
 		p.tb.Unscan()
 	}
 
-	// pointer of pointer
-	if tok, _, _ := p.tb.Scan(); tok == lexer.MUL {
-		ti.IsPtrPtr = true
-	} else {
-		p.tb.Unscan()
+	var suffixes []func(*TypeInfo) *TypeInfo
+	for {
+		tok, _, _ := p.tb.Scan()
+		if tok == lexer.LBRACKET {
+			n, err := p.scanExpr(lexer.RBRACKET)
+			if err != nil {
+				return "", nil, err
+			}
+			if err := p.expectToken(lexer.RBRACKET); err != nil {
+				return "", nil, err
+			}
+			suffixes = append(suffixes, func(t *TypeInfo) *TypeInfo {
+				return &TypeInfo{Kind: TypeArray, Elem: t, Len: n}
+			})
+		} else if tok == lexer.LPAREN {
+			fn, err := p.expectParams()
+			if err != nil {
+				return "", nil, err
+			}
+			suffixes = append(suffixes, func(t *TypeInfo) *TypeInfo {
+				f := *fn
+				f.ReturnType = *t
+				return &TypeInfo{Func: &f}
+			})
+		} else {
+			p.tb.Unscan()
+			break
+		}
 	}
 
-	if parens {
-		if err := p.expectToken(lexer.RPAREN); err != nil {
+	apply = func(t *TypeInfo) (*TypeInfo, error) {
+		for _, level := range ptrs {
+			if t, err = pointerTo(t, level.tok, level.annots); err != nil {
+				return nil, err
+			}
+		}
+		for i := len(suffixes) - 1; i >= 0; i-- {
+			t = suffixes[i](t)
+		}
+		return inner(t)
+	}
+	return name, apply, nil
+}
+
+// isNestedDeclarator returns true if the tokens after an opening
+// parenthesis start a nested declarator rather than a parameter list.
+func (p *Parser) isNestedDeclarator() bool {
+	tok, _, _ := p.tb.Peek()
+	switch tok {
+	case lexer.MUL, lexer.XOR, lexer.LPAREN, lexer.LBRACKET:
+		return true
+	}
+	return false
+}
+
+// pointerTo returns a pointer to t, or for ^ a block. A pointer to a
+// function type is a function pointer.
+func pointerTo(t *TypeInfo, tok lexer.Token, annots map[TypeAnnotation]bool) (*TypeInfo, error) {
+	if t.isFunction() {
+		fn := *t.Func
+		if tok == lexer.XOR {
+			fn.IsBlock = true
+		} else {
+			fn.IsPtr = true
+		}
+		return &TypeInfo{Func: &fn, Annots: annots}, nil
+	}
+	if tok == lexer.XOR {
+		return nil, fmt.Errorf("found ^ before %s, expected a function type for block", t)
+	}
+	return &TypeInfo{Kind: TypePointer, Elem: t, Annots: annots}, nil
+}
+
+// expectParams parses a parameter list after its opening parenthesis. A
+// list of just void has no parameters.
+func (p *Parser) expectParams() (fn *FunctionDecl, err error) {
+	fn = &FunctionDecl{}
+
+	if tok, _, _ := p.tb.Scan(); tok == lexer.RPAREN {
+		return fn, nil
+	}
+	p.tb.Unscan()
+
+	for {
+		// Peek at the next token to check for '...'
+		if tok, _, _ := p.tb.Scan(); tok == lexer.VARARG {
+			fn.Variadic = true
+			break
+		} else {
+			p.tb.Unscan()
+		}
+
+		arg := ArgInfo{}
+		var typ *TypeInfo
+		if arg.Name, typ, err = p.expectDecl(true); err != nil {
 			return nil, err
 		}
+		arg.Type = *typ
+
+		if len(fn.Args) == 0 && arg.Name == "" && isVoid(arg.Type) {
+			if tok, _, _ := p.tb.Peek(); tok == lexer.RPAREN {
+				break
+			}
+		}
+
+		fn.Args = append(fn.Args, arg)
+
+		if tok, _, _ := p.tb.Scan(); tok != lexer.COMMA {
+			p.tb.Unscan()
+			break
+		}
 	}
 
-	return ti, nil
+	if err := p.expectToken(lexer.RPAREN); err != nil {
+		return nil, err
+	}
+
+	return fn, nil
+}
+
+func isVoid(t TypeInfo) bool {
+	return t.Kind == TypeNamed && t.Func == nil && t.Name == "void" && len(t.Annots) == 0
 }
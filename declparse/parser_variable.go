@@ -1,7 +1,9 @@
 package declparse
 
 import (
+	"fmt"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/progrium/macschema/lexer"
 )
@@ -9,14 +11,16 @@ import (
 func parseVariable(p *Parser) (next stateFn, node Node, err error) {
 	decl := &VariableDecl{}
 
-	typ, err := p.expectType(false)
+	name, typ, err := p.expectDecl(true)
 	if err != nil {
 		return nil, nil, err
 	}
+	if decl.Name = name; name == "" {
+		return nil, nil, fmt.Errorf("expected variable name after type %s", typ)
+	}
 	decl.Type = *typ
 
-	decl.Name, err = p.expectIdent()
-	if err != nil {
+	if decl.SwiftName, err = p.parseSwiftName(); err != nil {
 		return nil, nil, err
 	}
 
@@ -25,18 +29,9 @@ func parseVariable(p *Parser) (next stateFn, node Node, err error) {
 		return nil, decl, nil
 	}
 
-	var rest []string
-	for {
-		tok, _, lit := p.tb.Scan()
-		if tok == lexer.EOF || tok == lexer.SEMICOLON {
-			break
-		}
-		if lit == "" {
-			lit = tok.String()
-		}
-		rest = append(rest, lit)
+	if decl.Value, err = p.scanValue(); err != nil {
+		return nil, nil, err
 	}
-	decl.Value = strings.Join(rest, "")
 
 	return nil, decl, nil
 }
@@ -49,23 +44,97 @@ func parseEnumCase(p *Parser) (next stateFn, node Node, err error) {
 		return nil, nil, err
 	}
 
+	if decl.SwiftName, err = p.parseSwiftName(); err != nil {
+		return nil, nil, err
+	}
+
 	if err := p.expectToken(lexer.EQ); err != nil {
 		p.tb.Unscan()
 		return nil, decl, nil
 	}
 
-	var rest []string
+	if decl.Value, err = p.scanValue(); err != nil {
+		return nil, nil, err
+	}
+
+	return nil, decl, nil
+}
+
+// scanValue reads the rest of the statement as a value expression.
+func (p *Parser) scanValue() (string, error) {
+	v, err := p.scanExpr()
+	if err != nil {
+		return "", err
+	}
+	p.tb.Scan() // semicolon or EOF
+	return v, nil
+}
+
+// scanExpr reads a value expression up to EOF, a semicolon or, outside of any
+// brackets, one of the stop tokens. The token ending it is left unread.
+// Tokens are joined without whitespace except between words that were
+// separated in the source, and string literals are requoted so the value
+// can be parsed again.
+func (p *Parser) scanExpr(stop ...lexer.Token) (string, error) {
+	b := &strings.Builder{}
+	var last lexer.Token
+	var end lexer.Pos
+	var depth int
 	for {
-		tok, _, lit := p.tb.Scan()
-		if tok == lexer.EOF || tok == lexer.SEMICOLON {
+		tok, pos, lit := p.tb.Scan()
+		if tok == lexer.EOF || tok == lexer.SEMICOLON || depth <= 0 && isToken(tok, stop...) {
+			p.tb.Unscan()
 			break
 		}
+		switch tok {
+		case lexer.ILLEGAL, lexer.BADSTRING, lexer.BADESCAPE, lexer.DIRECTIVE:
+			return "", fmt.Errorf("found %s (%q) in value at %v", tok, lit, pos)
+		case lexer.IDENT:
+			if !isIdent(lit) {
+				return "", fmt.Errorf("found %s (%q) in value at %v", tok, lit, pos)
+			}
+		case lexer.LPAREN, lexer.LBRACKET, lexer.LCURLY:
+			depth++
+		case lexer.RPAREN, lexer.RBRACKET, lexer.RCURLY:
+			depth--
+		}
 		if lit == "" {
 			lit = tok.String()
 		}
-		rest = append(rest, lit)
+		if isWordToken(last) && isWordToken(tok) && pos != end {
+			b.WriteString(" ")
+		} else if last == lexer.DIV && (tok == lexer.MUL || tok == lexer.DIV) {
+			// don't start a comment
+			b.WriteString(" ")
+		}
+		end = lexer.Pos{Line: pos.Line, Char: pos.Char + utf8.RuneCountInString(lit)}
+		if tok == lexer.STRING {
+			lit = quoteString(lit)
+		} else if tok == lexer.ATSTRING {
+			lit = "@" + quoteString(lit)
+		} else if tok == lexer.CHAR {
+			lit = "'" + lit + "'"
+		}
+		b.WriteString(lit)
+		last = tok
 	}
-	decl.Value = strings.Join(rest, "")
+	return b.String(), nil
+}
 
-	return nil, decl, nil
+func isToken(tok lexer.Token, toks ...lexer.Token) bool {
+	for _, t := range toks {
+		if tok == t {
+			return true
+		}
+	}
+	return false
+}
+
+func isWordToken(tok lexer.Token) bool {
+	return tok == lexer.IDENT || tok == lexer.INTEGER || tok == lexer.DECIMAL || tok == lexer.CHAR || tok.IsKeyword()
+}
+
+func quoteString(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return `"` + r.Replace(s) + `"`
 }
@@ -0,0 +1,134 @@
+package preprocessor
+
+// DefaultSymbols are predefined for a macOS target by New.
+var DefaultSymbols = []string{
+	"__APPLE__=1",
+	"__MACH__=1",
+	"__OBJC__=1",
+	"__OBJC2__=1",
+	"__LP64__=1",
+	"__STDC__=1",
+	"TARGET_OS_MAC=1",
+	"TARGET_OS_OSX=1",
+	"TARGET_OS_IPHONE=0",
+	"TARGET_OS_IOS=0",
+	"TARGET_OS_TV=0",
+	"TARGET_OS_WATCH=0",
+	"TARGET_OS_MACCATALYST=0",
+	"TARGET_OS_SIMULATOR=0",
+}
+
+// builtinMacros reduce the Foundation and CoreFoundation annotation macros
+// to declarations the parser understands. Availability and Swift
+// annotations expand to nothing, typed enums to enums with a fixed
+// underlying type and nullability regions to the pragmas clang uses.
+// NS_NOESCAPE, CF_NOESCAPE and the Swift names given by NS_SWIFT_NAME
+// and CF_SWIFT_NAME expand to themselves for the parser.
+const builtinMacros = `
+#define __has_feature(x) 0
+#define __has_extension(x) 0
+#define __has_attribute(x) 0
+#define __has_builtin(x) 0
+#define __attribute__(x)
+#define __deprecated
+#define __unavailable
+
+#define NS_ENUM(_type, _name) enum _name : _type
+#define NS_OPTIONS(_type, _name) enum _name : _type
+#define NS_CLOSED_ENUM(_type, _name) enum _name : _type
+#define NS_ERROR_ENUM(_domain, _name) enum _name : NSInteger
+#define CF_ENUM(_type, _name) enum _name : _type
+#define CF_OPTIONS(_type, _name) enum _name : _type
+#define CF_CLOSED_ENUM(_type, _name) enum _name : _type
+
+#define NS_ASSUME_NONNULL_BEGIN _Pragma("clang assume_nonnull begin")
+#define NS_ASSUME_NONNULL_END _Pragma("clang assume_nonnull end")
+#define CF_ASSUME_NONNULL_BEGIN _Pragma("clang assume_nonnull begin")
+#define CF_ASSUME_NONNULL_END _Pragma("clang assume_nonnull end")
+#define CF_IMPLICIT_BRIDGING_ENABLED
+#define CF_IMPLICIT_BRIDGING_DISABLED
+#define CF_EXTERN_C_BEGIN
+#define CF_EXTERN_C_END
+
+#define FOUNDATION_EXPORT extern
+#define FOUNDATION_EXTERN extern
+#define FOUNDATION_STATIC_INLINE static inline
+#define APPKIT_EXTERN extern
+#define UIKIT_EXTERN extern
+#define CF_EXPORT extern
+#define CG_EXTERN extern
+#define CA_EXTERN extern
+#define WK_EXTERN extern
+#define NS_INLINE static inline
+#define CF_INLINE static inline
+#define CG_INLINE static inline
+
+#define API_AVAILABLE(...)
+#define API_UNAVAILABLE(...)
+#define API_DEPRECATED(...)
+#define API_DEPRECATED_WITH_REPLACEMENT(...)
+#define API_UNAVAILABLE_BEGIN(...)
+#define API_UNAVAILABLE_END
+#define API_AVAILABLE_BEGIN(...)
+#define API_AVAILABLE_END
+#define NS_AVAILABLE(...)
+#define NS_AVAILABLE_MAC(...)
+#define NS_AVAILABLE_IOS(...)
+#define NS_DEPRECATED(...)
+#define NS_DEPRECATED_MAC(...)
+#define NS_DEPRECATED_IOS(...)
+#define NS_CLASS_AVAILABLE(...)
+#define NS_CLASS_AVAILABLE_MAC(...)
+#define NS_CLASS_AVAILABLE_IOS(...)
+#define NS_CLASS_DEPRECATED(...)
+#define NS_CLASS_DEPRECATED_MAC(...)
+#define NS_ENUM_AVAILABLE(...)
+#define NS_ENUM_AVAILABLE_MAC(...)
+#define NS_ENUM_DEPRECATED(...)
+#define NS_ENUM_DEPRECATED_MAC(...)
+#define CF_AVAILABLE(...)
+#define CF_AVAILABLE_MAC(...)
+#define CF_DEPRECATED(...)
+#define CF_DEPRECATED_MAC(...)
+#define CF_ENUM_AVAILABLE(...)
+#define CG_AVAILABLE_STARTING(...)
+#define CG_AVAILABLE_BUT_DEPRECATED(...)
+#define NS_UNAVAILABLE
+#define NS_AUTOMATED_REFCOUNT_UNAVAILABLE
+#define NS_AUTOMATED_REFCOUNT_WEAK_UNAVAILABLE
+
+#define NS_SWIFT_NAME(_name) NS_SWIFT_NAME(_name)
+#define NS_SWIFT_UNAVAILABLE(_msg)
+#define NS_SWIFT_NOTHROW
+#define NS_SWIFT_SENDABLE
+#define NS_SWIFT_UI_ACTOR
+#define NS_REFINED_FOR_SWIFT
+#define NS_TYPED_ENUM
+#define NS_TYPED_EXTENSIBLE_ENUM
+#define NS_STRING_ENUM
+#define NS_EXTENSIBLE_STRING_ENUM
+#define CF_SWIFT_NAME(_name) CF_SWIFT_NAME(_name)
+#define CF_REFINED_FOR_SWIFT
+
+#define NS_DESIGNATED_INITIALIZER
+#define NS_REQUIRES_SUPER
+#define NS_REQUIRES_NIL_TERMINATION
+#define NS_ROOT_CLASS
+#define NS_NOESCAPE NS_NOESCAPE
+#define NS_FORMAT_FUNCTION(F, A)
+#define NS_FORMAT_ARGUMENT(A)
+#define NS_RETURNS_RETAINED
+#define NS_RETURNS_NOT_RETAINED
+#define NS_RETURNS_INNER_POINTER
+#define NS_RELEASES_ARGUMENT
+#define NS_VALID_UNTIL_END_OF_SCOPE
+#define CF_RETURNS_RETAINED
+#define CF_RETURNS_NOT_RETAINED
+#define CF_RELEASES_ARGUMENT
+#define CF_CONSUMED
+#define CF_NOESCAPE CF_NOESCAPE
+#define CF_FORMAT_FUNCTION(F, A)
+#define CF_BRIDGED_TYPE(T)
+#define CF_BRIDGED_MUTABLE_TYPE(T)
+#define NS_OBJECT_OVERRIDE_RETURNS_INNER_POINTER
+`
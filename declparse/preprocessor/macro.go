@@ -0,0 +1,354 @@
+package preprocessor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/progrium/macschema/lexer"
+)
+
+// Macro is a #define. Params is nil for an object-like macro.
+type Macro struct {
+	Name     string
+	Params   []string
+	Variadic bool // the last parameter collects the rest of the arguments
+	Body     string
+
+	body []token
+}
+
+// FunctionLike returns true if the macro takes arguments.
+func (m *Macro) FunctionLike() bool {
+	return m.Params != nil
+}
+
+// String returns the macro as a #define directive.
+func (m *Macro) String() string {
+	b := &strings.Builder{}
+	b.WriteString("#define ")
+	b.WriteString(m.Name)
+	if m.FunctionLike() {
+		params := append([]string{}, m.Params...)
+		if m.Variadic {
+			if last := len(params) - 1; params[last] == "__VA_ARGS__" {
+				params[last] = "..."
+			} else {
+				params[last] += "..."
+			}
+		}
+		fmt.Fprintf(b, "(%s)", strings.Join(params, ", "))
+	}
+	if m.Body != "" {
+		b.WriteString(" ")
+		b.WriteString(m.Body)
+	}
+	return b.String()
+}
+
+// ParseMacro parses the text of a #define directive after the keyword,
+// eg "MAX(a, b) ((a) > (b) ? (a) : (b))". A macro is function-like only
+// if the parameter list follows the name without whitespace.
+func ParseMacro(def string) (*Macro, error) {
+	def = strings.TrimSpace(stripComments(def))
+	end := strings.IndexFunc(def, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+	if end < 0 {
+		end = len(def)
+	}
+	m := &Macro{Name: def[:end]}
+	if m.Name == "" || unicode.IsDigit(rune(m.Name[0])) {
+		return nil, fmt.Errorf("invalid macro name in %q", def)
+	}
+	rest := def[end:]
+
+	if strings.HasPrefix(rest, "(") {
+		close := strings.IndexByte(rest, ')')
+		if close < 0 {
+			return nil, fmt.Errorf("missing ) in parameter list of %s", m.Name)
+		}
+		m.Params = []string{}
+		if list := strings.TrimSpace(rest[1:close]); list != "" {
+			for i, param := range strings.Split(list, ",") {
+				param = strings.TrimSpace(param)
+				if strings.HasSuffix(param, "...") {
+					if i != strings.Count(list, ",") {
+						return nil, fmt.Errorf("variadic parameter must be last in %s", m.Name)
+					}
+					m.Variadic = true
+					if param = strings.TrimSuffix(param, "..."); param == "" {
+						param = "__VA_ARGS__"
+					}
+				}
+				if param == "" || strings.IndexFunc(param, func(r rune) bool {
+					return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+				}) >= 0 {
+					return nil, fmt.Errorf("invalid parameter %q in %s", param, m.Name)
+				}
+				m.Params = append(m.Params, param)
+			}
+		}
+		rest = rest[close+1:]
+	}
+
+	m.Body = strings.TrimSpace(rest)
+	m.body = tokenize(m.Body)
+	return m, nil
+}
+
+// token is a preprocessing token and the source text it was scanned from.
+type token struct {
+	tok  lexer.Token
+	text string
+}
+
+func (t token) isSpace() bool {
+	return t.tok == lexer.WS || t.tok == lexer.COMMENT
+}
+
+var space = token{tok: lexer.WS, text: " "}
+
+// tokenize splits source text into tokens. A # is always punctuation,
+// since directives are handled before tokens reach macro expansion.
+func tokenize(src string) []token {
+	var toks []token
+	s := lexer.NewScanner(strings.NewReader(src))
+	for {
+		tok, _, lit := s.Scan()
+		if tok == lexer.EOF {
+			return toks
+		}
+		if tok == lexer.DIRECTIVE {
+			toks = append(toks, token{tok: lexer.HASH, text: "#"})
+			toks = append(toks, tokenize(lit[1:])...)
+			continue
+		}
+		if tok == lexer.COMMENT {
+			// comments are a space, as in translation phase 3
+			toks = append(toks, token{tok: lexer.WS, text: " "})
+			continue
+		}
+		toks = append(toks, token{tok: tok, text: tokenText(tok, lit)})
+	}
+}
+
+// tokenText returns the source text of a scanned token.
+func tokenText(tok lexer.Token, lit string) string {
+	switch tok {
+	case lexer.STRING:
+		return quote(lit)
+	case lexer.ATSTRING:
+		return "@" + quote(lit)
+	case lexer.CHAR:
+		return "'" + lit + "'"
+	case lexer.BADSTRING:
+		return `"` + lit
+	}
+	if lit != "" {
+		return lit
+	}
+	return tok.String()
+}
+
+func quote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return `"` + r.Replace(s) + `"`
+}
+
+func join(toks []token) string {
+	b := &strings.Builder{}
+	for _, t := range toks {
+		b.WriteString(t.text)
+	}
+	return b.String()
+}
+
+// trimSpace removes leading and trailing whitespace tokens.
+func trimSpace(toks []token) []token {
+	for len(toks) > 0 && toks[0].isSpace() {
+		toks = toks[1:]
+	}
+	for len(toks) > 0 && toks[len(toks)-1].isSpace() {
+		toks = toks[:len(toks)-1]
+	}
+	return toks
+}
+
+// nextToken returns the index of the first token at or after i that isn't
+// whitespace, or len(toks).
+func nextToken(toks []token, i int) int {
+	for i < len(toks) && toks[i].isSpace() {
+		i++
+	}
+	return i
+}
+
+// expand replaces macro invocations in toks. Macros in hide are not
+// expanded again, which stops recursion.
+func (p *Preprocessor) expand(toks []token, hide map[string]bool) []token {
+	var out []token
+	for i := 0; i < len(toks); i++ {
+		t := toks[i]
+		m, ok := p.Macros[t.text]
+		if !ok || hide[t.text] || t.tok != lexer.IDENT && !t.tok.IsKeyword() {
+			out = append(out, t)
+			continue
+		}
+
+		body := m.body
+		if m.FunctionLike() {
+			j := nextToken(toks, i+1)
+			if j == len(toks) || toks[j].tok != lexer.LPAREN {
+				out = append(out, t)
+				continue
+			}
+			args, end, ok := collectArgs(toks, j)
+			if !ok {
+				out = append(out, t)
+				continue
+			}
+			body = p.substitute(m, args, hide)
+			i = end
+		}
+
+		inner := map[string]bool{m.Name: true}
+		for name := range hide {
+			inner[name] = true
+		}
+		out = append(out, space)
+		out = append(out, p.expand(body, inner)...)
+		out = append(out, space)
+	}
+	return out
+}
+
+// collectArgs splits the arguments of a macro invocation starting at the
+// opening parenthesis at toks[i]. It returns the index of the closing
+// parenthesis, or false if there is none.
+func collectArgs(toks []token, i int) (args [][]token, end int, ok bool) {
+	depth := 0
+	start := i + 1
+	for j := i; j < len(toks); j++ {
+		switch toks[j].tok {
+		case lexer.LPAREN:
+			depth++
+		case lexer.RPAREN:
+			depth--
+			if depth == 0 {
+				args = append(args, trimSpace(toks[start:j]))
+				return args, j, true
+			}
+		case lexer.COMMA:
+			if depth == 1 {
+				args = append(args, trimSpace(toks[start:j]))
+				start = j + 1
+			}
+		}
+	}
+	return nil, 0, false
+}
+
+// substitute replaces the parameters in the body of m with args. Arguments
+// are macro expanded first unless they are operands of # or ##.
+func (p *Preprocessor) substitute(m *Macro, args [][]token, hide map[string]bool) []token {
+	params := map[string][]token{}
+	for i, param := range m.Params {
+		var arg []token
+		if m.Variadic && i == len(m.Params)-1 {
+			for j := i; j < len(args); j++ {
+				if j > i {
+					arg = append(arg, token{tok: lexer.COMMA, text: ","}, space)
+				}
+				arg = append(arg, args[j]...)
+			}
+		} else if i < len(args) {
+			arg = args[i]
+		}
+		params[param] = arg
+	}
+	isParam := func(t token) bool {
+		_, ok := params[t.text]
+		return ok && t.tok == lexer.IDENT
+	}
+	isPaste := func(i int) bool {
+		return i+1 < len(m.body) && m.body[i].tok == lexer.HASH && m.body[i+1].tok == lexer.HASH
+	}
+
+	var out []token
+	body := m.body
+	for i := 0; i < len(body); i++ {
+		t := body[i]
+		switch {
+		case isPaste(i):
+			// paste the last token before ## and the first after it
+			for len(out) > 0 && out[len(out)-1].isSpace() {
+				out = out[:len(out)-1]
+			}
+			j := nextToken(body, i+2)
+			if j == len(body) {
+				i = j
+				continue
+			}
+			right := []token{body[j]}
+			if isParam(body[j]) {
+				right = params[body[j].text]
+				// a comma pasted to empty variadic arguments is dropped
+				if len(right) == 0 && m.Variadic && body[j].text == m.Params[len(m.Params)-1] &&
+					len(out) > 0 && out[len(out)-1].tok == lexer.COMMA {
+					out = out[:len(out)-1]
+				}
+			}
+			if len(out) > 0 && len(right) > 0 {
+				left := out[len(out)-1]
+				out = append(out[:len(out)-1], tokenize(left.text+right[0].text)...)
+				right = right[1:]
+			}
+			out = append(out, right...)
+			i = j
+		case t.tok == lexer.HASH && m.FunctionLike():
+			j := nextToken(body, i+1)
+			if j < len(body) && isParam(body[j]) {
+				out = append(out, stringize(params[body[j].text]))
+				i = j
+				continue
+			}
+			out = append(out, t)
+		case isParam(t):
+			arg := params[t.text]
+			if isPaste(nextToken(body, i+1)) {
+				out = append(out, arg...)
+			} else {
+				out = append(out, p.expand(arg, hide)...)
+			}
+		default:
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// stringize returns the text of toks as a string literal, with whitespace
+// between tokens reduced to a single space.
+func stringize(toks []token) token {
+	b := &strings.Builder{}
+	for i, t := range toks {
+		if t.isSpace() {
+			if i > 0 && !toks[i-1].isSpace() {
+				b.WriteString(" ")
+			}
+			continue
+		}
+		b.WriteString(t.text)
+	}
+	return token{tok: lexer.STRING, text: quote(b.String())}
+}
+
+// unquote returns the contents of a string literal.
+func unquote(s string) string {
+	if u, err := strconv.Unquote(s); err == nil {
+		return u
+	}
+	return strings.Trim(s, `"`)
+}
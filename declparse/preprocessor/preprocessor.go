@@ -0,0 +1,440 @@
+// Package preprocessor implements enough of the C preprocessor to read
+// SDK headers: macro definition and expansion, conditional compilation and
+// #include/#import resolution. Its output is source text for a
+// lexer.TokenBuffer or declparse.Parser.
+package preprocessor
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/progrium/macschema/declparse"
+	"github.com/progrium/macschema/lexer"
+)
+
+// maxIncludeDepth limits nested includes, which catches include cycles in
+// headers without guards.
+const maxIncludeDepth = 200
+
+type Preprocessor struct {
+	Macros map[string]*Macro
+
+	// IncludePath is searched in order for #include and #import. A
+	// <Framework/Header.h> include is also looked up as
+	// Framework.framework/Headers/Header.h in each directory.
+	IncludePath []string
+
+	// Unresolved lists includes that weren't found on the search path.
+	// They are skipped.
+	Unresolved []string
+
+	conds      []cond
+	included   map[string]bool // files that are only included once
+	predefined map[string]bool // macros sources can't redefine
+	depth      int
+}
+
+// cond is the state of an #if group.
+type cond struct {
+	parent bool // enclosing group is active
+	active bool // current branch is active
+	taken  bool // a branch has been active
+	isElse bool // #else has been seen
+}
+
+// New returns a Preprocessor with the built in macros and DefaultSymbols
+// defined. Like symbols from Define, they take precedence over #define
+// and #undef in headers, so the SDK's own NS_ENUM doesn't replace the
+// one the parser understands.
+func New() *Preprocessor {
+	p := &Preprocessor{
+		Macros:     map[string]*Macro{},
+		included:   map[string]bool{},
+		predefined: map[string]bool{},
+	}
+	if _, err := p.Preprocess("<builtin>", strings.NewReader(builtinMacros)); err != nil {
+		panic(err)
+	}
+	for name := range p.Macros {
+		p.predefined[name] = true
+	}
+	for _, def := range DefaultSymbols {
+		if err := p.Define(def); err != nil {
+			panic(err)
+		}
+	}
+	return p
+}
+
+// Define defines a macro like the -D flag of a compiler: "NAME" defines
+// NAME as 1, "NAME=value" as value and "F(x)=body" a function-like macro.
+func (p *Preprocessor) Define(def string) error {
+	name, body := def, "1"
+	if i := strings.IndexByte(def, '='); i >= 0 {
+		name, body = def[:i], def[i+1:]
+	}
+	m, err := ParseMacro(name + " " + body)
+	if err != nil {
+		return err
+	}
+	p.Macros[m.Name] = m
+	p.predefined[m.Name] = true
+	return nil
+}
+
+// Undefine removes a macro.
+func (p *Preprocessor) Undefine(name string) {
+	delete(p.Macros, name)
+	delete(p.predefined, name)
+}
+
+// PreprocessFile preprocesses the file at path.
+func (p *Preprocessor) PreprocessFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	return p.Preprocess(path, f)
+}
+
+// Preprocess returns the source read from r with directives processed and
+// macros expanded. The name is used to resolve quoted includes and in
+// errors. Pragmas, including those produced by _Pragma, are kept on lines
+// of their own.
+func (p *Preprocessor) Preprocess(name string, r io.Reader) (string, error) {
+	out := &strings.Builder{}
+	if err := p.process(name, r, out); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+func (p *Preprocessor) process(name string, r io.Reader, out *strings.Builder) error {
+	s := lexer.NewScanner(r)
+	depth := len(p.conds)
+
+	var text []token
+	flush := func() {
+		p.writeText(out, p.expand(text, nil))
+		text = nil
+	}
+	for {
+		tok, pos, lit := s.Scan()
+		if tok == lexer.EOF {
+			break
+		}
+		if tok == lexer.DIRECTIVE {
+			flush()
+			if err := p.directive(name, lit, out); err != nil {
+				return fmt.Errorf("%s:%d: %w", name, pos.Line+1, err)
+			}
+			continue
+		}
+		if p.active() {
+			text = append(text, token{tok: tok, text: tokenText(tok, lit)})
+		}
+	}
+	flush()
+
+	if len(p.conds) > depth {
+		p.conds = p.conds[:depth]
+		return fmt.Errorf("%s: unterminated #if", name)
+	}
+	return nil
+}
+
+func (p *Preprocessor) active() bool {
+	return len(p.conds) == 0 || p.conds[len(p.conds)-1].active
+}
+
+// writeText writes expanded tokens, moving _Pragma operators to lines of
+// their own as #pragma directives.
+func (p *Preprocessor) writeText(out *strings.Builder, toks []token) {
+	for i := 0; i < len(toks); i++ {
+		t := toks[i]
+		if t.text == "_Pragma" {
+			j := nextToken(toks, i+1)
+			k := nextToken(toks, j+1)
+			l := nextToken(toks, k+1)
+			if l < len(toks) && toks[j].tok == lexer.LPAREN && toks[k].tok == lexer.STRING && toks[l].tok == lexer.RPAREN {
+				writeDirective(out, "#pragma "+unquote(toks[k].text))
+				i = l
+				continue
+			}
+		}
+		out.WriteString(t.text)
+	}
+}
+
+// writeDirective writes a directive on a line of its own.
+func writeDirective(out *strings.Builder, directive string) {
+	if s := out.String(); s != "" && !strings.HasSuffix(s, "\n") {
+		out.WriteString("\n")
+	}
+	out.WriteString(directive)
+	out.WriteString("\n")
+}
+
+// directive processes a directive line.
+func (p *Preprocessor) directive(name, line string, out *strings.Builder) error {
+	line = strings.TrimSpace(stripComments(strings.TrimPrefix(line, "#")))
+	keyword := line
+	if i := strings.IndexFunc(line, func(r rune) bool { return r == ' ' || r == '\t' || r == '(' || r == '<' || r == '"' }); i >= 0 {
+		keyword = line[:i]
+	}
+	arg := strings.TrimSpace(line[len(keyword):])
+
+	// conditionals are tracked in inactive groups too
+	switch keyword {
+	case "if", "ifdef", "ifndef":
+		c := cond{parent: p.active()}
+		if c.parent {
+			ok, err := p.condition(keyword, arg)
+			if err != nil {
+				return err
+			}
+			c.active, c.taken = ok, ok
+		}
+		p.conds = append(p.conds, c)
+		return nil
+	case "elif", "else", "endif":
+		if len(p.conds) == 0 {
+			return fmt.Errorf("#%s without #if", keyword)
+		}
+		c := &p.conds[len(p.conds)-1]
+		switch keyword {
+		case "endif":
+			p.conds = p.conds[:len(p.conds)-1]
+		case "else":
+			if c.isElse {
+				return fmt.Errorf("#else after #else")
+			}
+			c.isElse = true
+			c.active = c.parent && !c.taken
+			c.taken = c.taken || c.active
+		case "elif":
+			if c.isElse {
+				return fmt.Errorf("#elif after #else")
+			}
+			c.active = false
+			if c.parent && !c.taken {
+				ok, err := p.condition("if", arg)
+				if err != nil {
+					return err
+				}
+				c.active, c.taken = ok, ok
+			}
+		}
+		return nil
+	}
+	if !p.active() {
+		return nil
+	}
+
+	switch keyword {
+	case "define":
+		m, err := ParseMacro(arg)
+		if err != nil {
+			return err
+		}
+		if !p.predefined[m.Name] {
+			p.Macros[m.Name] = m
+		}
+	case "undef":
+		if !p.predefined[arg] {
+			p.Undefine(arg)
+		}
+	case "include", "include_next", "import":
+		return p.include(name, arg, keyword == "import", out)
+	case "pragma":
+		if arg == "once" {
+			p.included[name] = true
+			return nil
+		}
+		writeDirective(out, "#pragma "+arg)
+	case "error":
+		return fmt.Errorf("#error %s", arg)
+	}
+	// #warning, #line and unknown directives are ignored
+	return nil
+}
+
+// stripComments replaces the comments in a directive line with a space,
+// as translation phase 3 does. Comment markers in string and character
+// literals are left alone.
+func stripComments(line string) string {
+	var b strings.Builder
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			b.WriteByte(c)
+			if c == '\\' && i+1 < len(line) {
+				i++
+				b.WriteByte(line[i])
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+			b.WriteByte(c)
+		case strings.HasPrefix(line[i:], "//"):
+			return b.String()
+		case strings.HasPrefix(line[i:], "/*"):
+			end := strings.Index(line[i+2:], "*/")
+			if end < 0 {
+				return b.String()
+			}
+			b.WriteByte(' ')
+			i += end + 3
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// condition evaluates the condition of an #if, #ifdef or #ifndef.
+func (p *Preprocessor) condition(keyword, arg string) (bool, error) {
+	switch keyword {
+	case "ifdef", "ifndef":
+		_, ok := p.Macros[arg]
+		return ok == (keyword == "ifdef"), nil
+	}
+
+	// defined and __has_include are replaced before macro expansion
+	toks := tokenize(arg)
+	var pre []token
+	for i := 0; i < len(toks); i++ {
+		t := toks[i]
+		switch t.text {
+		case "defined":
+			j := nextToken(toks, i+1)
+			parens := j < len(toks) && toks[j].tok == lexer.LPAREN
+			if parens {
+				j = nextToken(toks, j+1)
+			}
+			if j == len(toks) {
+				return false, fmt.Errorf("missing macro name after defined in #if %s", arg)
+			}
+			_, ok := p.Macros[toks[j].text]
+			if parens {
+				if j = nextToken(toks, j+1); j == len(toks) || toks[j].tok != lexer.RPAREN {
+					return false, fmt.Errorf("missing ) after defined in #if %s", arg)
+				}
+			}
+			pre = append(pre, boolToken(ok))
+			i = j
+		case "__has_include", "__has_include_next":
+			j := nextToken(toks, i+1)
+			if j == len(toks) || toks[j].tok != lexer.LPAREN {
+				return false, fmt.Errorf("missing ( after %s in #if %s", t.text, arg)
+			}
+			args, end, ok := collectArgs(toks, j)
+			if !ok || len(args) != 1 {
+				return false, fmt.Errorf("invalid %s in #if %s", t.text, arg)
+			}
+			_, found := p.resolve("", join(args[0]))
+			pre = append(pre, boolToken(found))
+			i = end
+		default:
+			pre = append(pre, t)
+		}
+	}
+
+	// identifiers left after expansion are 0
+	zero := func(string) (*big.Int, bool) { return big.NewInt(0), true }
+	v, err := declparse.EvalInt(join(p.expand(pre, nil)), zero)
+	if err != nil {
+		return false, fmt.Errorf("#if %s: %w", arg, err)
+	}
+	return v.Sign() != 0, nil
+}
+
+func boolToken(b bool) token {
+	if b {
+		return token{tok: lexer.INTEGER, text: "1"}
+	}
+	return token{tok: lexer.INTEGER, text: "0"}
+}
+
+// include preprocesses an included file into out.
+func (p *Preprocessor) include(from, arg string, once bool, out *strings.Builder) error {
+	if !strings.HasPrefix(arg, "<") && !strings.HasPrefix(arg, `"`) {
+		// computed include
+		arg = strings.TrimSpace(join(p.expand(tokenize(arg), nil)))
+	}
+	path, ok := p.resolve(from, arg)
+	if !ok {
+		p.unresolved(arg)
+		return nil
+	}
+	if p.included[path] {
+		return nil
+	}
+	if once {
+		p.included[path] = true
+	}
+	if p.depth >= maxIncludeDepth {
+		return fmt.Errorf("#include nested too deeply")
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	p.depth++
+	defer func() { p.depth-- }()
+	return p.process(path, strings.NewReader(string(b)), out)
+}
+
+func (p *Preprocessor) unresolved(arg string) {
+	i := sort.SearchStrings(p.Unresolved, arg)
+	if i < len(p.Unresolved) && p.Unresolved[i] == arg {
+		return
+	}
+	p.Unresolved = append(p.Unresolved, "")
+	copy(p.Unresolved[i+1:], p.Unresolved[i:])
+	p.Unresolved[i] = arg
+}
+
+// resolve finds the file named by the argument of an include directive.
+// Quoted names are looked up next to the including file first.
+func (p *Preprocessor) resolve(from, arg string) (string, bool) {
+	var dirs []string
+	var name string
+	switch {
+	case len(arg) > 2 && arg[0] == '"' && arg[len(arg)-1] == '"':
+		name = arg[1 : len(arg)-1]
+		if from != "" {
+			dirs = append(dirs, filepath.Dir(from))
+		}
+	case len(arg) > 2 && arg[0] == '<' && arg[len(arg)-1] == '>':
+		name = arg[1 : len(arg)-1]
+	default:
+		return "", false
+	}
+	dirs = append(dirs, p.IncludePath...)
+
+	for _, dir := range dirs {
+		candidates := []string{filepath.Join(dir, name)}
+		if i := strings.IndexByte(name, '/'); i > 0 {
+			framework := name[:i] + ".framework"
+			candidates = append(candidates, filepath.Join(dir, framework, "Headers", name[i+1:]))
+		}
+		for _, path := range candidates {
+			if fi, err := os.Stat(path); err == nil && !fi.IsDir() {
+				return path, true
+			}
+		}
+	}
+	return "", false
+}
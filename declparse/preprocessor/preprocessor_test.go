@@ -0,0 +1,265 @@
+package preprocessor
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/progrium/macschema/declparse"
+)
+
+// normalize collapses whitespace and drops blank lines. Expansions keep
+// the spaces around them so tokens aren't joined.
+func normalize(s string) string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestPreprocess(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{
+			in:   "#define N 4\nint a[N];",
+			want: "int a[ 4 ];",
+		},
+		{
+			in:   "#define MAX(a, b) ((a) > (b) ? (a) : (b))\nx = MAX(1, MAX(2, 3));",
+			want: "x = ((1) > ( ((2) > (3) ? (2) : (3)) ) ? (1) : ( ((2) > (3) ? (2) : (3)) )) ;",
+		},
+		{
+			// function-like macros need arguments to expand
+			in:   "#define F(x) x\nint F;",
+			want: "int F;",
+		},
+		{
+			// a macro isn't expanded inside its own expansion
+			in:   "#define foo foo + 1\nfoo;",
+			want: "foo + 1 ;",
+		},
+		{
+			in:   "#define CAT(a, b) a ## b\n#define STR(x) #x\nCAT(NS, Window) STR(a  \"b\");",
+			want: `NSWindow "a \"b\"" ;`,
+		},
+		{
+			in:   "#define LOG(fmt, ...) log(fmt, ## __VA_ARGS__)\nLOG(\"a\"); LOG(\"b\", 1, 2);",
+			want: `log("a") ; log("b",1, 2) ;`,
+		},
+		{
+			in:   "#define ARGS(...) f(__VA_ARGS__)\nARGS(1, (2, 3));",
+			want: "f(1, (2, 3)) ;",
+		},
+		{
+			in: `typedef NS_ENUM(NSInteger, NSWindowTitleVisibility) {
+				NSWindowTitleVisible = 0,
+				NSWindowTitleHidden = 1,
+			} API_AVAILABLE(macos(10.10));`,
+			want: "typedef enum NSWindowTitleVisibility : NSInteger {\nNSWindowTitleVisible = 0,\nNSWindowTitleHidden = 1,\n} ;",
+		},
+		{
+			in:   "NS_ASSUME_NONNULL_BEGIN\n@property (readonly) NSString *title;\nNS_ASSUME_NONNULL_END",
+			want: "#pragma clang assume_nonnull begin\n@property (readonly) NSString *title;\n#pragma clang assume_nonnull end",
+		},
+		{
+			in:   "#if TARGET_OS_OSX\nmac\n#elif TARGET_OS_IPHONE\nios\n#else\nother\n#endif",
+			want: "mac",
+		},
+		{
+			in:   "#if TARGET_OS_IPHONE\nios\n#elif defined(__APPLE__) && !defined UNDEFINED\napple\n#endif",
+			want: "apple",
+		},
+		{
+			in:   "#ifdef UNDEFINED\n#if UNDEFINED(\nbroken\n#endif\n#else\nok\n#endif",
+			want: "ok",
+		},
+		{
+			// unknown identifiers are 0 in #if
+			in:   "#ifndef X\n#define X\n#endif\n#if MAC_OS_X_VERSION_MIN_REQUIRED >= 101500\nnew\n#else\nold\n#endif",
+			want: "old",
+		},
+		{
+			in:   "#define V 2\n#if V * 2 == 4\nfour\n#endif\n#undef V\n#ifdef V\nstill\n#endif",
+			want: "four",
+		},
+		{
+			in:   "#if __has_feature(objc_arc) || __has_include(<Missing/Missing.h>)\narc\n#endif",
+			want: "",
+		},
+		{
+			// headers can't redefine built in macros or symbols
+			in:   "#define NS_ENUM(_type, _name) enum _name\n#undef TARGET_OS_OSX\n#if TARGET_OS_OSX\ntypedef NS_ENUM(NSInteger, E) { A };\n#endif",
+			want: "typedef enum E : NSInteger { A };",
+		},
+		{
+			// NS_NOESCAPE is left for the parser, even where headers define it
+			in:   "#define NS_NOESCAPE __attribute__((noescape))\n- (void)f:(NS_NOESCAPE void (^)(void))b;",
+			want: "- (void)f:( NS_NOESCAPE void (^)(void))b;",
+		},
+		{
+			in:   "- (void)orderFront:(id)sender NS_SWIFT_NAME(orderFront(_:));",
+			want: "- (void)orderFront:(id)sender NS_SWIFT_NAME(orderFront(_:)) ;",
+		},
+		{
+			in:   "#define FOO 1 /* on */\n#ifdef FOO // enabled\nyes\n#else\nno\n#endif /* FOO */",
+			want: "yes",
+		},
+		{
+			// a comment in a macro body doesn't comment out the rest of the line
+			in:   "#define NSMax 10 // the max\n#define F(x) x /* twice */ * 2\nint a[NSMax]; int b = F(1); // done",
+			want: "int a[ 10 ]; int b = 1 * 2 ; // done",
+		},
+		{
+			in:   "#pragma mark - Section\n#warning ignored\nint x;",
+			want: "#pragma mark - Section\nint x;",
+		},
+	}
+	for _, tt := range tests {
+		p := New()
+		got, err := p.Preprocess("test.h", strings.NewReader(tt.in))
+		if err != nil {
+			t.Errorf("%q: %v", tt.in, err)
+			continue
+		}
+		if normalize(got) != tt.want {
+			t.Errorf("%q:\ngot:  %q\nwant: %q", tt.in, normalize(got), tt.want)
+		}
+	}
+}
+
+func TestPreprocess_Errors(t *testing.T) {
+	tests := []struct {
+		in  string
+		err string
+	}{
+		{in: "#if 1\nint x;", err: "test.h: unterminated #if"},
+		{in: "#endif", err: "test.h:1: #endif without #if"},
+		{in: "#if 1\n#else\n#else\n#endif", err: "test.h:3: #else after #else"},
+		{in: "int x;\n#error unsupported", err: "test.h:2: #error unsupported"},
+		{in: "#if 1 +\n#endif", err: "test.h:1: #if 1 +: unexpected end of expression"},
+		{in: "#define F(a\n", err: "test.h:1: missing ) in parameter list of F"},
+	}
+	for _, tt := range tests {
+		_, err := New().Preprocess("test.h", strings.NewReader(tt.in))
+		if err == nil || err.Error() != tt.err {
+			t.Errorf("%q: got error %v, want %q", tt.in, err, tt.err)
+		}
+	}
+}
+
+func TestDefine(t *testing.T) {
+	p := New()
+	for _, def := range []string{"TARGET_OS_OSX=0", "TARGET_OS_IPHONE", "SQUARE(x)=((x)*(x)) // squared"} {
+		if err := p.Define(def); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := p.Preprocess("test.h", strings.NewReader("#if TARGET_OS_OSX\nmac\n#elif TARGET_OS_IPHONE\nSQUARE(2)\n#endif"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if normalize(got) != "((2)*(2))" {
+		t.Fatalf("got %q", got)
+	}
+	if m := p.Macros["SQUARE"]; m.String() != "#define SQUARE(x) ((x)*(x))" {
+		t.Fatalf("got %q", m)
+	}
+}
+
+func TestPreprocess_Include(t *testing.T) {
+	dir, err := ioutil.TempDir("", "preprocessor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"AppKit.framework/Headers/AppKit.h":   "#import <AppKit/NSWindow.h>\n#import <AppKit/NSWindow.h>\n#include \"Local.h\"",
+		"AppKit.framework/Headers/NSWindow.h": "#define NS_WINDOW 1\n@interface NSWindow : NSResponder\n@end",
+		"AppKit.framework/Headers/Local.h":    "#pragma once\n#if NS_WINDOW\nlocal\n#endif\n#include \"Local.h\"",
+		"main.h":                              "#include <AppKit/AppKit.h>\n#include <Missing/Missing.h>\n#if __has_include(<AppKit/NSWindow.h>)\nfound\n#endif",
+	}
+	for name, src := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p := New()
+	p.IncludePath = []string{dir}
+	got, err := p.PreprocessFile(filepath.Join(dir, "main.h"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "@interface NSWindow : NSResponder\n@end\nlocal\nfound"
+	if normalize(got) != want {
+		t.Fatalf("got:  %q\nwant: %q", normalize(got), want)
+	}
+	if len(p.Unresolved) != 1 || p.Unresolved[0] != "<Missing/Missing.h>" {
+		t.Fatalf("unresolved: %v", p.Unresolved)
+	}
+}
+
+func TestPreprocess_Parse(t *testing.T) {
+	src := `
+#define MY_EXPORT FOUNDATION_EXPORT
+NS_ASSUME_NONNULL_BEGIN
+typedef NS_OPTIONS(NSUInteger, NSWindowStyleMask) {
+	NSWindowStyleMaskBorderless = 0,
+	NSWindowStyleMaskTitled = 1 << 0,
+} API_AVAILABLE(macos(10.12));
+MY_EXPORT NSString * const NSWindowDidBecomeKeyNotification API_AVAILABLE(macos(10.0));
+NS_ASSUME_NONNULL_END
+`
+	out, err := New().Preprocess("test.h", strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hints := []declparse.Hint{declparse.HintNone, declparse.HintVariable}
+	var got []string
+	for _, decl := range strings.SplitAfter(out, ";") {
+		var lines []string
+		for _, line := range strings.Split(decl, "\n") {
+			if !strings.HasPrefix(strings.TrimSpace(line), "#") {
+				lines = append(lines, line)
+			}
+		}
+		if decl = strings.TrimSpace(strings.Join(lines, "\n")); decl == "" {
+			continue
+		}
+		p := declparse.NewStringParser(decl)
+		p.Hint = hints[len(got)]
+		stmt, err := p.Parse()
+		if err != nil {
+			t.Fatalf("%q: %v", decl, err)
+		}
+		got = append(got, stmt.String())
+	}
+	want := []string{
+		"typedef enum NSWindowStyleMask : NSUInteger { NSWindowStyleMaskBorderless = 0, NSWindowStyleMaskTitled = 1<<0 } NSWindowStyleMask;",
+		"NSString *const NSWindowDidBecomeKeyNotification;",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func FuzzPreprocess(f *testing.F) {
+	f.Add("#define F(x, ...) #x x ## __VA_ARGS__\nF(a, b, c)")
+	f.Add("#if defined(A) || B(1)\n#elif 1\n#else\n#endif")
+	f.Add(builtinMacros + "typedef NS_ENUM(NSInteger, E) { A };")
+	f.Fuzz(func(t *testing.T, src string) {
+		_, _ = New().Preprocess("fuzz.h", strings.NewReader(src))
+	})
+}
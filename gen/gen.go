@@ -0,0 +1,126 @@
+// Package gen generates bindings to Apple APIs from macschema schemas.
+package gen
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/progrium/macschema/schema"
+)
+
+// comment returns text as a line comment, or nothing for empty text.
+func comment(text string) string {
+	if text == "" {
+		return ""
+	}
+	b := &strings.Builder{}
+	for _, line := range strings.Split(text, "\n") {
+		if line == "" {
+			b.WriteString("//\n")
+		} else {
+			b.WriteString("// " + line + "\n")
+		}
+	}
+	return b.String()
+}
+
+// doc returns the documentation of a declaration: its description, topic
+// URL and whether it is deprecated.
+func doc(name, description, topicURL string, deprecated bool) string {
+	var paras []string
+	if description != "" {
+		paras = append(paras, description)
+	}
+	if topicURL != "" {
+		paras = append(paras, topicURL)
+	}
+	if deprecated {
+		paras = append(paras, "Deprecated: "+name+" is deprecated.")
+	}
+	return strings.Join(paras, "\n\n")
+}
+
+// declDoc is the documentation of a declaration, for generators that
+// write it in their own comment syntax.
+type declDoc struct {
+	Description string
+	TopicURL    string
+	Deprecated  bool
+}
+
+// uniqueSchemas returns schemas with each declaration once. The enums and
+// functions of an API collection are left out of it when they have a
+// schema of their own or are in a collection before it, and schemas of a
+// declaration already in the list are left out. Protocols are named apart
+// from other declarations, like NSObject.
+func uniqueSchemas(schemas []schema.Schema) []schema.Schema {
+	key := func(s schema.Schema, name string) string {
+		if s.Protocol != nil {
+			return "@protocol " + name
+		}
+		return name
+	}
+	own := make(map[string]bool)
+	for _, s := range schemas {
+		if s.APICollection == nil {
+			own[key(s, s.Identifier().Name)] = true
+		}
+	}
+	var out []schema.Schema
+	seen := make(map[string]bool)
+	for _, s := range schemas {
+		if s.APICollection == nil {
+			if k := key(s, s.Identifier().Name); !seen[k] {
+				seen[k] = true
+				out = append(out, s)
+			}
+			continue
+		}
+		ac := *s.APICollection
+		ac.Enums, ac.Functions = nil, nil
+		for _, en := range s.APICollection.Enums {
+			if !own[en.Name] && !seen[en.Name] {
+				seen[en.Name] = true
+				ac.Enums = append(ac.Enums, en)
+			}
+		}
+		for _, fn := range s.APICollection.Functions {
+			if !own[fn.Name] && !seen[fn.Name] {
+				seen[fn.Name] = true
+				ac.Functions = append(ac.Functions, fn)
+			}
+		}
+		s.APICollection = &ac
+		out = append(out, s)
+	}
+	return out
+}
+
+// exported returns name with its first letter in upper case. Names that
+// don't start with a letter get an X in front.
+func exported(name string) string {
+	r, n := utf8.DecodeRuneInString(name)
+	if !unicode.IsLetter(r) {
+		return "X" + name
+	}
+	return string(unicode.ToUpper(r)) + name[n:]
+}
+
+// snakeCase returns name in snake case, with acronyms as one word, as in
+// url_string for URLString.
+func snakeCase(name string) string {
+	runes := []rune(name)
+	b := &strings.Builder{}
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			next := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && next) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
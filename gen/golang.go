@@ -0,0 +1,690 @@
+package gen
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"go/format"
+	"go/token"
+	"math/big"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/progrium/macschema/schema"
+)
+
+//go:embed templates/go.tmpl
+var goTemplate string
+
+// Go generates Go bindings from Objective-C schemas: a type for each class
+// with methods that send messages through the Runtime interface declared
+// in the generated package, constants for enums and options, structs with
+// the layout of their C counterparts and func types for blocks and
+// callbacks. The bindings are one gofmt'd source file.
+type Go struct {
+	// Package is the name of the generated package.
+	Package string
+
+	// Encoding gives the type encodings of messages and the typedefs and
+	// structs used but not declared by the schemas, which are added to
+	// it. It is for arm64 if nil.
+	Encoding *schema.Encoding
+
+	// Types gives the Go types of type names, and resolves typedefs,
+	// with the schemas added to it. It is the built-in go map if nil.
+	Types *schema.TypeMap
+
+	// Skipped lists the declarations Generate left out, because they use
+	// types with no Go counterpart, and why.
+	Skipped []string
+}
+
+type goFile struct {
+	Package string
+	Classes []goClass
+	Enums   []goEnum
+	Structs []goStruct
+	Types   []goTypeDecl
+	Funcs   []goFunc
+}
+
+type goClass struct {
+	Name  string
+	Doc   string
+	Funcs []goFunc
+}
+
+// goFunc is a method or function that sends a message or calls a C
+// function, with the Go source of each part.
+type goFunc struct {
+	Doc    string
+	Sig    string // as in (x NSWindow) SetTitle(title NSString)
+	RetVar string // type of the variable the result is stored in
+	Call   string
+	Return string // expression returned
+}
+
+type goEnum struct {
+	Name  string
+	Doc   string
+	Type  string
+	Cases []goConst
+}
+
+type goConst struct {
+	Name  string
+	Doc   string
+	Value string
+}
+
+type goStruct struct {
+	Name   string
+	Doc    string
+	Fields []goField
+	Size   int
+}
+
+type goField struct {
+	Name string
+	Type string
+}
+
+type goTypeDecl struct {
+	Name string
+	Doc  string
+	Type string // with = for an alias
+}
+
+// goType is the Go type of a C type and how its values are passed to the
+// runtime.
+type goType struct {
+	Name     string       // empty for void
+	Class    bool         // a class type, which wraps an Object
+	Block    *schema.Func // signature of a block to wrap with the runtime
+	Callback *schema.Func // signature of a function pointer to wrap
+}
+
+// goReserved are the names the generated package declares itself.
+var goReserved = []string{"Runtime", "SetRuntime", "Object", "ObjectFrom", "Class", "Selector", "objcRuntime"}
+
+// goGen is the state of a Generate call.
+type goGen struct {
+	*Go
+	enc     *schema.Encoding
+	types   *schema.TypeMap
+	file    goFile
+	names   map[string]bool // declared at the top level
+	classes map[string]bool
+	enums   map[string]*schema.Enum
+	aliases map[string]*schema.TypeAlias
+	emitted map[string]error // structs and typedefs, by name
+	class   string           // whose methods are generated, for instancetype
+}
+
+// Generate returns the Go source of the bindings of schemas.
+func (g *Go) Generate(schemas []schema.Schema) ([]byte, error) {
+	schemas = uniqueSchemas(schemas)
+	gg := &goGen{
+		Go:      g,
+		enc:     g.Encoding,
+		types:   g.Types,
+		file:    goFile{Package: g.Package},
+		names:   make(map[string]bool),
+		classes: make(map[string]bool),
+		enums:   make(map[string]*schema.Enum),
+		aliases: make(map[string]*schema.TypeAlias),
+		emitted: make(map[string]error),
+	}
+	if gg.enc == nil {
+		gg.enc = schema.NewEncoding("arm64")
+	}
+	if gg.types == nil {
+		types, err := schema.DefaultTypeMap("go")
+		if err != nil {
+			return nil, err
+		}
+		gg.types = types
+	}
+	g.Skipped = nil
+	for _, name := range goReserved {
+		gg.names[name] = true
+	}
+
+	var enums []schema.Enum
+	for _, s := range schemas {
+		gg.enc.AddSchema(s)
+		gg.types.AddSchema(s)
+		switch {
+		case s.Class != nil:
+			gg.classes[s.Class.Name] = true
+		case s.Enum != nil:
+			enums = append(enums, *s.Enum)
+		case s.TypeAlias != nil:
+			gg.aliases[s.TypeAlias.Name] = s.TypeAlias
+		case s.APICollection != nil:
+			enums = append(enums, s.APICollection.Enums...)
+		}
+	}
+	for _, en := range enums {
+		en := en
+		gg.enums[en.Name] = &en
+	}
+	for _, en := range enums {
+		gg.enum(en)
+	}
+	for _, s := range schemas {
+		switch {
+		case s.Class != nil:
+			gg.genClass(*s.Class)
+		case s.Struct != nil:
+			if err := gg.mirror(s.Struct.Name); err != nil {
+				gg.skip(s.Struct.Name, err)
+			}
+		case s.TypeAlias != nil:
+			if _, err := gg.namedType(s.TypeAlias.Name, false); err != nil {
+				gg.skip(s.TypeAlias.Name, err)
+			}
+		case s.Function != nil:
+			gg.genFunc(*s.Function)
+		case s.APICollection != nil:
+			for _, fn := range s.APICollection.Functions {
+				gg.genFunc(fn)
+			}
+		}
+	}
+
+	t, err := template.New("go").Funcs(template.FuncMap{"comment": comment}).Parse(goTemplate)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, gg.file); err != nil {
+		return nil, err
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return buf.Bytes(), fmt.Errorf("gen: formatting Go source: %w", err)
+	}
+	return src, nil
+}
+
+func (g *goGen) skip(name string, err error) {
+	g.Skipped = append(g.Skipped, fmt.Sprintf("%s: %v", name, err))
+}
+
+// declare reserves a top-level name, unless it is taken.
+func (g *goGen) declare(name string) error {
+	if g.names[name] {
+		return fmt.Errorf("%s is already declared", name)
+	}
+	g.names[name] = true
+	return nil
+}
+
+func (g *goGen) genClass(c schema.Class) {
+	if err := g.declare(c.Name); err != nil {
+		g.skip(c.Name, err)
+		return
+	}
+	g.class = c.Name
+	defer func() { g.class = "" }()
+
+	class := goClass{
+		Name: c.Name,
+		Doc:  doc(c.Name, c.Description, c.TopicURL, c.Deprecated),
+	}
+	methods := make(map[string]bool)
+	add := func(fn goFunc, name string, err error) {
+		if err != nil {
+			g.skip(c.Name+" "+name, err)
+			return
+		}
+		class.Funcs = append(class.Funcs, fn)
+	}
+	for _, p := range c.InstanceProperties {
+		for _, m := range propertyMethods(p) {
+			if name := goName(m.Name); !methods[name] {
+				methods[name] = true
+				fn, err := g.method(m, name, false)
+				add(fn, m.Name, err)
+			}
+		}
+	}
+	for _, m := range c.InstanceMethods {
+		if name := goName(m.Name); !methods[name] {
+			methods[name] = true
+			fn, err := g.method(m, name, false)
+			add(fn, m.Name, err)
+		}
+	}
+	for _, p := range c.TypeProperties {
+		for _, m := range propertyMethods(p) {
+			name := c.Name + "_" + goName(m.Name)
+			if err := g.declare(name); err == nil {
+				fn, err := g.method(m, name, true)
+				add(fn, m.Name, err)
+			}
+		}
+	}
+	for _, m := range c.TypeMethods {
+		name := c.Name + "_" + goName(m.Name)
+		if err := g.declare(name); err == nil {
+			fn, err := g.method(m, name, true)
+			add(fn, m.Name, err)
+		}
+	}
+	g.file.Classes = append(g.file.Classes, class)
+}
+
+// propertyMethods returns the getter and, unless the property is
+// readonly, the setter of a property.
+func propertyMethods(p schema.Property) []schema.Method {
+	getter := schema.Method{
+		Name:        p.Name,
+		Description: p.Description,
+		Return:      p.Type,
+		Deprecated:  p.Deprecated,
+		TopicURL:    p.TopicURL,
+	}
+	if name, ok := p.Attrs["getter"].(string); ok {
+		getter.Name = name
+	}
+	if p.Attrs["readonly"] != nil {
+		return []schema.Method{getter}
+	}
+	setter := schema.Method{
+		Name:        "set" + exported(p.Name) + ":",
+		Description: p.Description,
+		Return:      schema.DataType{Name: "void"},
+		Args:        []schema.Arg{{Name: "value", Type: p.Type}},
+		Deprecated:  p.Deprecated,
+		TopicURL:    p.TopicURL,
+	}
+	if name, ok := p.Attrs["setter"].(string); ok {
+		setter.Name = name
+	}
+	return []schema.Method{getter, setter}
+}
+
+// goName returns the Go name of a selector: its first part exported and
+// the others joined with underscores, as in SetFrame_display.
+func goName(sel string) string {
+	parts := strings.Split(strings.TrimSuffix(sel, ":"), ":")
+	parts[0] = exported(parts[0])
+	if name := strings.Join(parts, "_"); name != "Object" {
+		return name
+	}
+	// the embedded Object can't have a method of the same name
+	return "Object_"
+}
+
+// method returns the Go method named name that sends the message of m,
+// or with typeMethod set the function that sends it to the class.
+func (g *goGen) method(m schema.Method, name string, typeMethod bool) (goFunc, error) {
+	enc, err := g.enc.Method(m)
+	if err != nil {
+		return goFunc{}, err
+	}
+	params, args, err := g.params(m.Args)
+	if err != nil {
+		return goFunc{}, err
+	}
+	fn, err := g.result(m.Return)
+	if err != nil {
+		return goFunc{}, err
+	}
+	fn.Doc = doc(name, m.Description, m.TopicURL, m.Deprecated)
+	fn.Sig = fmt.Sprintf("(x %s) %s(%s) %s", g.class, name, params, fn.Sig)
+	receiver := "x.Object"
+	if typeMethod {
+		fn.Sig = strings.TrimPrefix(fn.Sig, "(x "+g.class+") ")
+		receiver = fmt.Sprintf("objcRuntime.Class(%q)", g.class)
+	}
+	fn.Call = fmt.Sprintf("objcRuntime.Send(%s, %q, %q, %s%s)", receiver, m.Name, enc, fn.Call, args)
+	return fn, nil
+}
+
+func (g *goGen) genFunc(f schema.Func) {
+	fn, err := g.function(f)
+	if err != nil {
+		g.skip(f.Name, err)
+		return
+	}
+	g.file.Funcs = append(g.file.Funcs, fn)
+}
+
+// function returns the Go function that calls the C function f.
+func (g *goGen) function(f schema.Func) (goFunc, error) {
+	enc, err := g.enc.Func(f)
+	if err != nil {
+		return goFunc{}, err
+	}
+	params, args, err := g.params(f.Args)
+	if err != nil {
+		return goFunc{}, err
+	}
+	fn, err := g.result(f.Return)
+	if err != nil {
+		return goFunc{}, err
+	}
+	if err := g.declare(f.Name); err != nil {
+		return goFunc{}, err
+	}
+	fn.Doc = doc(f.Name, f.Description, f.TopicURL, f.Deprecated)
+	fn.Sig = fmt.Sprintf("%s(%s) %s", f.Name, params, fn.Sig)
+	fn.Call = fmt.Sprintf("objcRuntime.Call(%q, %q, %s%s)", f.Name, enc, fn.Call, args)
+	return fn, nil
+}
+
+// params returns the parameters of a Go function for args and the
+// arguments it passes to the runtime, each after a comma.
+func (g *goGen) params(args []schema.Arg) (params, values string, err error) {
+	var ps, vs []string
+	for i, arg := range args {
+		t, err := g.goType(arg.Type, false)
+		if err != nil {
+			return "", "", fmt.Errorf("%s: %w", arg.Name, err)
+		}
+		if t.Name == "" {
+			return "", "", fmt.Errorf("%s: void argument", arg.Name)
+		}
+		name := paramName(arg.Name, i)
+		ps = append(ps, name+" "+t.Name)
+		switch {
+		case t.Class:
+			vs = append(vs, ", "+name+".Object")
+		case t.Block != nil:
+			enc, err := g.enc.Block(*t.Block)
+			if err != nil {
+				return "", "", fmt.Errorf("%s: %w", arg.Name, err)
+			}
+			vs = append(vs, fmt.Sprintf(", objcRuntime.Block(%s, %q)", name, enc))
+		case t.Callback != nil:
+			enc, err := g.enc.Func(*t.Callback)
+			if err != nil {
+				return "", "", fmt.Errorf("%s: %w", arg.Name, err)
+			}
+			vs = append(vs, fmt.Sprintf(", objcRuntime.Callback(%s, %q)", name, enc))
+		default:
+			vs = append(vs, ", "+name)
+		}
+	}
+	return strings.Join(ps, ", "), strings.Join(vs, ""), nil
+}
+
+// result returns a goFunc with the result type as Sig, the result
+// argument to the runtime as Call and how it is returned. Blocks and
+// callbacks are returned as they are.
+func (g *goGen) result(dt schema.DataType) (goFunc, error) {
+	t, err := g.goType(dt, false)
+	if err != nil {
+		return goFunc{}, fmt.Errorf("result: %w", err)
+	}
+	switch {
+	case t.Name == "":
+		return goFunc{Call: "nil"}, nil
+	case t.Class:
+		return goFunc{Sig: t.Name, RetVar: "Object", Call: "&ret", Return: t.Name + "{ret}"}, nil
+	case t.Block != nil:
+		t.Name = "Object"
+	case t.Callback != nil:
+		t.Name = "unsafe.Pointer"
+	}
+	return goFunc{Sig: t.Name, RetVar: t.Name, Call: "&ret", Return: "ret"}, nil
+}
+
+// goReservedParams are names parameters can't have because the generated
+// code uses them.
+var goReservedParams = map[string]bool{
+	"x": true, "ret": true, "objcRuntime": true, "unsafe": true,
+	"bool": true, "byte": true, "error": true, "float32": true, "float64": true,
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"rune": true, "string": true, "uint": true, "uint8": true, "uint16": true,
+	"uint32": true, "uint64": true, "uintptr": true,
+}
+
+func paramName(name string, i int) string {
+	if name == "" {
+		return fmt.Sprintf("arg%d", i)
+	}
+	if token.IsKeyword(name) || goReservedParams[name] {
+		return name + "_"
+	}
+	return name
+}
+
+// goType returns the Go type of a C type. With raw set, objects are
+// Object rather than their class, as inside blocks and structs.
+func (g *goGen) goType(dt schema.DataType, raw bool) (goType, error) {
+	switch {
+	case dt.Kind == "pointer":
+		return g.pointerType(*dt.Elem, raw)
+	case dt.Kind == "array":
+		elem, err := g.goType(*dt.Elem, true)
+		if err != nil {
+			return goType{}, err
+		}
+		if _, err := strconv.Atoi(dt.Len); err != nil {
+			// arrays of unknown length are passed as pointers
+			return goType{Name: "*" + elem.Name}, nil
+		}
+		return goType{Name: "[" + dt.Len + "]" + elem.Name}, nil
+	case dt.Kind != "":
+		return goType{}, fmt.Errorf("no Go type for %s types", dt.Kind)
+	case dt.Block != nil:
+		sig, err := g.funcType(*dt.Block)
+		return goType{Name: sig, Block: dt.Block}, err
+	case dt.FuncPtr != nil:
+		sig, err := g.funcType(*dt.FuncPtr)
+		return goType{Name: sig, Callback: dt.FuncPtr}, err
+	}
+	return g.namedType(dt.CName(), raw)
+}
+
+func (g *goGen) pointerType(elem schema.DataType, raw bool) (goType, error) {
+	if elem.Kind == "" && elem.Block == nil && elem.FuncPtr == nil {
+		if t, ok := g.types.Types[elem.CName()+" *"]; ok {
+			return goType{Name: t}, nil
+		}
+		if g.isObject(elem.CName()) {
+			if !raw && g.classes[elem.Name] {
+				return goType{Name: elem.Name, Class: true}, nil
+			}
+			return goType{Name: "Object"}, nil
+		}
+	}
+	t, err := g.goType(elem, true)
+	if err != nil {
+		return goType{}, err
+	}
+	return goType{Name: "*" + t.Name}, nil
+}
+
+// isObject returns true for the name of a type pointers to which are
+// objects: a class, or any type that isn't otherwise known.
+func (g *goGen) isObject(name string) bool {
+	if g.classes[name] {
+		return true
+	}
+	if _, ok := g.enc.Types[name]; ok || !g.types.IsObject(name) {
+		return false
+	}
+	_, ok := g.enc.Structs[name]
+	return !ok
+}
+
+func (g *goGen) namedType(name string, raw bool) (goType, error) {
+	if name == "instancetype" {
+		if raw || g.class == "" {
+			return goType{Name: "Object"}, nil
+		}
+		return goType{Name: g.class, Class: true}, nil
+	}
+	if t, ok := g.types.Types[name]; ok {
+		return goType{Name: t}, nil
+	}
+	if _, ok := g.enums[name]; ok {
+		return goType{Name: name}, nil
+	}
+	if ta, ok := g.aliases[name]; ok {
+		return g.typeDecl(*ta)
+	}
+	if alias, ok := g.types.Resolve(name); ok {
+		return g.goType(alias, raw)
+	}
+	if alias, ok := g.enc.Types[name]; ok {
+		return g.goType(alias, raw)
+	}
+	if _, ok := g.enc.Structs[name]; ok {
+		if err := g.mirror(name); err != nil {
+			return goType{}, err
+		}
+		return goType{Name: name}, nil
+	}
+	return goType{}, fmt.Errorf("no Go type for %q", name)
+}
+
+// typeDecl declares the Go type of a typedef, which is a func type for
+// blocks and callbacks and an alias of the Go type of other named types.
+// Typedefs of pointers and arrays are left as the Go type they are.
+func (g *goGen) typeDecl(ta schema.TypeAlias) (goType, error) {
+	if ta.Type.Kind != "" {
+		return g.goType(ta.Type, false)
+	}
+	t, err := g.goType(ta.Type, false)
+	if err != nil {
+		return goType{}, err
+	}
+	decl := goTypeDecl{
+		Name: ta.Name,
+		Doc:  doc(ta.Name, ta.Description, ta.TopicURL, ta.Deprecated),
+		Type: "= " + t.Name,
+	}
+	if t.Block != nil || t.Callback != nil {
+		decl.Type = t.Name
+	}
+	if _, ok := g.emitted[ta.Name]; !ok {
+		if g.emitted[ta.Name] = g.declare(ta.Name); g.emitted[ta.Name] == nil {
+			g.file.Types = append(g.file.Types, decl)
+		}
+	}
+	if err := g.emitted[ta.Name]; err != nil {
+		return goType{}, err
+	}
+	t.Name = ta.Name
+	return t, nil
+}
+
+// funcType returns the Go func type of a block or function pointer.
+func (g *goGen) funcType(fn schema.Func) (string, error) {
+	var params []string
+	named := true
+	for _, arg := range fn.Args {
+		if arg.Type.Name == "void" && arg.Type.Kind == "" && arg.Name == "" && len(fn.Args) == 1 {
+			break
+		}
+		t, err := g.goType(arg.Type, true)
+		if err != nil {
+			return "", err
+		}
+		params = append(params, t.Name)
+		named = named && arg.Name != ""
+	}
+	if named {
+		for i, arg := range fn.Args[:len(params)] {
+			params[i] = paramName(arg.Name, i) + " " + params[i]
+		}
+	}
+	ret, err := g.goType(fn.Return, true)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(fmt.Sprintf("func(%s) %s", strings.Join(params, ", "), ret.Name)), nil
+}
+
+// mirror declares the Go struct with the layout of a C struct, once.
+func (g *goGen) mirror(name string) error {
+	if err, ok := g.emitted[name]; ok {
+		return err
+	}
+	if err := g.declare(name); err != nil {
+		g.emitted[name] = err
+		return err
+	}
+	// pointers in the fields may refer to the struct itself
+	g.emitted[name] = nil
+
+	c, ok := g.enc.Structs[name]
+	if !ok {
+		g.emitted[name] = fmt.Errorf("no fields")
+		return g.emitted[name]
+	}
+	st := goStruct{Name: name, Doc: doc(name, c.Description, c.TopicURL, c.Deprecated)}
+	for _, f := range c.Fields {
+		if f.Name == "" {
+			g.emitted[name] = fmt.Errorf("anonymous field")
+			return g.emitted[name]
+		}
+		t, err := g.goType(f.Type, true)
+		if err != nil {
+			g.emitted[name] = fmt.Errorf("%s: %w", f.Name, err)
+			return g.emitted[name]
+		}
+		if t.Block != nil || t.Callback != nil {
+			t.Name = "unsafe.Pointer"
+		}
+		st.Fields = append(st.Fields, goField{Name: exported(f.Name), Type: t.Name})
+	}
+	if size, _, err := g.enc.Sizeof(schema.DataType{Name: name}); err == nil {
+		st.Size = size
+	}
+	g.file.Structs = append(g.file.Structs, st)
+	return nil
+}
+
+// enum declares the Go type and constants of an enum. Cases without an
+// integer value are left out.
+func (g *goGen) enum(en schema.Enum) {
+	typ := "int32" // of C enums without a fixed type
+	if en.Type.Name != "" || en.Type.Kind != "" {
+		t, err := g.goType(en.Type, true)
+		if err != nil {
+			delete(g.enums, en.Name)
+			g.skip(en.Name, err)
+			return
+		}
+		typ = t.Name
+	}
+	if err := g.declare(en.Name); err != nil {
+		g.skip(en.Name, err)
+		return
+	}
+	e := goEnum{
+		Name: en.Name,
+		Doc:  doc(en.Name, en.Description, en.TopicURL, en.Deprecated),
+		Type: typ,
+	}
+	for _, c := range en.Cases {
+		if c.IntValue == nil {
+			g.skip(c.Name, fmt.Errorf("no integer value"))
+			continue
+		}
+		if err := g.declare(c.Name); err != nil {
+			g.skip(c.Name, err)
+			continue
+		}
+		v := new(big.Int).Set(c.IntValue)
+		if strings.HasPrefix(typ, "uint") && v.Sign() < 0 {
+			// as the unsigned value C converts it to
+			size, _, _ := g.enc.Sizeof(en.Type)
+			v.Add(v, new(big.Int).Lsh(big.NewInt(1), uint(size*8)))
+		}
+		e.Cases = append(e.Cases, goConst{
+			Name:  c.Name,
+			Doc:   doc(c.Name, c.Description, c.TopicURL, c.Deprecated),
+			Value: v.String(),
+		})
+	}
+	g.file.Enums = append(g.file.Enums, e)
+}
@@ -0,0 +1,216 @@
+package gen
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+
+	"github.com/progrium/macschema/declparse"
+	"github.com/progrium/macschema/schema"
+)
+
+// stubRuntime is compiled with the generated bindings to check they use
+// the Runtime interface they declare.
+const stubRuntime = `package appkit
+
+import "unsafe"
+
+type stubRuntime struct{}
+
+func (stubRuntime) Class(name string) Object { return Object{} }
+func (stubRuntime) Send(receiver Object, sel Selector, enc string, ret interface{}, args ...interface{}) {}
+func (stubRuntime) Call(fn string, enc string, ret interface{}, args ...interface{}) {}
+func (stubRuntime) Block(fn interface{}, enc string) Object { return Object{} }
+func (stubRuntime) Callback(fn interface{}, enc string) unsafe.Pointer { return nil }
+
+func init() {
+	SetRuntime(stubRuntime{})
+}
+`
+
+func parseDecl(t *testing.T, decl string, hint declparse.Hint) *declparse.Statement {
+	t.Helper()
+	p := declparse.NewStringParser(decl)
+	p.Hint = hint
+	stmt, err := p.Parse()
+	if err != nil {
+		t.Fatalf("%s: %v", decl, err)
+	}
+	return stmt
+}
+
+func testSchemas(t *testing.T) []schema.Schema {
+	c := &schema.Class{Identifier: schema.Identifier{
+		Name:        "NSWindow",
+		Description: "A window that an app displays on the screen.",
+		TopicURL:    "https://developer.apple.com/documentation/appkit/nswindow?language=objc",
+	}}
+	for _, decl := range []string{
+		`- (instancetype)initWithContentRect:(NSRect)contentRect styleMask:(NSWindowStyleMask)style backing:(NSBackingStoreType)backingStoreType defer:(BOOL)flag;`,
+		`- (void)setFrame:(NSRect)frameRect display:(BOOL)flag;`,
+		`- (void)makeKeyAndOrderFront:(id)sender;`,
+		`- (void)beginSheet:(NSWindow *)sheetWindow completionHandler:(void (^)(NSModalResponse returnCode))handler;`,
+		`- (void)enumerateChildren:(NSWindowEnumerator)block;`,
+		`- (BOOL)getBytes:(void *)buffer error:(NSError **)error;`,
+		`- (void)sortUsingFunction:(NSInteger (*)(id, id, void *))compare context:(void *)context;`,
+		`- (void)getComponents:(CGFloat[4])components;`,
+		`- (FSRef)unsupported;`,
+		`- (id)object;`,
+	} {
+		m := schema.MethodFromAst(*parseDecl(t, decl, declparse.HintNone).Method, false)
+		c.InstanceMethods = append(c.InstanceMethods, m)
+	}
+	for _, decl := range []string{
+		`@property (copy) NSString *title;`,
+		`@property (readonly) NSRect frame;`,
+		`@property (getter=isVisible, readonly) BOOL visible;`,
+		`@property (weak) id<NSWindowDelegate> delegate;`,
+	} {
+		p := schema.PropertyFromAst(*parseDecl(t, decl, declparse.HintNone).Property, false)
+		c.InstanceProperties = append(c.InstanceProperties, p)
+	}
+	for _, decl := range []string{
+		`+ (instancetype)windowWithContentViewController:(NSViewController *)contentViewController;`,
+		`+ (NSWindowStyleMask)styleMask;`,
+	} {
+		m := schema.MethodFromAst(*parseDecl(t, decl, declparse.HintNone).Method, false)
+		c.TypeMethods = append(c.TypeMethods, m)
+	}
+
+	mask := schema.EnumFromAst(*parseDecl(t, `typedef enum NSWindowStyleMask : NSUInteger {
+		NSWindowStyleMaskBorderless = 0,
+		NSWindowStyleMaskTitled = 1 << 0,
+		NSWindowStyleMaskClosable = 1 << 1,
+		NSWindowStyleMaskAll = -1
+	};`, declparse.HintNone).Enum)
+	backing := schema.EnumFromAst(*parseDecl(t, `typedef enum NSBackingStoreType : NSUInteger {
+		NSBackingStoreRetained, NSBackingStoreNonretained, NSBackingStoreBuffered
+	};`, declparse.HintNone).Enum)
+
+	structOf := func(name string, fields ...string) schema.Struct {
+		st := schema.Struct{Identifier: schema.Identifier{Name: name}}
+		for _, decl := range fields {
+			v := schema.VariableFromAst(*parseDecl(t, decl, declparse.HintVariable).Variable, false)
+			st.Fields = append(st.Fields, v)
+		}
+		return st
+	}
+	edge := structOf("NSDirectionalEdgeInsets", "CGFloat top;", "CGFloat leading;", "CGFloat bottom;", "CGFloat trailing;")
+	node := structOf("Node", "char tag;", "Node *next;", "NSInteger value;", "BOOL flags[3];")
+
+	enumerator := parseDecl(t, `typedef void (^NSWindowEnumerator)(NSWindow *window, BOOL *stop);`, declparse.HintNone)
+	response := parseDecl(t, `typedef NSInteger NSModalResponse;`, declparse.HintNone)
+
+	beep := schema.FuncFromAst(parseDecl(t, `void NSBeep(void);`, declparse.HintFunction).Function, false)
+	rect := schema.FuncFromAst(parseDecl(t, `NSRect NSInsetRect(NSRect aRect, CGFloat dX, CGFloat dY);`, declparse.HintFunction).Function, false)
+
+	return []schema.Schema{
+		{Class: c},
+		{Enum: &mask},
+		{Enum: &backing},
+		{Struct: &edge},
+		{Struct: &node},
+		{TypeAlias: &schema.TypeAlias{Identifier: schema.Identifier{Name: "NSWindowEnumerator"}, Type: schema.DataTypeFromAst(*enumerator.TypeAlias)}},
+		{TypeAlias: &schema.TypeAlias{Identifier: schema.Identifier{Name: "NSModalResponse"}, Type: schema.DataTypeFromAst(*response.TypeAlias)}},
+		{APICollection: &schema.APICollection{Functions: []schema.Func{*beep, *rect}}},
+	}
+}
+
+func TestGo_Generate(t *testing.T) {
+	g := &Go{Package: "appkit"}
+	src, err := g.Generate(testSchemas(t))
+	if err != nil {
+		t.Fatalf("%v\n%s", err, src)
+	}
+
+	// compile the bindings, checking struct layouts for arm64
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for name, s := range map[string]string{"appkit.go": string(src), "stub.go": stubRuntime} {
+		f, err := parser.ParseFile(fset, name, s, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, f)
+	}
+	conf := types.Config{Importer: importer.Default(), Sizes: types.SizesFor("gc", "arm64")}
+	if _, err := conf.Check("appkit", fset, files, nil); err != nil {
+		t.Fatalf("%v\n%s", err, src)
+	}
+
+	for _, want := range []string{
+		"// A window that an app displays on the screen.\n//\n// https://developer.apple.com/documentation/appkit/nswindow?language=objc\ntype NSWindow struct {",
+		`func (x NSWindow) InitWithContentRect_styleMask_backing_defer(contentRect CGRect, style NSWindowStyleMask, backingStoreType NSBackingStoreType, flag bool) NSWindow {`,
+		`objcRuntime.Send(x.Object, "initWithContentRect:styleMask:backing:defer:", "@@:{CGRect={CGPoint=dd}{CGSize=dd}}QQB", &ret, contentRect, style, backingStoreType, flag)`,
+		`return NSWindow{ret}`,
+		`func (x NSWindow) BeginSheet_completionHandler(sheetWindow NSWindow, handler func(returnCode NSModalResponse)) {`,
+		`objcRuntime.Send(x.Object, "beginSheet:completionHandler:", "v@:@@?", nil, sheetWindow.Object, objcRuntime.Block(handler, "v@?q"))`,
+		`func (x NSWindow) EnumerateChildren(block NSWindowEnumerator) {`,
+		`objcRuntime.Block(block, "v@?@^B")`,
+		`func (x NSWindow) Object_() Object {`,
+		`func (x NSWindow) GetBytes_error(buffer unsafe.Pointer, error_ *Object) bool {`,
+		`func (x NSWindow) SortUsingFunction_context(compare func(Object, Object, unsafe.Pointer) int, context unsafe.Pointer) {`,
+		`objcRuntime.Callback(compare, "q@@^v")`,
+		`func (x NSWindow) GetComponents(components [4]float64) {`,
+		`func (x NSWindow) Title() Object {`,
+		`func (x NSWindow) SetTitle(value Object) {`,
+		`func (x NSWindow) IsVisible() bool {`,
+		`func NSWindow_WindowWithContentViewController(contentViewController Object) NSWindow {`,
+		`objcRuntime.Send(objcRuntime.Class("NSWindow"), "styleMask", "Q@:", &ret)`,
+		"type NSWindowStyleMask uint",
+		"NSWindowStyleMaskClosable   NSWindowStyleMask = 2",
+		"NSWindowStyleMaskAll        NSWindowStyleMask = 18446744073709551615",
+		"NSBackingStoreBuffered    NSBackingStoreType = 2",
+		"type NSWindowEnumerator func(window Object, stop *bool)",
+		"type NSModalResponse = int",
+		"type CGRect struct {\n\tOrigin CGPoint\n\tSize   CGSize\n}",
+		"var _ [32]byte = [unsafe.Sizeof(CGRect{})]byte{}",
+		"type Node struct {\n\tTag   int8\n\tNext  *Node\n\tValue int\n\tFlags [3]bool\n}",
+		"var _ [32]byte = [unsafe.Sizeof(Node{})]byte{}",
+		`objcRuntime.Call("NSInsetRect", "{CGRect={CGPoint=dd}{CGSize=dd}}{CGRect={CGPoint=dd}{CGSize=dd}}dd", &ret, aRect, dX, dY)`,
+		`objcRuntime.Call("NSBeep", "v", nil)`,
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+	if t.Failed() {
+		t.Logf("\n%s", src)
+	}
+
+	skipped := strings.Join(g.Skipped, "\n")
+	if !strings.Contains(skipped, "NSWindow unsupported: ") {
+		t.Errorf("long double method not skipped: %v", g.Skipped)
+	}
+}
+
+func TestGo_Generate_Collection(t *testing.T) {
+	schemas := testSchemas(t)
+	mask := *schemas[1].Enum
+	beep := schemas[len(schemas)-1].APICollection.Functions[0]
+	schemas = append(schemas, schema.Schema{APICollection: &schema.APICollection{
+		Identifier: schema.Identifier{Name: "Window Styles"},
+		Enums:      []schema.Enum{mask},
+		Functions:  []schema.Func{beep},
+	}})
+
+	g := &Go{Package: "appkit"}
+	src, err := g.Generate(schemas)
+	if err != nil {
+		t.Fatalf("%v\n%s", err, src)
+	}
+	for _, s := range g.Skipped {
+		if strings.Contains(s, "already declared") {
+			t.Errorf("skipped %s", s)
+		}
+	}
+	for _, want := range []string{"type NSWindowStyleMask uint", "func NSBeep() {"} {
+		if n := strings.Count(string(src), want); n != 1 {
+			t.Errorf("%q declared %d times", want, n)
+		}
+	}
+}
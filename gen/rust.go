@@ -0,0 +1,516 @@
+package gen
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"math/big"
+	"strings"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/progrium/macschema/schema"
+)
+
+//go:embed templates/rust.tmpl
+var rustTemplate string
+
+// Rust generates Rust bindings in the style of the objc2 crates from
+// Objective-C schemas: extern_class! and extern_methods! declarations for
+// classes, #[repr(C)] structs and newtypes for enums, with bitflags for
+// options. Objects are returned as Retained and passed as references.
+// The output is rendered by a text/template, which can be replaced to
+// tune the bindings without recompiling.
+type Rust struct {
+	// Template is the text/template the bindings are rendered with. It
+	// is the built-in one if empty. It gets the classes, enums, structs,
+	// typedefs and functions of the schemas with their Rust source, and
+	// has the funcs of Funcs and rustdoc, to write doc comments.
+	Template string
+
+	// Encoding gives the sizes of enum types. It is for arm64 if nil.
+	Encoding *schema.Encoding
+
+	// Types gives the Rust types of type names, and resolves typedefs,
+	// with the schemas added to it. It is the built-in rust map if nil.
+	Types *schema.TypeMap
+
+	// Skipped lists the declarations Generate left out, because they use
+	// types with no Rust counterpart, and why.
+	Skipped []string
+}
+
+type rustFile struct {
+	Classes []rustClass
+	Enums   []rustEnum
+	Structs []rustStruct
+	Types   []rustTypeDecl
+	Funcs   []rustFunc
+}
+
+type rustClass struct {
+	Name    string
+	Doc     declDoc
+	Super   string
+	Methods []rustFunc
+}
+
+// rustFunc is a method or C function, with the Rust source of each part.
+type rustFunc struct {
+	Doc      declDoc
+	Attr     string // method, or method_id for methods returning objects
+	Selector string
+	Name     string
+	Params   string // with the receiver of methods, as in &self
+	Result   string // empty for ()
+}
+
+type rustEnum struct {
+	Name    string
+	Doc     declDoc
+	Type    string
+	Options bool
+	Cases   []rustConst
+}
+
+type rustConst struct {
+	Name  string
+	Doc   declDoc
+	Value string
+}
+
+type rustStruct struct {
+	Name   string
+	Doc    declDoc
+	Fields []rustField
+}
+
+type rustField struct {
+	Name     string
+	Type     string
+	Encoding string // the Encoding of the type, as in <CGFloat>::ENCODING
+}
+
+type rustTypeDecl struct {
+	Name string
+	Doc  declDoc
+	Type string
+}
+
+// rustGen is the state of a Generate call.
+type rustGen struct {
+	*Rust
+	enc   *schema.Encoding
+	types *schema.TypeMap
+	file  rustFile
+}
+
+// Generate returns the Rust source of the bindings of schemas.
+func (r *Rust) Generate(schemas []schema.Schema) ([]byte, error) {
+	schemas = uniqueSchemas(schemas)
+	g := &rustGen{Rust: r, enc: r.Encoding, types: r.Types}
+	if g.enc == nil {
+		g.enc = schema.NewEncoding("arm64")
+	}
+	if g.types == nil {
+		types, err := schema.DefaultTypeMap("rust")
+		if err != nil {
+			return nil, err
+		}
+		g.types = types
+	}
+	r.Skipped = nil
+
+	for _, s := range schemas {
+		g.enc.AddSchema(s)
+		g.types.AddSchema(s)
+	}
+	for _, s := range schemas {
+		switch {
+		case s.Class != nil:
+			g.class(*s.Class)
+		case s.Enum != nil:
+			g.enum(*s.Enum)
+		case s.Struct != nil:
+			g.mirror(*s.Struct)
+		case s.TypeAlias != nil:
+			g.typeDecl(*s.TypeAlias)
+		case s.Function != nil:
+			g.function(*s.Function)
+		case s.APICollection != nil:
+			for _, en := range s.APICollection.Enums {
+				g.enum(en)
+			}
+			for _, fn := range s.APICollection.Functions {
+				g.function(fn)
+			}
+		}
+	}
+
+	text := r.Template
+	if text == "" {
+		text = rustTemplate
+	}
+	funcs := Funcs(g.types)
+	funcs["rustdoc"] = rustdoc
+	t, err := template.New("rust").Funcs(funcs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, g.file); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (g *rustGen) skip(name string, err error) {
+	g.Skipped = append(g.Skipped, fmt.Sprintf("%s: %v", name, err))
+}
+
+// class declares a class with its methods and the getters and setters of
+// its properties. Classes without a superclass inherit from NSObject.
+func (g *rustGen) class(c schema.Class) {
+	class := rustClass{
+		Name:  c.Name,
+		Doc:   declDoc{c.Description, c.TopicURL, c.Deprecated},
+		Super: c.Superclass,
+	}
+	if class.Super == "" {
+		class.Super = "NSObject"
+	}
+	names := make(map[string]bool)
+	add := func(m schema.Method, typeMethod bool) {
+		name := rustName(m.Name)
+		if names[name] {
+			return
+		}
+		names[name] = true
+		fn, err := g.method(m, c.Name, typeMethod)
+		if err != nil {
+			g.skip(c.Name+" "+m.Name, err)
+			return
+		}
+		class.Methods = append(class.Methods, fn)
+	}
+	for _, p := range c.InstanceProperties {
+		for _, m := range propertyMethods(p) {
+			add(m, false)
+		}
+	}
+	for _, m := range c.InstanceMethods {
+		add(m, false)
+	}
+	for _, p := range c.TypeProperties {
+		for _, m := range propertyMethods(p) {
+			add(m, true)
+		}
+	}
+	for _, m := range c.TypeMethods {
+		add(m, true)
+	}
+	g.file.Classes = append(g.file.Classes, class)
+}
+
+// rustName returns the name of the method of a selector, its parts joined
+// with underscores as in setFrame_display.
+func rustName(sel string) string {
+	return rustIdent(strings.ReplaceAll(strings.TrimSuffix(sel, ":"), ":", "_"))
+}
+
+// rustKeywords are the words that are only identifiers as raw identifiers.
+var rustKeywords = map[string]bool{
+	"as": true, "async": true, "await": true, "break": true, "const": true,
+	"continue": true, "dyn": true, "else": true, "enum": true, "extern": true,
+	"false": true, "fn": true, "for": true, "if": true, "impl": true, "in": true,
+	"let": true, "loop": true, "match": true, "mod": true, "move": true, "mut": true,
+	"pub": true, "ref": true, "return": true, "static": true, "struct": true,
+	"trait": true, "true": true, "type": true, "unsafe": true, "use": true,
+	"where": true, "while": true, "abstract": true, "become": true, "box": true,
+	"do": true, "final": true, "macro": true, "override": true, "priv": true,
+	"try": true, "typeof": true, "unsized": true, "virtual": true, "yield": true,
+}
+
+// rustIdent returns name as a Rust identifier. Keywords are raw
+// identifiers, except those that can't be, which get an underscore.
+func rustIdent(name string) string {
+	switch {
+	case rustKeywords[name]:
+		return "r#" + name
+	case name == "self" || name == "Self" || name == "super" || name == "crate":
+		return name + "_"
+	}
+	return name
+}
+
+// isInit returns true for selectors in the init method family.
+func isInit(sel string) bool {
+	rest := strings.TrimPrefix(sel, "init")
+	if rest == sel {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(rest)
+	return rest == "" || r == ':' || unicode.IsUpper(r)
+}
+
+// method returns the extern_methods! declaration of a method. Methods in
+// the init family take the allocated object as this.
+func (g *rustGen) method(m schema.Method, class string, typeMethod bool) (rustFunc, error) {
+	fn := rustFunc{
+		Doc:      declDoc{m.Description, m.TopicURL, m.Deprecated},
+		Attr:     "method",
+		Selector: m.Name,
+		Name:     rustName(m.Name),
+	}
+	var params []string
+	switch {
+	case typeMethod:
+	case isInit(m.Name):
+		params = append(params, "this: Allocated<Self>")
+	default:
+		params = append(params, "&self")
+	}
+	for i, arg := range m.Args {
+		t, err := g.rustType(arg.Type, class, "&")
+		if err != nil {
+			return rustFunc{}, fmt.Errorf("%s: %w", arg.Name, err)
+		}
+		params = append(params, rustParam(arg.Name, i)+": "+t)
+	}
+	fn.Params = strings.Join(params, ", ")
+
+	t, err := g.rustType(m.Return, class, "Retained")
+	if err != nil {
+		return rustFunc{}, fmt.Errorf("result: %w", err)
+	}
+	if strings.HasPrefix(t, "Retained<") || strings.HasPrefix(t, "Option<Retained<") {
+		fn.Attr = "method_id"
+	}
+	if t != "()" {
+		fn.Result = t
+	}
+	return fn, nil
+}
+
+// rustType returns the Rust type of a method argument or result. Objects
+// the type map maps to raw pointers are wrapped, with ref as a reference
+// or Retained, and are an Option unless they are nonnull.
+func (g *rustGen) rustType(dt schema.DataType, class, ref string) (string, error) {
+	t, err := g.types.MapSelf(dt, class)
+	if err != nil || !g.isObject(dt) || !strings.HasPrefix(t, "*mut ") {
+		return t, err
+	}
+	t = strings.TrimPrefix(t, "*mut ")
+	if ref == "&" {
+		t = "&" + t
+	} else {
+		t = ref + "<" + t + ">"
+	}
+	if dt.Nullability != schema.NullabilityNonnull {
+		t = "Option<" + t + ">"
+	}
+	return t, nil
+}
+
+// isObject returns true for pointers to objects, and id and instancetype.
+func (g *rustGen) isObject(dt schema.DataType) bool {
+	switch {
+	case dt.Kind == "":
+		return dt.Name == "id" || dt.Name == "instancetype"
+	case dt.Kind == "pointer" && dt.Elem.Kind == "" && dt.Elem.Block == nil && dt.Elem.FuncPtr == nil:
+		_, ok := g.types.Types[dt.Elem.CName()+" *"]
+		return !ok && g.types.IsObject(dt.Elem.CName())
+	}
+	return false
+}
+
+func (g *rustGen) function(f schema.Func) {
+	fn := rustFunc{
+		Doc:  declDoc{f.Description, f.TopicURL, f.Deprecated},
+		Name: f.Name,
+	}
+	var params []string
+	for i, arg := range f.Args {
+		t, err := g.types.Map(arg.Type)
+		if err != nil {
+			g.skip(f.Name, fmt.Errorf("%s: %w", arg.Name, err))
+			return
+		}
+		params = append(params, rustParam(arg.Name, i)+": "+t)
+	}
+	fn.Params = strings.Join(params, ", ")
+	t, err := g.types.Map(f.Return)
+	if err != nil {
+		g.skip(f.Name, fmt.Errorf("result: %w", err))
+		return
+	}
+	if t != "()" {
+		fn.Result = t
+	}
+	g.file.Funcs = append(g.file.Funcs, fn)
+}
+
+// rustParam returns the Rust name of a parameter, in snake case.
+func rustParam(name string, i int) string {
+	if name == "" {
+		return fmt.Sprintf("arg%d", i)
+	}
+	return rustIdent(snakeCase(name))
+}
+
+// enum declares the newtype of an enum, with bitflags for options. Cases
+// are named without the prefix they share, and those without an integer
+// value are left out.
+func (g *rustGen) enum(en schema.Enum) {
+	typ, err := g.types.Map(schema.DataType{Name: "int"}) // of C enums without a fixed type
+	if en.Type.Name != "" || en.Type.Kind != "" {
+		typ, err = g.types.Map(en.Type)
+	}
+	if err != nil {
+		g.skip(en.Name, err)
+		return
+	}
+	e := rustEnum{
+		Name:    en.Name,
+		Doc:     declDoc{en.Description, en.TopicURL, en.Deprecated},
+		Type:    typ,
+		Options: en.IsOptions(),
+	}
+	unsigned := g.isUnsigned(en.Type)
+	prefix := casePrefix(en)
+	for _, c := range en.Cases {
+		if c.IntValue == nil {
+			g.skip(c.Name, fmt.Errorf("no integer value"))
+			continue
+		}
+		v := new(big.Int).Set(c.IntValue)
+		if unsigned && v.Sign() < 0 {
+			// as the unsigned value C converts it to
+			size, _, _ := g.enc.Sizeof(en.Type)
+			v.Add(v, new(big.Int).Lsh(big.NewInt(1), uint(size*8)))
+		}
+		e.Cases = append(e.Cases, rustConst{
+			Name:  caseName(prefix, c.Name),
+			Doc:   declDoc{c.Description, c.TopicURL, c.Deprecated},
+			Value: v.String(),
+		})
+	}
+	g.file.Enums = append(g.file.Enums, e)
+}
+
+// isUnsigned returns true for unsigned integer types, resolving typedefs.
+func (g *rustGen) isUnsigned(dt schema.DataType) bool {
+	for i := 0; i < 32 && dt.Kind == ""; i++ {
+		name := dt.CName()
+		if strings.HasPrefix(name, "unsigned") || strings.HasPrefix(name, "uint") || name == "size_t" {
+			return true
+		}
+		alias, ok := g.types.Resolve(dt.Name)
+		if !ok {
+			break
+		}
+		dt = alias
+	}
+	return false
+}
+
+// casePrefix returns the prefix enum cases are named with: the words the
+// names of all cases start with, or of one case the enum name.
+func casePrefix(en schema.Enum) string {
+	if len(en.Cases) == 1 {
+		return en.Name
+	}
+	var prefix string
+	for i, c := range en.Cases {
+		if i == 0 {
+			prefix = c.Name
+		}
+		for !strings.HasPrefix(c.Name, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	// back to the start of a word in every case
+	for prefix != "" {
+		ok := true
+		for _, c := range en.Cases {
+			r, _ := utf8.DecodeRuneInString(c.Name[len(prefix):])
+			ok = ok && unicode.IsUpper(r)
+		}
+		if ok {
+			break
+		}
+		prefix = prefix[:len(prefix)-1]
+	}
+	return prefix
+}
+
+// caseName returns the name of an enum case without prefix, as in Titled
+// for NSWindowStyleMaskTitled, if that leaves a name.
+func caseName(prefix, name string) string {
+	rest := strings.TrimPrefix(name, prefix)
+	if r, _ := utf8.DecodeRuneInString(rest); rest == "" || !unicode.IsUpper(r) {
+		return name
+	}
+	return rustIdent(rest)
+}
+
+// mirror declares the #[repr(C)] struct of a struct.
+func (g *rustGen) mirror(st schema.Struct) {
+	s := rustStruct{Name: st.Name, Doc: declDoc{st.Description, st.TopicURL, st.Deprecated}}
+	for _, f := range st.Fields {
+		if f.Name == "" {
+			g.skip(st.Name, fmt.Errorf("anonymous field"))
+			return
+		}
+		t, err := g.types.Map(f.Type)
+		if err != nil {
+			g.skip(st.Name, fmt.Errorf("%s: %w", f.Name, err))
+			return
+		}
+		enc := "<" + t + ">::ENCODING"
+		if t == "*mut "+st.Name || t == "*const "+st.Name {
+			// a pointer to the struct itself, whose encoding can't refer
+			// to its own
+			enc = fmt.Sprintf("Encoding::Pointer(&Encoding::Struct(%q, &[]))", st.Name)
+		}
+		s.Fields = append(s.Fields, rustField{Name: rustIdent(f.Name), Type: t, Encoding: enc})
+	}
+	g.file.Structs = append(g.file.Structs, s)
+}
+
+func (g *rustGen) typeDecl(ta schema.TypeAlias) {
+	t, err := g.types.Map(ta.Type)
+	if err != nil {
+		g.skip(ta.Name, err)
+		return
+	}
+	g.file.Types = append(g.file.Types, rustTypeDecl{
+		Name: ta.Name,
+		Doc:  declDoc{ta.Description, ta.TopicURL, ta.Deprecated},
+		Type: t,
+	})
+}
+
+// rustdoc returns the documentation of a declaration as doc comments, and
+// a deprecated attribute, with each line indented.
+func rustdoc(d declDoc, indent string) string {
+	b := &strings.Builder{}
+	var paras []string
+	if d.Description != "" {
+		paras = append(paras, d.Description)
+	}
+	if d.TopicURL != "" {
+		paras = append(paras, "See also <"+d.TopicURL+">")
+	}
+	for i, line := range strings.Split(strings.Join(paras, "\n\n"), "\n") {
+		if i == 0 && line == "" {
+			break
+		}
+		b.WriteString(strings.TrimRight(indent+"/// "+line, " ") + "\n")
+	}
+	if d.Deprecated {
+		b.WriteString(indent + "#[deprecated]\n")
+	}
+	return b.String()
+}
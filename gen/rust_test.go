@@ -0,0 +1,73 @@
+package gen
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRust_Generate(t *testing.T) {
+	schemas := testSchemas(t)
+	schemas[0].Class.Superclass = "NSResponder"
+
+	r := &Rust{}
+	src, err := r.Generate(schemas)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"extern_class!(\n    /// A window that an app displays on the screen.\n    ///\n    /// See also <https://developer.apple.com/documentation/appkit/nswindow?language=objc>\n    #[derive(Debug, PartialEq, Eq, Hash)]\n    pub struct NSWindow;\n\n    unsafe impl ClassType for NSWindow {\n        type Super = NSResponder;",
+		"extern_methods!(\n    unsafe impl NSWindow {",
+		"        #[method_id(initWithContentRect:styleMask:backing:defer:)]\n        pub unsafe fn initWithContentRect_styleMask_backing_defer(this: Allocated<Self>, content_rect: CGRect, style: NSWindowStyleMask, backing_store_type: NSBackingStoreType, flag: Bool) -> Option<Retained<NSWindow>>;",
+		"        #[method(setFrame:display:)]\n        pub unsafe fn setFrame_display(&self, frame_rect: CGRect, flag: Bool);",
+		"        #[method_id(title)]\n        pub unsafe fn title(&self) -> Option<Retained<NSString>>;",
+		"        #[method(setTitle:)]\n        pub unsafe fn setTitle(&self, value: Option<&NSString>);",
+		"        #[method(isVisible)]\n        pub unsafe fn isVisible(&self) -> Bool;",
+		"pub unsafe fn delegate(&self) -> Option<Retained<ProtocolObject<dyn NSWindowDelegate>>>;",
+		"pub unsafe fn beginSheet_completionHandler(&self, sheet_window: Option<&NSWindow>, handler: *mut Block<dyn Fn(NSInteger) -> ()>);",
+		"pub unsafe fn getBytes_error(&self, buffer: *mut c_void, error: *mut *mut NSError) -> Bool;",
+		"pub unsafe fn windowWithContentViewController(content_view_controller: Option<&NSViewController>) -> Option<Retained<NSWindow>>;",
+		"        #[method(styleMask)]\n        pub unsafe fn styleMask() -> NSWindowStyleMask;",
+		"bitflags::bitflags! {\n    #[repr(transparent)]\n    #[derive(Clone, Copy, Debug, PartialEq, Eq, Hash, PartialOrd, Ord)]\n    pub struct NSWindowStyleMask: NSUInteger {\n        const Borderless = 0;\n        const Titled = 1;\n        const Closable = 2;\n        const All = 18446744073709551615;\n    }\n}",
+		"pub struct NSBackingStoreType(pub NSUInteger);\n\nimpl NSBackingStoreType {\n    pub const Retained: Self = Self(0);\n    pub const Nonretained: Self = Self(1);\n    pub const Buffered: Self = Self(2);\n}",
+		"unsafe impl Encode for NSBackingStoreType {\n    const ENCODING: Encoding = <NSUInteger>::ENCODING;\n}",
+		"#[repr(C)]\n#[derive(Clone, Copy, Debug, PartialEq)]\npub struct Node {\n    pub tag: c_char,\n    pub next: *mut Node,\n    pub value: NSInteger,\n    pub flags: [Bool; 3],\n}",
+		"Encoding::Struct(\"Node\", &[\n        <c_char>::ENCODING,\n        Encoding::Pointer(&Encoding::Struct(\"Node\", &[])),\n        <NSInteger>::ENCODING,\n        <[Bool; 3]>::ENCODING,\n    ]);",
+		"pub type NSWindowEnumerator = *mut Block<dyn Fn(*mut NSWindow, *mut Bool) -> ()>;",
+		"pub type NSModalResponse = NSInteger;",
+		"extern \"C\" {\n    pub fn NSBeep();\n    pub fn NSInsetRect(a_rect: CGRect, d_x: CGFloat, d_y: CGFloat) -> CGRect;\n}",
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+	if t.Failed() {
+		t.Logf("\n%s", src)
+	}
+
+	// the template can be replaced
+	r.Template = `{{range .Classes}}{{.Name}}:{{range .Methods}} {{snake .Name}}{{end}}{{end}}`
+	src, err = r.Generate(schemas)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "NSWindow: title set_title frame is_visible delegate set_delegate init_with_content_rect_style_mask_backing_defer"; !strings.HasPrefix(string(src), want) {
+		t.Errorf("exp=%s got=%s", want, src)
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	for name, want := range map[string]string{
+		"frameRect":        "frame_rect",
+		"URLString":        "url_string",
+		"contentsOfURL":    "contents_of_url",
+		"dX":               "d_x",
+		"utf8String":       "utf8_string",
+		"NSWindow":         "ns_window",
+		"value":            "value",
+		"setFrame_display": "set_frame_display",
+	} {
+		if got := snakeCase(name); got != want {
+			t.Errorf("%s: exp=%s got=%s", name, want, got)
+		}
+	}
+}
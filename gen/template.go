@@ -0,0 +1,224 @@
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/progrium/macschema/schema"
+)
+
+// Templates generates code with text/templates from a directory, for
+// bindings with conventions no built-in generator has. The templates in
+// the directory are parsed together, so they can use each other, and each
+// one whose name doesn't start with _ is executed with every schema. The
+// output of a template named go.tmpl for the schema of NSWindow is the
+// file nswindow.go; output that is only space is left out, so templates
+// can skip schemas. Templates have the funcs of Funcs.
+type Templates struct {
+	// Types gives the types of the target language for mapType and
+	// mapSelf. It is the built-in go map if nil.
+	Types *schema.TypeMap
+
+	tmpl  *template.Template
+	names []string // of the templates executed with each schema
+}
+
+// File is a generated file.
+type File struct {
+	Name string
+	Src  []byte
+}
+
+// ParseTemplates parses the templates with the extension .tmpl in dir.
+func ParseTemplates(dir string, types *schema.TypeMap) (*Templates, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no templates in %s", dir)
+	}
+	if types == nil {
+		types, err = schema.DefaultTypeMap("go")
+		if err != nil {
+			return nil, err
+		}
+	}
+	t := &Templates{Types: types, tmpl: template.New(filepath.Base(dir)).Funcs(Funcs(types))}
+	for _, path := range paths {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		name := filepath.Base(path)
+		if _, err := t.tmpl.New(name).Parse(string(b)); err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(name, "_") {
+			t.names = append(t.names, name)
+		}
+	}
+	sort.Strings(t.names)
+	return t, nil
+}
+
+// Generate executes the templates with each schema, returning the files
+// in the order of the schemas. The types the schemas declare are added to
+// Types first.
+func (t *Templates) Generate(schemas []schema.Schema) ([]File, error) {
+	for _, s := range schemas {
+		t.Types.AddSchema(s)
+	}
+	var files []File
+	for _, s := range schemas {
+		for _, name := range t.names {
+			var buf bytes.Buffer
+			if err := t.tmpl.ExecuteTemplate(&buf, name, s); err != nil {
+				return nil, err
+			}
+			if strings.TrimSpace(buf.String()) == "" {
+				continue
+			}
+			files = append(files, File{
+				Name: fileName(s.Identifier().Name) + "." + strings.TrimSuffix(name, ".tmpl"),
+				Src:  buf.Bytes(),
+			})
+		}
+	}
+	return files, nil
+}
+
+// fileName returns name in lower case with only its letters and digits,
+// like the names of topic files.
+func fileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, name)
+}
+
+// Funcs returns the funcs of templates, with types used to map types:
+//
+//	selectorParts  the parts of a selector, as in [setFrame display]
+//	join           strings joined with a separator: join "_" .Parts
+//	snake          a name in snake case, as in frame_rect
+//	camel          a name with its first letter in lower case
+//	pascal         a name with its first letter in upper case
+//	upper, lower   a name in upper or lower case
+//	trimPrefix     a name without a prefix: trimPrefix "NS" .Name
+//	comment        text as // comments
+//	mapType        the target type of a DataType
+//	mapSelf        the target type of a DataType in the methods of a class
+//	cType          a DataType in C syntax
+//	isObject       whether a DataType is an object
+//	nullable       whether a DataType may be nil
+//	nonnull        whether a DataType is never nil
+//	available      the elements of a slice that aren't deprecated
+//	deprecated     the elements of a slice that are deprecated
+//	availableOn    the elements of a slice available on a target, as in
+//	               availableOn "macOS 11" .InstanceMethods
+func Funcs(types *schema.TypeMap) template.FuncMap {
+	return template.FuncMap{
+		"selectorParts": selectorParts,
+		"join":          func(sep string, parts []string) string { return strings.Join(parts, sep) },
+		"snake":         snakeCase,
+		"camel":         camelCase,
+		"pascal":        exported,
+		"upper":         strings.ToUpper,
+		"lower":         strings.ToLower,
+		"trimPrefix":    func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+		"comment":       comment,
+		"mapType":       types.Map,
+		"mapSelf":       func(dt schema.DataType, class string) (string, error) { return types.MapSelf(dt, class) },
+		"cType":         schema.DataType.String,
+		"isObject": func(dt schema.DataType) bool {
+			if dt.Kind == "" {
+				return dt.Name == "id" || dt.Name == "instancetype"
+			}
+			return dt.Kind == "pointer" && dt.Elem.Kind == "" && types.IsObject(dt.Elem.CName())
+		},
+		"nullable": func(dt schema.DataType) bool {
+			return dt.Nullability == schema.NullabilityNullable || dt.Nullability == schema.NullabilityResettable
+		},
+		"nonnull": func(dt schema.DataType) bool {
+			return dt.Nullability == schema.NullabilityNonnull
+		},
+		"available":   func(list interface{}) (interface{}, error) { return filterDeprecated(list, false) },
+		"deprecated":  func(list interface{}) (interface{}, error) { return filterDeprecated(list, true) },
+		"availableOn": filterAvailableOn,
+	}
+}
+
+// selectorParts returns the parts of a selector between colons.
+func selectorParts(sel string) []string {
+	return strings.Split(strings.TrimSuffix(sel, ":"), ":")
+}
+
+// camelCase returns name with its first letter in lower case, and all of
+// an acronym it starts with, as in urlString for URLString.
+func camelCase(name string) string {
+	runes := []rune(name)
+	for i := range runes {
+		if !unicode.IsUpper(runes[i]) {
+			break
+		}
+		if i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			break
+		}
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
+
+// filterDeprecated returns the elements of a slice of schema types whose
+// Deprecated field is deprecated.
+func filterDeprecated(list interface{}, deprecated bool) (interface{}, error) {
+	return filterField(list, "Deprecated", reflect.TypeOf(false), func(f reflect.Value) bool {
+		return f.Bool() == deprecated
+	})
+}
+
+// filterAvailableOn returns the elements of a slice of schema types that
+// are available on a target, like macOS 11.
+func filterAvailableOn(target string, list interface{}) (interface{}, error) {
+	t, err := schema.ParseTarget(target)
+	if err != nil {
+		return nil, err
+	}
+	return filterField(list, "Availability", reflect.TypeOf([]schema.Availability(nil)), func(f reflect.Value) bool {
+		return schema.IsAvailable(f.Interface().([]schema.Availability), t)
+	})
+}
+
+// filterField returns the elements of a slice of structs whose field of
+// a name and type is kept.
+func filterField(list interface{}, name string, typ reflect.Type, keep func(reflect.Value) bool) (interface{}, error) {
+	v := reflect.ValueOf(list)
+	if v.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("can't filter %T", list)
+	}
+	out := reflect.MakeSlice(v.Type(), 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		el := reflect.Indirect(v.Index(i))
+		if el.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("can't filter %T", list)
+		}
+		f := el.FieldByName(name)
+		if !f.IsValid() || f.Type() != typ {
+			return nil, fmt.Errorf("can't filter %T", list)
+		}
+		if keep(f) {
+			out = reflect.Append(out, v.Index(i))
+		}
+	}
+	return out.Interface(), nil
+}
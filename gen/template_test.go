@@ -0,0 +1,87 @@
+package gen
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/progrium/macschema/schema"
+)
+
+func TestTemplates_Generate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "templates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for name, text := range map[string]string{
+		"_header.tmpl": `// {{.Name}} ({{len .InstanceMethods}} methods)`,
+		"go.tmpl": `{{with .Class}}{{template "_header.tmpl" .}}
+{{- range available .InstanceMethods}}
+{{camel $.Class.Name}}.{{pascal (join "_" (selectorParts .Name))}}({{range $i, $a := .Args}}{{if $i}}, {{end}}{{snake $a.Name}} {{mapType $a.Type}}{{end}}) {{mapSelf .Return "NSWindow"}}
+{{- end}}
+{{- range .InstanceProperties}}
+{{.Name}} {{cType .Type}}{{if isObject .Type}} object{{end}}{{if nullable .Type}} nullable{{end}}{{if nonnull .Type}} nonnull{{end}}
+{{- end}}
+deprecated:{{range deprecated .InstanceMethods}} {{.Name}}{{end}}
+macOS 10.15:{{range availableOn "macOS 10.15" .InstanceMethods}} {{.Name}}{{end}}
+{{end}}`,
+		"txt.tmpl": `{{with .Enum}}{{range .Cases}}{{lower (trimPrefix "NSWindowStyleMask" .Name)}} {{end}}{{end}}`,
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	schemas := testSchemas(t)
+	c := schemas[0].Class
+	c.InstanceMethods[1].Deprecated = true
+	c.InstanceMethods[8].Deprecated = true // FSRef has no Go type
+	c.InstanceProperties[0].Type.Nullability = "nullable"
+	for i := range c.InstanceMethods {
+		c.InstanceMethods[i].Availability = schema.ParseAvailability([]string{"macOS 10.0+"})
+	}
+	c.InstanceMethods[0].Availability = schema.ParseAvailability([]string{"macOS 11.0+"})
+
+	tmpl, err := ParseTemplates(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := tmpl.Generate(schemas)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 3 {
+		t.Fatalf("files: %v", files)
+	}
+
+	want := map[string][]string{
+		"nswindow.go": {
+			"// NSWindow (10 methods)\n",
+			"nsWindow.InitWithContentRect_styleMask_backing_defer(content_rect CGRect, style NSWindowStyleMask, backing_store_type NSBackingStoreType, flag bool) NSWindow\n",
+			"nsWindow.MakeKeyAndOrderFront(sender Object) \n",
+			"title NSString * object nullable\n",
+			"visible BOOL\n",
+			"delegate id<NSWindowDelegate> object\n",
+			"deprecated: setFrame:display: unsupported\n",
+			"macOS 10.15: setFrame:display: makeKeyAndOrderFront: beginSheet:completionHandler: enumerateChildren: getBytes:error: sortUsingFunction:context: getComponents: unsupported object\n",
+		},
+		"nswindowstylemask.txt":  {"borderless titled closable all "},
+		"nsbackingstoretype.txt": {"nsbackingstoreretained nsbackingstorenonretained nsbackingstorebuffered "},
+	}
+	for _, f := range files {
+		for _, s := range want[f.Name] {
+			if !strings.Contains(string(f.Src), s) {
+				t.Errorf("%s: missing %q in\n%s", f.Name, s, f.Src)
+			}
+		}
+		if want[f.Name] == nil {
+			t.Errorf("unexpected file %s", f.Name)
+		}
+		if strings.Contains(string(f.Src), "setFrame_display") {
+			t.Errorf("%s: deprecated method not filtered", f.Name)
+		}
+	}
+}
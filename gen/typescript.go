@@ -0,0 +1,466 @@
+package gen
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/progrium/macschema/schema"
+)
+
+//go:embed templates/ts.tmpl
+var tsTemplate string
+
+// TypeScript generates TypeScript declarations (.d.ts) from Objective-C
+// schemas, for APIs used through a JavaScript bridge: a class for each
+// class, extending its superclass, an interface for each protocol, const
+// enums, interfaces for structs and type aliases for typedefs, with blocks
+// as function types. Methods are named after their selector with colons
+// replaced by underscores, as in setFrame_display_. Types used but not
+// declared by the schemas are declared as empty interfaces, or with the
+// fields of structs known to Encoding.
+type TypeScript struct {
+	// Encoding gives the layout of structs used but not declared by the
+	// schemas. It is for arm64 if nil.
+	Encoding *schema.Encoding
+
+	// Types gives the TypeScript types of type names, and resolves
+	// typedefs, with the schemas added to it. It is the built-in ts map
+	// if nil.
+	Types *schema.TypeMap
+
+	// Skipped lists the declarations Generate left out, because they use
+	// types with no TypeScript counterpart, and why.
+	Skipped []string
+}
+
+type tsFile struct {
+	Classes []tsClass
+	Enums   []tsEnum
+	Structs []tsStruct
+	Types   []tsTypeDecl
+	Funcs   []tsMember
+	Opaque  []tsClass // declared elsewhere, with no members
+}
+
+type tsClass struct {
+	Name       string
+	Doc        declDoc
+	Keyword    string // class or interface, for protocols
+	Extends    string
+	Implements string // protocols of a class
+	Members    []tsMember
+}
+
+type tsMember struct {
+	Doc  declDoc
+	Decl string // as in setTitle_(value: string): void;
+}
+
+type tsEnum struct {
+	Name  string
+	Doc   declDoc
+	Cases []tsConst
+}
+
+type tsConst struct {
+	Name  string
+	Doc   declDoc
+	Value string
+}
+
+type tsStruct struct {
+	Name   string
+	Doc    declDoc
+	Fields []tsField
+}
+
+type tsField struct {
+	Name string
+	Type string
+}
+
+type tsTypeDecl struct {
+	Name string
+	Doc  declDoc
+	Type string
+}
+
+// tsGen is the state of a Generate call.
+type tsGen struct {
+	*TypeScript
+	enc      *schema.Encoding
+	types    *schema.TypeMap
+	file     tsFile
+	declared map[string]bool
+	used     map[string]bool // types in declarations, in TypeScript
+	supers   map[string]bool // superclasses, which must be classes
+}
+
+// Generate returns the TypeScript declarations of schemas.
+func (ts *TypeScript) Generate(schemas []schema.Schema) ([]byte, error) {
+	schemas = uniqueSchemas(schemas)
+	g := &tsGen{
+		TypeScript: ts,
+		enc:        ts.Encoding,
+		types:      ts.Types,
+		declared:   make(map[string]bool),
+		used:       make(map[string]bool),
+		supers:     make(map[string]bool),
+	}
+	if g.enc == nil {
+		g.enc = schema.NewEncoding("arm64")
+	}
+	if g.types == nil {
+		types, err := schema.DefaultTypeMap("ts")
+		if err != nil {
+			return nil, err
+		}
+		g.types = types
+	}
+	ts.Skipped = nil
+
+	for _, s := range schemas {
+		g.enc.AddSchema(s)
+		g.types.AddSchema(s)
+		switch {
+		case s.Class != nil:
+			g.declared[s.Class.Name] = true
+		case s.Protocol != nil:
+			g.declared[s.Protocol.Name] = true
+		}
+	}
+	for _, s := range schemas {
+		switch {
+		case s.Class != nil:
+			g.class(*s.Class, false)
+		case s.Protocol != nil:
+			g.class(*s.Protocol, true)
+		case s.Enum != nil:
+			g.enum(*s.Enum)
+		case s.Struct != nil:
+			g.mirror(*s.Struct)
+		case s.TypeAlias != nil:
+			g.typeDecl(*s.TypeAlias)
+		case s.Function != nil:
+			g.function(*s.Function)
+		case s.APICollection != nil:
+			for _, en := range s.APICollection.Enums {
+				g.enum(en)
+			}
+			for _, fn := range s.APICollection.Functions {
+				g.function(fn)
+			}
+		}
+	}
+	g.declareUsed()
+
+	t, err := template.New("ts").Funcs(template.FuncMap{"jsdoc": jsdoc}).Parse(tsTemplate)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, g.file); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (g *tsGen) skip(name string, err error) {
+	g.Skipped = append(g.Skipped, fmt.Sprintf("%s: %v", name, err))
+}
+
+// mapType returns the TypeScript type of dt, where instancetype is class,
+// and notes it is used.
+func (g *tsGen) mapType(dt schema.DataType, class string) (string, error) {
+	t, err := g.types.MapSelf(dt, class)
+	if err == nil {
+		g.used[t] = true
+	}
+	return t, err
+}
+
+func (g *tsGen) class(c schema.Class, protocol bool) {
+	class := tsClass{
+		Name:    c.Name,
+		Doc:     declDoc{c.Description, c.TopicURL, c.Deprecated},
+		Keyword: "class",
+		Extends: c.Superclass,
+	}
+	if protocol {
+		class.Keyword = "interface"
+		class.Extends = strings.Join(c.Protocols, ", ")
+	} else {
+		class.Implements = strings.Join(c.Protocols, ", ")
+	}
+	for _, name := range append([]string{c.Superclass}, c.Protocols...) {
+		if name != "" {
+			g.used[name] = true
+		}
+	}
+	if !protocol && c.Superclass != "" {
+		g.supers[c.Superclass] = true
+	}
+
+	names := make(map[string]bool) // of members, with static ones prefixed
+	add := func(prefix, name, decl string, d declDoc, err error) {
+		switch {
+		case err != nil:
+			g.skip(c.Name+" "+name, err)
+		case !names[prefix+name]:
+			names[prefix+name] = true
+			class.Members = append(class.Members, tsMember{Doc: d, Decl: prefix + decl})
+		}
+	}
+	for _, p := range c.InstanceProperties {
+		decl, err := g.property(p, c.Name)
+		add("", p.Name, decl, declDoc{p.Description, p.TopicURL, p.Deprecated}, err)
+	}
+	for _, m := range c.InstanceMethods {
+		decl, err := g.method(m, c.Name)
+		add("", tsName(m.Name), decl, declDoc{m.Description, m.TopicURL, m.Deprecated}, err)
+	}
+	if protocol && len(c.TypeProperties)+len(c.TypeMethods) > 0 {
+		g.skip(c.Name, fmt.Errorf("type members of protocols"))
+	} else {
+		for _, p := range c.TypeProperties {
+			decl, err := g.property(p, c.Name)
+			add("static ", p.Name, decl, declDoc{p.Description, p.TopicURL, p.Deprecated}, err)
+		}
+		for _, m := range c.TypeMethods {
+			decl, err := g.method(m, c.Name)
+			add("static ", tsName(m.Name), decl, declDoc{m.Description, m.TopicURL, m.Deprecated}, err)
+		}
+	}
+	g.file.Classes = append(g.file.Classes, class)
+}
+
+// tsName returns the name of the method of a selector, with colons
+// replaced by underscores as JavaScript bridges do.
+func tsName(sel string) string {
+	return strings.ReplaceAll(sel, ":", "_")
+}
+
+func (g *tsGen) property(p schema.Property, class string) (string, error) {
+	t, err := g.mapType(p.Type, class)
+	if err != nil {
+		return "", err
+	}
+	decl := fmt.Sprintf("%s: %s;", p.Name, t)
+	if p.Attrs["readonly"] != nil {
+		decl = "readonly " + decl
+	}
+	return decl, nil
+}
+
+func (g *tsGen) method(m schema.Method, class string) (string, error) {
+	params, err := g.params(m.Args, class)
+	if err != nil {
+		return "", err
+	}
+	ret, err := g.mapType(m.Return, class)
+	if err != nil {
+		return "", fmt.Errorf("result: %w", err)
+	}
+	return fmt.Sprintf("%s(%s): %s;", tsName(m.Name), params, ret), nil
+}
+
+func (g *tsGen) function(f schema.Func) {
+	params, err := g.params(f.Args, "")
+	if err != nil {
+		g.skip(f.Name, err)
+		return
+	}
+	ret, err := g.mapType(f.Return, "")
+	if err != nil {
+		g.skip(f.Name, fmt.Errorf("result: %w", err))
+		return
+	}
+	g.declared[f.Name] = true
+	g.file.Funcs = append(g.file.Funcs, tsMember{
+		Doc:  declDoc{f.Description, f.TopicURL, f.Deprecated},
+		Decl: fmt.Sprintf("%s(%s): %s;", f.Name, params, ret),
+	})
+}
+
+// tsReserved are the words parameters can't be named.
+var tsReserved = map[string]bool{
+	"break": true, "case": true, "catch": true, "class": true, "const": true,
+	"continue": true, "debugger": true, "default": true, "delete": true, "do": true,
+	"else": true, "enum": true, "export": true, "extends": true, "false": true,
+	"finally": true, "for": true, "function": true, "if": true, "import": true,
+	"in": true, "instanceof": true, "new": true, "null": true, "return": true,
+	"super": true, "switch": true, "this": true, "throw": true, "true": true,
+	"try": true, "typeof": true, "var": true, "void": true, "while": true,
+	"with": true, "let": true, "static": true, "yield": true, "await": true,
+}
+
+func (g *tsGen) params(args []schema.Arg, class string) (string, error) {
+	var params []string
+	for i, arg := range args {
+		t, err := g.mapType(arg.Type, class)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", arg.Name, err)
+		}
+		name := arg.Name
+		if name == "" {
+			name = fmt.Sprintf("arg%d", i)
+		} else if tsReserved[name] {
+			name += "_"
+		}
+		params = append(params, name+": "+t)
+	}
+	return strings.Join(params, ", "), nil
+}
+
+// enum declares the const enum of an enum. Cases without an integer value
+// are left out.
+func (g *tsGen) enum(en schema.Enum) {
+	e := tsEnum{Name: en.Name, Doc: declDoc{en.Description, en.TopicURL, en.Deprecated}}
+	for _, c := range en.Cases {
+		if c.IntValue == nil {
+			g.skip(c.Name, fmt.Errorf("no integer value"))
+			continue
+		}
+		e.Cases = append(e.Cases, tsConst{
+			Name:  c.Name,
+			Doc:   declDoc{c.Description, c.TopicURL, c.Deprecated},
+			Value: c.IntValue.String(),
+		})
+	}
+	g.declared[en.Name] = true
+	g.file.Enums = append(g.file.Enums, e)
+}
+
+// mirror declares the interface of a struct, once.
+func (g *tsGen) mirror(st schema.Struct) {
+	if g.declared[st.Name] {
+		return
+	}
+	g.declared[st.Name] = true
+	s := tsStruct{Name: st.Name, Doc: declDoc{st.Description, st.TopicURL, st.Deprecated}}
+	for _, f := range st.Fields {
+		t, err := g.mapType(f.Type, "")
+		if err != nil {
+			g.skip(st.Name+" "+f.Name, err)
+			continue
+		}
+		s.Fields = append(s.Fields, tsField{Name: f.Name, Type: t})
+	}
+	g.file.Structs = append(g.file.Structs, s)
+}
+
+func (g *tsGen) typeDecl(ta schema.TypeAlias) {
+	t, err := g.mapType(ta.Type, "")
+	if err != nil {
+		g.skip(ta.Name, err)
+		return
+	}
+	g.declared[ta.Name] = true
+	g.file.Types = append(g.file.Types, tsTypeDecl{
+		Name: ta.Name,
+		Doc:  declDoc{ta.Description, ta.TopicURL, ta.Deprecated},
+		Type: t,
+	})
+}
+
+var (
+	// tsIdent matches the names in a TypeScript type.
+	tsIdent = regexp.MustCompile(`[A-Za-z_$][\w$]*`)
+
+	// tsPlaceholder matches the placeholders of type map patterns.
+	tsPlaceholder = regexp.MustCompile(`\{\w+\}`)
+)
+
+// typeNames returns the names of the types in a TypeScript type, leaving
+// out parameter names.
+func typeNames(t string) []string {
+	var names []string
+	for _, loc := range tsIdent.FindAllStringIndex(t, -1) {
+		if rest := strings.TrimLeft(t[loc[1]:], " ?"); strings.HasPrefix(rest, ":") {
+			continue
+		}
+		names = append(names, t[loc[0]:loc[1]])
+	}
+	return names
+}
+
+// declareUsed declares the types used but not declared: structs with the
+// fields they have in Encoding, superclasses as empty classes and others
+// as empty interfaces. The types of the type map are its own, like number
+// and Pointer.
+func (g *tsGen) declareUsed() {
+	builtin := map[string]bool{"null": true}
+	m := g.types
+	patterns := []string{m.Object, m.Protocols, m.Generic, m.Pointer, m.Array, m.Block, m.Function, m.Param, m.Self, m.Nullable}
+	for _, t := range m.Types {
+		patterns = append(patterns, t)
+	}
+	for _, t := range patterns {
+		for _, name := range typeNames(tsPlaceholder.ReplaceAllString(t, "")) {
+			builtin[name] = true
+		}
+	}
+	opaque := make(map[string]bool)
+	for len(g.used) > 0 {
+		used := g.used
+		g.used = make(map[string]bool)
+		for t := range used {
+			for _, name := range typeNames(t) {
+				if builtin[name] || g.declared[name] || opaque[name] {
+					continue
+				}
+				if st, ok := g.enc.Structs[name]; ok {
+					// fields may use more types
+					g.mirror(st)
+					continue
+				}
+				opaque[name] = true
+			}
+		}
+	}
+	var names []string
+	for name := range opaque {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		c := tsClass{Name: name, Keyword: "interface"}
+		if g.supers[name] {
+			c.Keyword = "class"
+		}
+		g.file.Opaque = append(g.file.Opaque, c)
+	}
+}
+
+// jsdoc returns the documentation of a declaration as a JSDoc comment,
+// with each line indented, or nothing for no documentation.
+func jsdoc(d declDoc, indent string) string {
+	var lines []string
+	if d.Description != "" {
+		lines = append(lines, strings.Split(d.Description, "\n")...)
+	}
+	if d.TopicURL != "" {
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, "@see "+d.TopicURL)
+	}
+	if d.Deprecated {
+		lines = append(lines, "@deprecated")
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	b := &strings.Builder{}
+	b.WriteString(indent + "/**\n")
+	for _, line := range lines {
+		b.WriteString(strings.TrimRight(indent+" * "+line, " ") + "\n")
+	}
+	b.WriteString(indent + " */\n")
+	return b.String()
+}
@@ -0,0 +1,75 @@
+package gen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/progrium/macschema/declparse"
+	"github.com/progrium/macschema/schema"
+)
+
+func TestTypeScript_Generate(t *testing.T) {
+	schemas := testSchemas(t)
+	schemas[0].Class.Superclass = "NSResponder"
+	schemas[0].Class.Protocols = []string{"NSWindowDelegate"}
+	schemas[0].Class.Deprecated = true
+
+	delegate := &schema.Class{
+		Identifier: schema.Identifier{Name: "NSWindowDelegate", Description: "A set of optional methods that a delegate of a window can implement."},
+		Protocols:  []string{"NSObject"},
+	}
+	for _, decl := range []string{
+		`- (BOOL)windowShouldClose:(NSWindow *)sender;`,
+		`- (nullable id)windowWillReturnFieldEditor:(NSWindow *)sender toObject:(nullable id)client;`,
+	} {
+		m := schema.MethodFromAst(*parseDecl(t, decl, declparse.HintNone).Method, false)
+		delegate.InstanceMethods = append(delegate.InstanceMethods, m)
+	}
+	schemas = append(schemas, schema.Schema{Protocol: delegate})
+
+	ts := &TypeScript{}
+	src, err := ts.Generate(schemas)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"/**\n * A window that an app displays on the screen.\n *\n * @see https://developer.apple.com/documentation/appkit/nswindow?language=objc\n * @deprecated\n */\nexport declare class NSWindow extends NSResponder {",
+		"export interface NSWindow extends NSWindowDelegate {}",
+		"    initWithContentRect_styleMask_backing_defer_(contentRect: CGRect, style: NSWindowStyleMask, backingStoreType: NSBackingStoreType, flag: boolean): NSWindow;",
+		"    beginSheet_completionHandler_(sheetWindow: NSWindow, handler: (returnCode: number) => void): void;",
+		"    enumerateChildren_(block: (window: NSWindow, stop: Pointer<boolean>) => void): void;",
+		"    getBytes_error_(buffer: Pointer<void>, error: Pointer<NSError>): boolean;",
+		"    sortUsingFunction_context_(compare: (arg0: any, arg1: any, arg2: Pointer<void>) => number, context: Pointer<void>): void;",
+		"    getComponents_(components: number[]): void;",
+		"    title: NSString;",
+		"    readonly frame: CGRect;",
+		"    readonly visible: boolean;",
+		"    delegate: NSWindowDelegate;",
+		"    static windowWithContentViewController_(contentViewController: NSViewController): NSWindow;",
+		"    static styleMask(): NSWindowStyleMask;",
+		"/**\n * A set of optional methods that a delegate of a window can implement.\n */\nexport declare interface NSWindowDelegate extends NSObject {",
+		"    windowWillReturnFieldEditor_toObject_(sender: NSWindow, client: any): any;",
+		"export declare const enum NSWindowStyleMask {\n    NSWindowStyleMaskBorderless = 0,\n    NSWindowStyleMaskTitled = 1,\n    NSWindowStyleMaskClosable = 2,\n    NSWindowStyleMaskAll = -1,\n}",
+		"export interface NSDirectionalEdgeInsets {\n    top: number;\n    leading: number;\n    bottom: number;\n    trailing: number;\n}",
+		"export interface Node {\n    tag: number;\n    next: Pointer<Node>;\n    value: number;\n    flags: boolean[];\n}",
+		"export interface CGRect {\n    origin: CGPoint;\n    size: CGSize;\n}",
+		"export interface CGPoint {\n    x: number;\n    y: number;\n}",
+		"export type NSWindowEnumerator = (window: NSWindow, stop: Pointer<boolean>) => void;",
+		"export type NSModalResponse = number;",
+		"export declare function NSBeep(): void;",
+		"export declare function NSInsetRect(aRect: CGRect, dX: number, dY: number): CGRect;",
+		"export declare interface NSError {}\nexport declare interface NSObject {}\nexport declare class NSResponder {}\nexport declare interface NSString {}\nexport declare interface NSViewController {}\n",
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+	if t.Failed() {
+		t.Logf("\n%s", src)
+	}
+
+	skipped := strings.Join(ts.Skipped, "\n")
+	if !strings.Contains(skipped, "NSWindow unsupported: ") {
+		t.Errorf("FSRef method not skipped: %v", ts.Skipped)
+	}
+}
@@ -2,22 +2,27 @@ package lexer
 
 import (
 	"io"
+	"strings"
 )
 
 // TokenBuffer represents a wrapper for scanner to add a buffer.
 // It provides a fixed-length circular buffer that can be unread.
 type TokenBuffer struct {
 	IgnoreWhitespace bool
+	IgnoreComments   bool // skipped comments are kept, see Comments
 
 	s   *Scanner
 	i   int // buffer index
-	n   int // buffer size
+	n   int // number of unscanned tokens
+	c   int // number of tokens read
 	buf [6]struct {
-		tok Token
-		pos Pos
-		lit string
+		tok      Token
+		pos      Pos
+		lit      string
+		comments []string
 	}
-	replace []Token
+	replace  []Token
+	comments []string
 }
 
 // NewTokenBuffer returns a new buffered scanner for a reader.
@@ -30,11 +35,6 @@ func (s *TokenBuffer) Scan() (tok Token, pos Pos, lit string) {
 	return s.ScanFunc(s.s.Scan)
 }
 
-// ScanRegex reads a regex token from the scanner.
-func (s *TokenBuffer) ScanRegex() (tok Token, pos Pos, lit string) {
-	return s.ScanFunc(s.s.ScanRegex)
-}
-
 // OneRuneOperators sets the scanner option to ignore multi rune operators.
 func (s *TokenBuffer) OneRuneOperators(b bool) {
 	s.s.OneRuneOperators = b
@@ -50,20 +50,49 @@ func (s *TokenBuffer) ScanFunc(scan func() (Token, Pos, string)) (tok Token, pos
 
 	tok, pos, lit = scan()
 
-	if s.IgnoreWhitespace && tok == WS {
+	for {
+		if s.IgnoreWhitespace && tok == WS {
+			// a blank line detaches comments from what follows
+			if strings.Count(lit, "\n") > 1 {
+				s.comments = nil
+			}
+		} else if s.IgnoreComments && tok == COMMENT {
+			// comments after a token on the same line don't lead the next
+			if !s.s.midLine {
+				s.comments = append(s.comments, lit)
+			}
+		} else {
+			break
+		}
 		tok, pos, lit = scan()
 	}
 
 	// Move buffer position forward and save the token.
+	if s.c < len(s.buf) {
+		s.c++
+	}
 	s.i = (s.i + 1) % len(s.buf)
 	buf := &s.buf[s.i]
 	buf.tok, buf.pos, buf.lit = tok, pos, lit
+	buf.comments, s.comments = s.comments, nil
 
 	return s.Current()
 }
 
-// Unscan pushes the previously token back onto the buffer.
-func (s *TokenBuffer) Unscan() { s.n++ }
+// Comments returns the skipped comments on the lines before the current
+// token. Comments followed by a blank line or after another token on
+// their line are dropped.
+func (s *TokenBuffer) Comments() []string {
+	return s.buf[(s.i-s.n+len(s.buf))%len(s.buf)].comments
+}
+
+// Unscan pushes the previously read token back onto the buffer.
+// Unscanning past the first token or the size of the buffer is a no-op.
+func (s *TokenBuffer) Unscan() {
+	if s.n < s.c {
+		s.n++
+	}
+}
 
 // curr returns the last read token.
 func (s *TokenBuffer) Current() (tok Token, pos Pos, lit string) {
@@ -0,0 +1,99 @@
+package lexer
+
+import (
+	"strings"
+	"testing"
+)
+
+var fuzzSeeds = []string{
+	``,
+	`@interface NSMenu : NSObject`,
+	`+ (instancetype)arrayWithObjects:(ObjectType)firstObj, ...;`,
+	`- (void)beginSheet:(NSWindow *)sheetWindow completionHandler:(void (^)(NSModalResponse returnCode))handler;`,
+	`@property(readonly, copy) NSArray<__kindof NSWindow *> *sheets;`,
+	`typedef enum WKUserScriptInjectionTime : NSInteger { ... } WKUserScriptInjectionTime;`,
+	`kCALayerLeftEdge = 1U << 0`,
+	`NSString *const NSTitle = "title \"quoted\"";`,
+	`'foo\'bar' -.23 +100.23 10.3E-0.5.3 ** -> => != <= >= << >>`,
+	".\r\n\t..",
+}
+
+// Scanning must always make progress and end at EOF.
+func FuzzScanner(f *testing.F) {
+	for _, s := range fuzzSeeds {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		sc := NewScanner(strings.NewReader(s))
+		for i := 0; ; i++ {
+			if i > len(s)+1 {
+				t.Fatalf("scanner did not reach EOF after %d tokens", i)
+			}
+			if tok, _, _ := sc.Scan(); tok == EOF {
+				break
+			}
+		}
+	})
+}
+
+// Scans interleaved with unscans must see the same tokens as a plain scanner.
+func FuzzTokenBuffer(f *testing.F) {
+	for _, s := range fuzzSeeds {
+		f.Add(s, []byte{0, 0, 1, 0, 2, 1, 1, 0})
+	}
+	f.Fuzz(func(t *testing.T, s string, ops []byte) {
+		type token struct {
+			tok Token
+			lit string
+		}
+		var want []token
+		sc := NewScanner(strings.NewReader(s))
+		for {
+			tok, _, lit := sc.Scan()
+			if tok == WS {
+				continue
+			}
+			want = append(want, token{tok, lit})
+			if tok == EOF || len(want) > len(s)+1 {
+				break
+			}
+		}
+
+		tb := NewTokenBuffer(strings.NewReader(s))
+		tb.IgnoreWhitespace = true
+		// i is the index of the next token, n the number of unscanned ones
+		i, n := 0, 0
+		scan := func(scanFn func() (Token, Pos, string)) {
+			idx := i
+			if idx >= len(want) {
+				idx = len(want) - 1
+			}
+			tok, _, lit := scanFn()
+			if got := (token{tok, lit}); got != want[idx] {
+				t.Fatalf("token %d: got %v, want %v", idx, got, want[idx])
+			}
+			i++
+			if n > 0 {
+				n--
+			}
+		}
+		unscan := func(unscanFn func()) {
+			unscanFn()
+			if i > 0 && n < len(tb.buf) {
+				i--
+				n++
+			}
+		}
+		for _, op := range ops {
+			switch op % 3 {
+			case 0:
+				scan(tb.Scan)
+			case 1:
+				unscan(tb.Unscan)
+			case 2:
+				scan(tb.Peek)
+				unscan(func() {})
+			}
+		}
+	})
+}
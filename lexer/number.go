@@ -0,0 +1,104 @@
+package lexer
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Number is the decoded value of an INTEGER or DECIMAL literal.
+type Number struct {
+	Base    int     // 2, 8, 10 or 16
+	Suffix  string  // type suffix as written, eg "ULL" or "f"
+	Neg     bool    // literal has a leading minus sign
+	IsFloat bool    // literal is a floating constant
+	Int     uint64  // magnitude of an integer constant
+	Float   float64 // value of a floating constant, including its sign
+}
+
+// Unsigned returns true if the suffix makes an integer constant unsigned.
+func (n Number) Unsigned() bool {
+	return strings.ContainsAny(n.Suffix, "uU")
+}
+
+// ParseNumber decodes the literal of an INTEGER or DECIMAL token.
+func ParseNumber(lit string) (Number, error) {
+	var n Number
+	s := lit
+	if strings.HasPrefix(s, "+") {
+		s = s[1:]
+	} else if strings.HasPrefix(s, "-") {
+		s, n.Neg = s[1:], true
+	}
+
+	lower := strings.ToLower(s)
+	switch {
+	case strings.HasPrefix(lower, "0x"):
+		n.Base = 16
+		n.IsFloat = strings.ContainsAny(lower, ".p")
+	case strings.HasPrefix(lower, "0b"):
+		n.Base = 2
+	default:
+		n.Base = 10
+		n.IsFloat = strings.ContainsAny(lower, ".e")
+		if !n.IsFloat && len(s) > 1 && s[0] == '0' {
+			n.Base = 8
+		}
+	}
+
+	valid := isIntSuffix
+	if n.IsFloat {
+		valid = isFloatSuffix
+	}
+	i := len(s)
+	for i > 0 && valid(s[i-1:]) {
+		i--
+	}
+	s, n.Suffix = s[:i], s[i:]
+
+	var err error
+	if n.IsFloat {
+		if n.Base == 16 && !strings.ContainsAny(lower, "p") {
+			return Number{}, fmt.Errorf("hexadecimal floating constant %q has no exponent", lit)
+		}
+		n.Float, err = strconv.ParseFloat(s, 64)
+		if n.Neg {
+			n.Float = -n.Float
+		}
+	} else {
+		digits := s
+		if n.Base != 10 && n.Base != 8 {
+			digits = s[2:]
+		}
+		n.Int, err = strconv.ParseUint(digits, n.Base, 64)
+	}
+	if err != nil {
+		return Number{}, fmt.Errorf("invalid number %q: %w", lit, errors.Unwrap(err))
+	}
+	return n, nil
+}
+
+// isIntSuffix returns true if s is an integer suffix or the start of one.
+// The L of a long long suffix must be repeated in the same case.
+func isIntSuffix(s string) bool {
+	switch s {
+	case "u", "U", "l", "L",
+		"ul", "uL", "Ul", "UL", "lu", "lU", "Lu", "LU",
+		"ll", "LL", "ull", "uLL", "Ull", "ULL", "llu", "llU", "LLu", "LLU":
+		return true
+	}
+	return false
+}
+
+// isFloatSuffix returns true if s is a floating suffix.
+func isFloatSuffix(s string) bool {
+	switch s {
+	case "f", "F", "l", "L":
+		return true
+	}
+	return false
+}
+
+// isBinaryDigit returns true if the rune is a binary digit.
+func isBinaryDigit(ch rune) bool { return ch == '0' || ch == '1' }
@@ -10,11 +10,17 @@ import (
 // NOTE: The code below is HEAVILY influenced by the InfluxQL parser and lexer.
 // You can find the InfluxDB code at https://github.com/influxdb/influxdb/blob/master/influxql/scanner.go
 
-// Scanner represents a lexical scanner for InfluxQL.
+// Scanner represents a lexical scanner for C and Objective-C declarations.
 type Scanner struct {
 	OneRuneOperators bool // only scan one rune operators (+, not ++)
 
-	r *reader
+	r       *reader
+	midLine bool // true if a token other than whitespace precedes on the line
+	pending *struct {
+		tok Token
+		pos Pos
+		lit string
+	} // identifier read after a prefix rune that didn't form a keyword
 }
 
 // NewScanner returns a new instance of Scanner.
@@ -26,6 +32,24 @@ func NewScanner(r io.Reader) *Scanner {
 // Also returns the literal text read for strings and numbers tokens
 // since these token types can have different literal representations.
 func (s *Scanner) Scan() (tok Token, pos Pos, lit string) {
+	tok, pos, lit = s.scan()
+	switch tok {
+	case WS:
+		if strings.Contains(lit, "\n") {
+			s.midLine = false
+		}
+	case COMMENT:
+	default:
+		s.midLine = true
+	}
+	return
+}
+
+func (s *Scanner) scan() (tok Token, pos Pos, lit string) {
+	if p := s.pending; p != nil {
+		s.pending = nil
+		return p.tok, p.pos, p.lit
+	}
 
 	// Read next code point.
 	ch0, pos := s.r.read()
@@ -35,11 +59,15 @@ func (s *Scanner) Scan() (tok Token, pos Pos, lit string) {
 	// as an ident or reserved word.
 	if isWhitespace(ch0) {
 		return s.scanWhitespace()
-	} else if isLetter(ch0) || ch0 == '_' || isPrefixed(ch0) {
+	} else if isLetter(ch0) || ch0 == '_' {
 		s.r.unread()
 		return s.scanIdent()
 	} else if isDigit(ch0) {
 		return s.scanNumber()
+	} else if isPrefixed(ch0) && (ch0 != '#' || s.midLine) {
+		if tok, lit, ok := s.scanPrefixed(ch0); ok {
+			return tok, pos, lit
+		}
 	}
 
 	// Otherwise parse individual characters.
@@ -49,7 +77,7 @@ func (s *Scanner) Scan() (tok Token, pos Pos, lit string) {
 	case '"':
 		return s.scanString()
 	case '\'':
-		return s.scanString()
+		return s.scanChar()
 	case '.':
 		ch1, _ := s.r.read()
 		ch2, _ := s.r.read()
@@ -57,8 +85,8 @@ func (s *Scanner) Scan() (tok Token, pos Pos, lit string) {
 			return VARARG, pos, "..."
 		}
 		s.r.unread()
+		s.r.unread()
 		if isDigit(ch1) {
-			s.r.unread()
 			return s.scanNumber()
 		}
 		return DOT, pos, ""
@@ -77,19 +105,17 @@ func (s *Scanner) Scan() (tok Token, pos Pos, lit string) {
 		s.r.unread()
 		return s.scanNumber()
 	case '*':
-		if ch1, _ := s.r.read(); ch1 == '*' && !s.OneRuneOperators {
-			return POW, pos, ""
-		}
-		s.r.unread()
 		return MUL, pos, ""
 	case '/':
+		if ch1, _ := s.r.read(); ch1 == '/' {
+			return s.scanLineComment(pos)
+		} else if ch1 == '*' {
+			return s.scanBlockComment(pos)
+		}
+		s.r.unread()
 		return DIV, pos, ""
 	case '=':
-		if ch1, _ := s.r.read(); ch1 == '~' && !s.OneRuneOperators {
-			return EQREGEX, pos, ""
-		} else if ch1 == '>' && !s.OneRuneOperators {
-			return EQARROW, pos, ""
-		} else if ch1 == '=' && !s.OneRuneOperators {
+		if ch1, _ := s.r.read(); ch1 == '=' && !s.OneRuneOperators {
 			return EQEQ, pos, ""
 		}
 		s.r.unread()
@@ -97,10 +123,9 @@ func (s *Scanner) Scan() (tok Token, pos Pos, lit string) {
 	case '!':
 		if ch1, _ := s.r.read(); ch1 == '=' && !s.OneRuneOperators {
 			return NEQ, pos, ""
-		} else if ch1 == '~' && !s.OneRuneOperators {
-			return NEQREGEX, pos, ""
 		}
 		s.r.unread()
+		return NOT, pos, ""
 	case '>':
 		if ch1, _ := s.r.read(); ch1 == '=' && !s.OneRuneOperators {
 			return GTE, pos, ""
@@ -112,8 +137,6 @@ func (s *Scanner) Scan() (tok Token, pos Pos, lit string) {
 	case '<':
 		if ch1, _ := s.r.read(); ch1 == '=' && !s.OneRuneOperators {
 			return LTE, pos, ""
-		} else if ch1 == '>' && !s.OneRuneOperators {
-			return NEQ, pos, ""
 		} else if ch1 == '<' && !s.OneRuneOperators {
 			return LSHIFT, pos, ""
 		}
@@ -137,19 +160,40 @@ func (s *Scanner) Scan() (tok Token, pos Pos, lit string) {
 		return SEMICOLON, pos, ""
 	case ':':
 		return COLON, pos, ""
+	case '?':
+		return QUESTION, pos, ""
 	case '^':
 		return XOR, pos, ""
+	case '~':
+		return TILDE, pos, ""
 	case '|':
+		if ch1, _ := s.r.read(); ch1 == '|' && !s.OneRuneOperators {
+			return LOR, pos, ""
+		}
+		s.r.unread()
 		return PIPE, pos, ""
 	case '&':
+		if ch1, _ := s.r.read(); ch1 == '&' && !s.OneRuneOperators {
+			return LAND, pos, ""
+		}
+		s.r.unread()
 		return AMPERSAND, pos, ""
 	case '%':
-		return PERCENT, pos, ``
-	case '$':
-		return DOLLAR, pos, ""
+		return PERCENT, pos, ""
 	case '#':
+		if !s.midLine {
+			return s.scanDirective(pos)
+		}
 		return HASH, pos, ""
 	case '@':
+		if ch1, _ := s.r.read(); ch1 == '"' {
+			tok, _, lit := s.scanString()
+			if tok == STRING {
+				return ATSTRING, pos, lit
+			}
+			return tok, pos, lit
+		}
+		s.r.unread()
 		return ATSIGN, pos, ""
 	}
 
@@ -208,28 +252,7 @@ func (s *Scanner) scanIdent() (tok Token, pos Pos, lit string) {
 	_, pos = s.r.read()
 	s.r.unread()
 
-	var buf bytes.Buffer
-	for {
-		if ch, _ := s.r.read(); ch == eof {
-			break
-		} else if ch == '"' {
-			tok0, pos0, lit0 := s.scanString()
-			if tok0 == BADSTRING || tok0 == BADESCAPE {
-				return tok0, pos0, lit0
-			}
-			return IDENT, pos, lit0
-		} else if isIdentChar(ch) {
-			s.r.unread()
-			buf.WriteString(ScanBareIdent(s.r))
-		} else if isPrefixed(ch) {
-			buf.WriteRune(ch)
-			buf.WriteString(ScanBareIdent(s.r))
-		} else {
-			s.r.unread()
-			break
-		}
-	}
-	lit = buf.String()
+	lit = ScanBareIdent(s.r)
 
 	// If the literal matches a keyword then return that keyword.
 	if tok = Lookup(lit); tok != IDENT {
@@ -239,11 +262,45 @@ func (s *Scanner) scanIdent() (tok Token, pos Pos, lit string) {
 	return IDENT, pos, lit
 }
 
+// scanPrefixed consumes a keyword that starts with the prefix rune ch, like
+// @interface. If the identifier after the prefix doesn't form a keyword,
+// the prefix is returned as punctuation and the identifier is kept for the
+// next scan. It returns false without consuming anything if no identifier
+// follows the prefix.
+func (s *Scanner) scanPrefixed(ch rune) (tok Token, lit string, ok bool) {
+	ch1, ipos := s.r.read()
+	s.r.unread()
+	if !isLetter(ch1) && ch1 != '_' {
+		return ILLEGAL, "", false
+	}
+	ident := ScanBareIdent(s.r)
+	if tok := Lookup(string(ch) + ident); tok != IDENT {
+		return tok, "", true
+	}
+
+	tok = Lookup(ident)
+	if tok != IDENT {
+		ident = ""
+	}
+	s.pending = &struct {
+		tok Token
+		pos Pos
+		lit string
+	}{tok, ipos, ident}
+
+	for tok, str := range tokens {
+		if tok < startLiterals && str == string(ch) {
+			return tok, "", true
+		}
+	}
+	return ILLEGAL, string(ch), true
+}
+
 // scanString consumes a contiguous string of non-quote characters.
 // Quote characters can be consumed if they're first escaped with a backslash.
 func (s *Scanner) scanString() (tok Token, pos Pos, lit string) {
-	s.r.unread()
 	_, pos = s.r.curr()
+	s.r.unread()
 
 	var err error
 	lit, err = ScanString(s.r)
@@ -256,13 +313,108 @@ func (s *Scanner) scanString() (tok Token, pos Pos, lit string) {
 	return STRING, pos, lit
 }
 
+// scanChar consumes a character constant. The literal is the source text
+// between the quotes with escapes intact; use CharValue to decode it.
+func (s *Scanner) scanChar() (tok Token, pos Pos, lit string) {
+	_, pos = s.r.curr()
+	var buf bytes.Buffer
+	for {
+		ch0, _ := s.r.read()
+		switch ch0 {
+		case '\'':
+			if buf.Len() == 0 {
+				return BADSTRING, pos, ""
+			}
+			return CHAR, pos, buf.String()
+		case eof, '\n':
+			return BADSTRING, pos, buf.String()
+		case '\\':
+			ch1, epos := s.r.read()
+			if !isEscape(ch1) {
+				return BADESCAPE, epos, string(ch0) + string(ch1)
+			}
+			buf.WriteRune(ch0)
+			buf.WriteRune(ch1)
+		default:
+			buf.WriteRune(ch0)
+		}
+	}
+}
+
+// scanLineComment consumes a // comment up to the end of the line.
+func (s *Scanner) scanLineComment(pos Pos) (tok Token, _ Pos, lit string) {
+	buf := bytes.NewBufferString("//")
+	for {
+		ch, _ := s.r.read()
+		if ch == eof {
+			break
+		} else if ch == '\n' {
+			s.r.unread()
+			break
+		}
+		buf.WriteRune(ch)
+	}
+	return COMMENT, pos, buf.String()
+}
+
+// scanBlockComment consumes a /* */ comment.
+func (s *Scanner) scanBlockComment(pos Pos) (tok Token, _ Pos, lit string) {
+	buf := bytes.NewBufferString("/*")
+	for {
+		ch, _ := s.r.read()
+		if ch == eof {
+			return ILLEGAL, pos, buf.String()
+		}
+		buf.WriteRune(ch)
+		if ch == '*' {
+			if ch1, _ := s.r.read(); ch1 == '/' {
+				buf.WriteRune(ch1)
+				return COMMENT, pos, buf.String()
+			}
+			s.r.unread()
+		}
+	}
+}
+
+// scanDirective consumes a preprocessor directive up to the end of the
+// line. Escaped newlines continue the directive on the next line and are
+// replaced by a space. A # with nothing after it is returned as a HASH.
+func (s *Scanner) scanDirective(pos Pos) (tok Token, _ Pos, lit string) {
+	buf := bytes.NewBufferString("#")
+	for {
+		ch, _ := s.r.read()
+		if ch == eof {
+			break
+		} else if ch == '\n' {
+			s.r.unread()
+			break
+		} else if ch == '\\' {
+			if ch1, _ := s.r.read(); ch1 == '\n' {
+				buf.WriteRune(' ')
+				continue
+			}
+			s.r.unread()
+		}
+		buf.WriteRune(ch)
+	}
+	lit = strings.TrimRightFunc(buf.String(), isWhitespace)
+	if lit == "#" {
+		return HASH, pos, ""
+	}
+	return DIRECTIVE, pos, lit
+}
+
 // scanNumber consumes anything that looks like the start of a number.
 // Numbers start with a digit, full stop, plus sign or minus sign.
 // This function can return non-number tokens if a scan is a false positive.
 // For example, a minus sign followed by a letter will just return a minus sign.
+//
+// Integers may be written in decimal, octal (0777), hexadecimal (0xFF) or
+// binary (0b1010) and floating constants in decimal or hexadecimal (0x1p-3)
+// form. Both may carry a C type suffix such as UL or f. The literal is the
+// source text; use ParseNumber to decode it.
 func (s *Scanner) scanNumber() (tok Token, pos Pos, lit string) {
 	var buf bytes.Buffer
-	// var isFloat bool
 
 	// Check if the initial rune is a "+" or "-".
 	ch, pos := s.r.curr()
@@ -295,102 +447,136 @@ func (s *Scanner) scanNumber() (tok Token, pos Pos, lit string) {
 		s.r.unread()
 	}
 
+	// Hexadecimal and binary constants are introduced by a prefix.
+	if ch0, _ := s.r.read(); ch0 == '0' {
+		ch1, _ := s.r.read()
+		ch2, _ := s.r.read()
+		s.r.unread()
+		switch {
+		case (ch1 == 'x' || ch1 == 'X') && (isHexDigit(ch2) || ch2 == '.'):
+			buf.WriteRune(ch0)
+			buf.WriteRune(ch1)
+			return s.scanHexNumber(pos, &buf)
+		case (ch1 == 'b' || ch1 == 'B') && isBinaryDigit(ch2):
+			buf.WriteRune(ch0)
+			buf.WriteRune(ch1)
+			buf.WriteString(s.scanDigitsFunc(isBinaryDigit))
+			buf.WriteString(s.scanSuffix(isIntSuffix))
+			return INTEGER, pos, buf.String()
+		}
+		s.r.unread()
+		s.r.unread()
+	} else {
+		s.r.unread()
+	}
+
 	// Read as many digits as possible.
 	buf.WriteString(s.scanDigits())
+	tok = INTEGER
 
-	// Exponent?
-	if exp, _ := s.r.read(); exp == 'E' || exp == 'e' {
-		buf.WriteRune('E')
-		buf.WriteString(s.scanExponent())
-		return DECIMAL, pos, buf.String()
+	// A full stop makes it a floating constant with an optional fraction.
+	if ch0, _ := s.r.read(); ch0 == '.' {
+		_, _ = buf.WriteRune(ch0)
+		_, _ = buf.WriteString(s.scanDigits())
+		tok = DECIMAL
 	} else {
 		s.r.unread()
 	}
 
-	// If next code points are a full stop and digit then consume them.
-	if ch0, _ := s.r.read(); ch0 == '.' {
-		if ch1, _ := s.r.read(); isDigit(ch1) {
-			_, _ = buf.WriteRune(ch0)
-			_, _ = buf.WriteRune(ch1)
-			_, _ = buf.WriteString(s.scanDigits())
-		}
-
-		// Exponent?
-		if exp, _ := s.r.read(); exp == 'E' || exp == 'e' {
-			buf.WriteRune('E')
-			buf.WriteString(s.scanExponent())
-			return DECIMAL, pos, buf.String()
-		} else {
-			s.r.unread()
-		}
-		return DECIMAL, pos, buf.String()
+	// Exponent?
+	if exp := s.scanExponent('e', 'E'); exp != "" {
+		buf.WriteString(exp)
+		tok = DECIMAL
+	}
 
+	if tok == DECIMAL {
+		buf.WriteString(s.scanSuffix(isFloatSuffix))
 	} else {
-		s.r.unread()
+		buf.WriteString(s.scanSuffix(isIntSuffix))
 	}
+	return tok, pos, buf.String()
+}
 
-	// Attempt to read as a duration if it doesn't have a fractional part.
-	if !strings.Contains(buf.String(), ".") {
-		// If the next rune is a duration unit (u,µ,ms,s) then return a duration token
-		if ch0, _ := s.r.read(); ch0 == 'u' || ch0 == 'µ' || ch0 == 's' || ch0 == 'h' || ch0 == 'd' || ch0 == 'w' {
-			_, _ = buf.WriteRune(ch0)
-			return DURATION, pos, buf.String()
-		} else if ch0 == 'm' {
-			_, _ = buf.WriteRune(ch0)
-			if ch1, _ := s.r.read(); ch1 == 's' {
-				_, _ = buf.WriteRune(ch1)
-			} else {
-				s.r.unread()
-			}
-			return DURATION, pos, buf.String()
-		}
+// scanHexNumber consumes the rest of a hexadecimal constant after its 0x
+// prefix. A hexadecimal floating constant has a binary exponent.
+func (s *Scanner) scanHexNumber(pos Pos, buf *bytes.Buffer) (tok Token, _ Pos, lit string) {
+	buf.WriteString(s.scanDigitsFunc(isHexDigit))
+	if ch0, _ := s.r.read(); ch0 == '.' {
+		buf.WriteRune(ch0)
+		buf.WriteString(s.scanDigitsFunc(isHexDigit))
+	} else {
 		s.r.unread()
 	}
+	if exp := s.scanExponent('p', 'P'); exp != "" {
+		buf.WriteString(exp)
+		buf.WriteString(s.scanSuffix(isFloatSuffix))
+		return DECIMAL, pos, buf.String()
+	}
+	if strings.Contains(buf.String(), ".") {
+		// A hexadecimal fraction without an exponent is malformed, but
+		// is still a floating constant.
+		return DECIMAL, pos, buf.String()
+	}
+	buf.WriteString(s.scanSuffix(isIntSuffix))
 	return INTEGER, pos, buf.String()
 }
 
-// scanDigits consume a contiguous series of digits.
-func (s *Scanner) scanExponent() string {
+// scanExponent consumes an exponent introduced by one of the given marker
+// runes, an optional sign and at least one digit. Nothing is consumed and
+// an empty string is returned if there is no complete exponent.
+func (s *Scanner) scanExponent(markers ...rune) string {
+	ch0, _ := s.r.read()
+	if ch0 != markers[0] && ch0 != markers[1] {
+		s.r.unread()
+		return ""
+	}
+
 	var buf bytes.Buffer
+	buf.WriteRune(ch0)
 
 	// Sign?
 	ch1, _ := s.r.read()
 	if ch1 == '+' || ch1 == '-' {
-		_, _ = buf.WriteRune(ch1)
-	} else {
-		s.r.unread()
+		buf.WriteRune(ch1)
+		ch1, _ = s.r.read()
 	}
+	s.r.unread()
+	if !isDigit(ch1) {
+		for i := buf.Len(); i > 0; i-- {
+			s.r.unread()
+		}
+		return ""
+	}
+
+	buf.WriteString(s.scanDigits())
+	return buf.String()
+}
 
-	// Digits
-	var period bool
+// scanSuffix consumes the longest run of letters that the valid function
+// accepts as a (prefix of a) type suffix.
+func (s *Scanner) scanSuffix(valid func(string) bool) string {
+	var suffix string
 	for {
-		ch3, _ := s.r.read()
-		if isDigit(ch3) {
-			_, _ = buf.WriteRune(ch3)
-		} else if ch3 == '.' {
-
-			// Only allow one period
-			if !period {
-				period = true
-				buf.WriteRune(ch3)
-			} else {
-				s.r.unread()
-				break
-			}
-		} else {
+		ch, _ := s.r.read()
+		if ch == eof || !valid(suffix+string(ch)) {
 			s.r.unread()
-			break
+			return suffix
 		}
+		suffix += string(ch)
 	}
-	return buf.String()
 }
 
 // scanDigits consume a contiguous series of digits.
 func (s *Scanner) scanDigits() string {
+	return s.scanDigitsFunc(isDigit)
+}
+
+// scanDigitsFunc consumes a contiguous series of runes accepted by fn.
+func (s *Scanner) scanDigitsFunc(fn func(rune) bool) string {
 	var buf bytes.Buffer
 	for {
 		ch, _ := s.r.read()
-		if isDigit(ch) {
+		if fn(ch) {
 			buf.WriteRune(ch)
 		} else {
 			s.r.unread()
@@ -399,23 +585,3 @@ func (s *Scanner) scanDigits() string {
 	}
 	return buf.String()
 }
-
-// ScanRegex tokenizes a regex expression
-func (s *Scanner) ScanRegex() (tok Token, pos Pos, lit string) {
-	_, pos = s.r.curr()
-
-	// Start & end sentinels.
-	start, end := '/', '/'
-	// Valid escape chars.
-	escapes := map[rune]rune{'/': '/'}
-
-	b, err := ScanDelimited(s.r, start, end, escapes, true)
-
-	if err == errBadEscape {
-		_, pos = s.r.curr()
-		return BADESCAPE, pos, lit
-	} else if err != nil {
-		return BADREGEX, pos, lit
-	}
-	return REGEX, pos, string(b)
-}
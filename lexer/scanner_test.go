@@ -37,19 +37,22 @@ func TestScanner_Scan(t *testing.T) {
 		{s: "|", tok: PIPE},
 		{s: ">>", tok: RSHIFT},
 		{s: "<<", tok: LSHIFT},
-		{s: "**", tok: POW},
+		{s: "**", tok: MUL},
+		{s: "~", tok: TILDE},
+		{s: "%", tok: PERCENT},
 		{s: "->", tok: ARROW},
-		{s: "=>", tok: EQARROW},
+		{s: "=>", tok: EQ},
 
 		// Logical operators
-		{s: `AND`, tok: AND},
-		{s: `and`, tok: AND},
-		{s: `OR`, tok: OR},
-		{s: `or`, tok: OR},
+		{s: `&&`, tok: LAND},
+		{s: `||`, tok: LOR},
+		{s: `! `, tok: NOT},
+		{s: `!!`, tok: NOT},
+		{s: `and`, tok: IDENT, lit: `and`},
 
 		{s: `=`, tok: EQ},
-		{s: `<>`, tok: NEQ},
-		{s: `! `, tok: ILLEGAL, lit: "!"},
+		{s: `==`, tok: EQEQ},
+		{s: `<>`, tok: LT},
 		{s: `!=`, tok: NEQ},
 		{s: `<`, tok: LT},
 		{s: `<=`, tok: LTE},
@@ -66,14 +69,16 @@ func TestScanner_Scan(t *testing.T) {
 		{s: `,`, tok: COMMA},
 		{s: `;`, tok: SEMICOLON},
 		{s: `:`, tok: COLON},
+		{s: `?`, tok: QUESTION},
+		{s: `?:`, tok: QUESTION},
 		{s: `.`, tok: DOT},
 		{s: `%`, tok: PERCENT},
-		{s: "$", tok: DOLLAR},
+		{s: "$", tok: ILLEGAL, lit: "$"},
 		{s: "#", tok: HASH},
 		{s: "@", tok: ATSIGN},
-
-		{s: `=~`, tok: EQREGEX},
-		{s: `!~`, tok: NEQREGEX},
+		{s: "@ foo", tok: ATSIGN},
+		{s: "@1", tok: ATSIGN},
+		{s: "@autoreleasepool", tok: ATSIGN},
 
 		// Identifiers
 		{s: `foo`, tok: IDENT, lit: `foo`},
@@ -83,26 +88,67 @@ func TestScanner_Scan(t *testing.T) {
 		{s: `"foo\\bar"`, tok: STRING, lit: `foo\bar`},
 		{s: `"foo\bar"`, tok: BADESCAPE, lit: `\b`, pos: Pos{Line: 0, Char: 5}},
 		{s: `"foo\"bar\""`, tok: STRING, lit: `foo"bar"`},
-		{s: `test"`, tok: BADSTRING, lit: "", pos: Pos{Line: 0, Char: 3}},
+		{s: `test"`, tok: IDENT, lit: `test`},
 		{s: `"test`, tok: BADSTRING, lit: `test`},
+		{s: `@"foo"`, tok: ATSTRING, lit: `foo`},
+		{s: `@"foo\"bar"`, tok: ATSTRING, lit: `foo"bar`},
+		{s: `@"test`, tok: BADSTRING, lit: `test`},
+
+		// Keywords
+		{s: `const`, tok: CONST},
+		{s: `Const`, tok: IDENT, lit: `Const`},
+		{s: `extern`, tok: EXTERN},
+		{s: `static`, tok: STATIC},
+		{s: `_Thread_local`, tok: THREADLOCAL},
+		{s: `volatile`, tok: VOLATILE},
+		{s: `restrict`, tok: RESTRICT},
+		{s: `_Atomic`, tok: ATOMIC},
+		{s: `typedef`, tok: TYPEDEF},
+		{s: `union`, tok: UNION},
+		{s: `@interface`, tok: INTERFACE},
+		{s: `@property(nonatomic)`, tok: PROPERTY},
+		{s: `@protocol NSCopying`, tok: PROTOCOL},
+		{s: `@end`, tok: END},
+		{s: `@class`, tok: CLASS},
+		{s: `@optional`, tok: OPTIONAL},
+		{s: `@required`, tok: REQUIRED},
+		{s: `@selector(foo:)`, tok: SELECTOR},
+		{s: `@encode(int)`, tok: ENCODE},
+		{s: `@ending`, tok: ATSIGN},
 
 		{s: `true`, tok: TRUE},
 		{s: `false`, tok: FALSE},
 
-		// Strings
-		{s: `'testing 123!'`, tok: STRING, lit: `testing 123!`},
-		{s: `'foo\nbar'`, tok: STRING, lit: "foo\nbar"},
-		{s: `'foo\\bar'`, tok: STRING, lit: "foo\\bar"},
+		// Characters
+		{s: `'a'`, tok: CHAR, lit: `a`},
+		{s: `'\n'`, tok: CHAR, lit: `\n`},
+		{s: `'\''`, tok: CHAR, lit: `\'`},
+		{s: `'\x7f'`, tok: CHAR, lit: `\x7f`},
+		{s: `'icns'`, tok: CHAR, lit: `icns`},
+		{s: `''`, tok: BADSTRING},
 		{s: `'test`, tok: BADSTRING, lit: `test`},
 		{s: "'test\nfoo", tok: BADSTRING, lit: `test`},
 		{s: `'test\g'`, tok: BADESCAPE, lit: `\g`, pos: Pos{Line: 0, Char: 6}},
 
+		// Comments
+		{s: `// foo`, tok: COMMENT, lit: `// foo`},
+		{s: "// foo\nbar", tok: COMMENT, lit: `// foo`},
+		{s: `/* foo */ bar`, tok: COMMENT, lit: `/* foo */`},
+		{s: "/** foo\n * bar **/", tok: COMMENT, lit: "/** foo\n * bar **/"},
+		{s: `/* foo`, tok: ILLEGAL, lit: `/* foo`},
+
+		// Preprocessor directives
+		{s: `#import <Foundation/Foundation.h>`, tok: DIRECTIVE, lit: `#import <Foundation/Foundation.h>`},
+		{s: "  #if TARGET_OS_OSX \n", tok: WS},
+		{s: "#define MAX(a, b) \\\n  ((a) > (b) ? (a) : (b))\nfoo", tok: DIRECTIVE, lit: "#define MAX(a, b)    ((a) > (b) ? (a) : (b))"},
+		{s: "#  endif", tok: DIRECTIVE, lit: "#  endif"},
+
 		// Numbers
 		{s: `100`, tok: INTEGER, lit: `100`},
 		{s: `100.23`, tok: DECIMAL, lit: `100.23`},
 		{s: `+100.23`, tok: DECIMAL, lit: `+100.23`},
 		{s: `-100.23`, tok: DECIMAL, lit: `-100.23`},
-		{s: `-100.`, tok: DECIMAL, lit: `-100`},
+		{s: `-100.`, tok: DECIMAL, lit: `-100.`},
 		{s: `.23`, tok: DECIMAL, lit: `.23`},
 		{s: `+.23`, tok: DECIMAL, lit: `+.23`},
 		{s: `-.23`, tok: DECIMAL, lit: `-.23`},
@@ -113,19 +159,37 @@ func TestScanner_Scan(t *testing.T) {
 		{s: `10.3E5`, tok: DECIMAL, lit: `10.3E5`},
 		{s: `10.3E+5`, tok: DECIMAL, lit: `10.3E+5`},
 		{s: `10.3E-5`, tok: DECIMAL, lit: `10.3E-5`},
-		{s: `10.3E-0.5`, tok: DECIMAL, lit: `10.3E-0.5`},
-		{s: `10.3E-0.5.3`, tok: DECIMAL, lit: `10.3E-0.5`},
+		{s: `10.3e-5`, tok: DECIMAL, lit: `10.3e-5`},
+		{s: `10.3E-0.5`, tok: DECIMAL, lit: `10.3E-0`},
 		{s: `10E3`, tok: DECIMAL, lit: `10E3`},
+		{s: `10Ex`, tok: INTEGER, lit: `10`},
+		{s: `10E+x`, tok: INTEGER, lit: `10`},
+		{s: `0777`, tok: INTEGER, lit: `0777`},
+		{s: `0xFFFFFFFF`, tok: INTEGER, lit: `0xFFFFFFFF`},
+		{s: `0XdeadBEEF`, tok: INTEGER, lit: `0XdeadBEEF`},
+		{s: `0x`, tok: INTEGER, lit: `0`},
+		{s: `0b1010`, tok: INTEGER, lit: `0b1010`},
+		{s: `0b2`, tok: INTEGER, lit: `0`},
+		{s: `0x1p-3`, tok: DECIMAL, lit: `0x1p-3`},
+		{s: `0x1.8P+1f`, tok: DECIMAL, lit: `0x1.8P+1f`},
+		{s: `0x.8p1`, tok: DECIMAL, lit: `0x.8p1`},
 
-		// Durations
-		{s: `10u`, tok: DURATION, lit: `10u`},
-		{s: `10µ`, tok: DURATION, lit: `10µ`},
-		{s: `10ms`, tok: DURATION, lit: `10ms`},
-		{s: `-1s`, tok: DURATION, lit: `-1s`},
-		{s: `10m`, tok: DURATION, lit: `10m`},
-		{s: `10h`, tok: DURATION, lit: `10h`},
-		{s: `10d`, tok: DURATION, lit: `10d`},
-		{s: `10w`, tok: DURATION, lit: `10w`},
+		// Type suffixes
+		{s: `10u`, tok: INTEGER, lit: `10u`},
+		{s: `10UL`, tok: INTEGER, lit: `10UL`},
+		{s: `1ULL << 63`, tok: INTEGER, lit: `1ULL`},
+		{s: `-1ll`, tok: INTEGER, lit: `-1ll`},
+		{s: `1LLU`, tok: INTEGER, lit: `1LLU`},
+		{s: `1lL`, tok: INTEGER, lit: `1l`},
+		{s: `1UU`, tok: INTEGER, lit: `1U`},
+		{s: `0xFFu`, tok: INTEGER, lit: `0xFFu`},
+		{s: `0b11L`, tok: INTEGER, lit: `0b11L`},
+		{s: `1.0f`, tok: DECIMAL, lit: `1.0f`},
+		{s: `1.5L`, tok: DECIMAL, lit: `1.5L`},
+		{s: `1e3F`, tok: DECIMAL, lit: `1e3F`},
+		{s: `1.0u`, tok: DECIMAL, lit: `1.0`},
+		{s: `10f`, tok: INTEGER, lit: `10`},
+		{s: `10ms`, tok: INTEGER, lit: `10`},
 		{s: `10x`, tok: INTEGER, lit: `10`}, // non-duration unit
 	}
 
@@ -179,28 +243,141 @@ func TestScanString(t *testing.T) {
 	}
 }
 
-// Test scanning regex
-func TestScanRegex(t *testing.T) {
+// Ensure identifiers after a prefix rune that don't form a keyword are
+// scanned on their own.
+func TestScanner_Prefixed(t *testing.T) {
+	s := NewScanner(strings.NewReader(`@ending @const x@end`))
+	var got []Token
+	var lits []string
+	var poss []Pos
+	for {
+		tok, pos, lit := s.Scan()
+		if tok == EOF {
+			break
+		}
+		if tok == WS {
+			continue
+		}
+		got = append(got, tok)
+		lits = append(lits, lit)
+		poss = append(poss, pos)
+	}
+	want := []Token{ATSIGN, IDENT, ATSIGN, CONST, IDENT, END}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+	if lits[1] != "ending" || poss[1] != (Pos{Line: 0, Char: 1}) {
+		t.Errorf("identifier: got %q at %v", lits[1], poss[1])
+	}
+}
+
+// Ensure strings are at the position of their opening quote.
+func TestScanner_StringPos(t *testing.T) {
+	s := NewScanner(strings.NewReader(`x: "foo"`))
+	for i := 0; i < 3; i++ {
+		s.Scan()
+	}
+	if tok, pos, _ := s.Scan(); tok != STRING || pos != (Pos{Line: 0, Char: 3}) {
+		t.Errorf("got %s at %v", tok, pos)
+	}
+}
+
+// Ensure directives are only recognized at the start of a line.
+func TestScanner_Directives(t *testing.T) {
+	s := NewScanner(strings.NewReader("foo # bar\n  /* x */ #if A \\\n && B\nbaz"))
+	var got []Token
+	var lits []string
+	for {
+		tok, _, lit := s.Scan()
+		if tok == EOF {
+			break
+		}
+		if tok == WS {
+			continue
+		}
+		got = append(got, tok)
+		lits = append(lits, lit)
+	}
+	want := []Token{IDENT, HASH, IDENT, COMMENT, DIRECTIVE, IDENT}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+	if lits[4] != "#if A   && B" {
+		t.Errorf("directive literal: got %q", lits[4])
+	}
+}
+
+func TestCharValue(t *testing.T) {
 	var tests = []struct {
-		in  string
-		tok Token
 		lit string
-		err string
+		v   int64
+		err bool
 	}{
-		{in: `/^payments\./`, tok: REGEX, lit: `^payments\.`},
-		{in: `/foo\/bar/`, tok: REGEX, lit: `foo/bar`},
-		{in: `/foo\\/bar/`, tok: REGEX, lit: `foo\/bar`},
-		{in: `/foo\\bar/`, tok: REGEX, lit: `foo\\bar`},
+		{lit: `a`, v: 'a'},
+		{lit: `\n`, v: '\n'},
+		{lit: `\0`, v: 0},
+		{lit: `\177`, v: 0177},
+		{lit: `\x7f`, v: 0x7f},
+		{lit: `\'`, v: '\''},
+		{lit: `icns`, v: 0x69636e73},
+		{lit: `\x`, err: true},
+		{lit: `\q`, err: true},
+		{lit: ``, err: true},
 	}
 
 	for i, tt := range tests {
-		s := NewScanner(strings.NewReader(tt.in))
-		tok, _, lit := s.ScanRegex()
-		if tok != tt.tok {
-			t.Errorf("%d. %s: error:\n\texp=%s\n\tgot=%s\n", i, tt.in, tt.tok.String(), tok.String())
+		v, err := CharValue(tt.lit)
+		if tt.err != (err != nil) {
+			t.Errorf("%d. %q: error: %v", i, tt.lit, err)
+		} else if v != tt.v {
+			t.Errorf("%d. %q: exp=%d got=%d", i, tt.lit, tt.v, v)
 		}
-		if lit != tt.lit {
-			t.Errorf("%d. %s: error:\n\texp=%s\n\tgot=%s\n", i, tt.in, tt.lit, lit)
+	}
+}
+
+func TestParseNumber(t *testing.T) {
+	var tests = []struct {
+		lit string
+		n   Number
+		err bool
+	}{
+		{lit: `100`, n: Number{Base: 10, Int: 100}},
+		{lit: `-1`, n: Number{Base: 10, Neg: true, Int: 1}},
+		{lit: `0`, n: Number{Base: 10}},
+		{lit: `0777`, n: Number{Base: 8, Int: 0777}},
+		{lit: `0xFFFFFFFF`, n: Number{Base: 16, Int: 0xFFFFFFFF}},
+		{lit: `0xffULL`, n: Number{Base: 16, Suffix: "ULL", Int: 0xff}},
+		{lit: `0b1010`, n: Number{Base: 2, Int: 10}},
+		{lit: `10UL`, n: Number{Base: 10, Suffix: "UL", Int: 10}},
+		{lit: `18446744073709551615u`, n: Number{Base: 10, Suffix: "u", Int: 1<<64 - 1}},
+		{lit: `1.0f`, n: Number{Base: 10, Suffix: "f", IsFloat: true, Float: 1}},
+		{lit: `-.5`, n: Number{Base: 10, Neg: true, IsFloat: true, Float: -0.5}},
+		{lit: `1e3`, n: Number{Base: 10, IsFloat: true, Float: 1000}},
+		{lit: `100.`, n: Number{Base: 10, IsFloat: true, Float: 100}},
+		{lit: `0x1p-3`, n: Number{Base: 16, IsFloat: true, Float: 0.125}},
+		{lit: `0x1.8p1L`, n: Number{Base: 16, Suffix: "L", IsFloat: true, Float: 3}},
+		{lit: `09`, err: true},
+		{lit: `0x1.8`, err: true},
+		{lit: `18446744073709551616`, err: true},
+		{lit: `1lL`, err: true},
+	}
+
+	for i, tt := range tests {
+		n, err := ParseNumber(tt.lit)
+		if tt.err != (err != nil) {
+			t.Errorf("%d. %q: error: %v", i, tt.lit, err)
+		} else if n != tt.n {
+			t.Errorf("%d. %q: exp=%+v got=%+v", i, tt.lit, tt.n, n)
 		}
 	}
 }
@@ -1,7 +1,5 @@
 package lexer
 
-import "strings"
-
 // Token represents each lexer symbol
 type Token int
 
@@ -10,27 +8,25 @@ const (
 	ILLEGAL Token = iota
 	EOF
 	WS
+	COMMENT
+	DIRECTIVE
 
 	// Punctuation
 
-	LPAREN      // (
-	RPAREN      // )
-	LBRACKET    // [
-	RBRACKET    // ]
-	LCURLY      // {
-	RCURLY      // }
-	COMMA       // ,
-	SEMICOLON   // ;
-	COLON       // :
-	DOT         // .
-	SINGLEQUOTE // '
-	DOUBLEQUOTE // "
-	PERCENT     // %
-	DOLLAR      // $
-	HASH        // #
-	ATSIGN      // @
-	ARROW       // ->
-	EQARROW     // =>
+	LPAREN    // (
+	RPAREN    // )
+	LBRACKET  // [
+	RBRACKET  // ]
+	LCURLY    // {
+	RCURLY    // }
+	COMMA     // ,
+	SEMICOLON // ;
+	COLON     // :
+	QUESTION  // ?
+	DOT       // .
+	HASH      // #
+	ATSIGN    // @
+	ARROW     // ->
 
 	// Literals
 
@@ -39,13 +35,12 @@ const (
 	INTEGER
 	DECIMAL
 	STRING
+	ATSTRING // @"string"
+	CHAR
 	BADSTRING
 	BADESCAPE
 	TRUE
 	FALSE
-	REGEX
-	BADREGEX
-	DURATION
 	endLiterals
 
 	// Operators
@@ -57,28 +52,72 @@ const (
 	MINUSMINUS // --
 	MUL        // *
 	DIV        // /
+	PERCENT    // %
 	AMPERSAND  // &
 	XOR        // ^
 	PIPE       // |
+	TILDE      // ~
 	LSHIFT     // <<
 	RSHIFT     // >>
-	POW        // **
 
-	AND // AND
-	OR  // OR
+	LAND // &&
+	LOR  // ||
+	NOT  // !
 
-	EQ       // =
-	NEQ      // !=
-	EQEQ     // ==
-	EQREGEX  // =~
-	NEQREGEX // !~
-	LT       // <
-	LTE      // <=
-	GT       // >
-	GTE      // >=
+	EQ   // =
+	NEQ  // !=
+	EQEQ // ==
+	LT   // <
+	LTE  // <=
+	GT   // >
+	GTE  // >=
 
 	VARARG // ...
 	endOperators
+
+	// Keywords
+
+	startKeywords
+
+	// storage classes and function specifiers
+	AUTO
+	EXTERN
+	INLINE
+	REGISTER
+	STATIC
+	THREADLOCAL
+	TYPEDEF
+
+	// type qualifiers
+	ATOMIC
+	CONST
+	RESTRICT
+	VOLATILE
+
+	// tags
+	ENUM
+	STRUCT
+	UNION
+
+	// Objective-C directives
+	CLASS
+	DYNAMIC
+	ENCODE
+	END
+	IMPLEMENTATION
+	INTERFACE
+	OPTIONAL
+	PACKAGE
+	PRIVATE
+	PROPERTY
+	PROTECTED
+	PROTOCOL
+	PUBLIC
+	REQUIRED
+	SELECTOR
+	SYNTHESIZE
+
+	endKeywords
 )
 
 var tokens = map[Token]string{
@@ -86,33 +125,33 @@ var tokens = map[Token]string{
 	EOF:     "EOF",
 	WS:      "WS",
 
-	LPAREN:      "(",
-	RPAREN:      ")",
-	LBRACKET:    "[",
-	RBRACKET:    "]",
-	LCURLY:      "{",
-	RCURLY:      "}",
-	COMMA:       ",",
-	SEMICOLON:   ";",
-	COLON:       ":",
-	DOT:         ".",
-	SINGLEQUOTE: "'",
-	DOUBLEQUOTE: "\"",
-	PERCENT:     "%%",
-	DOLLAR:      "$",
-	HASH:        "#",
-	ATSIGN:      "@",
-	VARARG:      "...",
+	COMMENT:   "COMMENT",
+	DIRECTIVE: "DIRECTIVE",
+
+	LPAREN:    "(",
+	RPAREN:    ")",
+	LBRACKET:  "[",
+	RBRACKET:  "]",
+	LCURLY:    "{",
+	RCURLY:    "}",
+	COMMA:     ",",
+	SEMICOLON: ";",
+	COLON:     ":",
+	QUESTION:  "?",
+	DOT:       ".",
+	HASH:      "#",
+	ATSIGN:    "@",
+	ARROW:     "->",
+	VARARG:    "...",
 
 	IDENT:     "IDENT",
 	INTEGER:   "INTEGER",
 	DECIMAL:   "DECIMAL",
 	STRING:    "TEXTUAL",
-	DURATION:  "DURATION",
+	ATSTRING:  "ATSTRING",
+	CHAR:      "CHAR",
 	BADSTRING: "BADSTRING",
 	BADESCAPE: "BADESCAPE",
-	REGEX:     "REGEX",
-	BADREGEX:  "BADREGEX",
 
 	PLUS:       "+",
 	PLUSPLUS:   "++",
@@ -120,26 +159,21 @@ var tokens = map[Token]string{
 	MINUSMINUS: "--",
 	MUL:        "*",
 	DIV:        "/",
+	PERCENT:    "%",
 	AMPERSAND:  "&",
 	XOR:        "^",
 	PIPE:       "|",
+	TILDE:      "~",
 	RSHIFT:     ">>",
 	LSHIFT:     "<<",
-	POW:        "**",
-	ARROW:      "->",
-	EQARROW:    "=>",
 
-	AND: "AND",
-	OR:  "OR",
-
-	TRUE:  "true",
-	FALSE: "false",
+	LAND: "&&",
+	LOR:  "||",
+	NOT:  "!",
 
-	EQ:       "=",
-	NEQ:      "!=",
-	EQEQ:     "==",
-	EQREGEX:  "=~",
-	NEQREGEX: "!~",
+	EQ:   "=",
+	NEQ:  "!=",
+	EQEQ: "==",
 
 	LT:  "<",
 	LTE: "<=",
@@ -147,19 +181,59 @@ var tokens = map[Token]string{
 	GTE: ">=",
 }
 
+// keywordTokens are the C and Objective-C keywords built into the lexer.
+var keywordTokens = map[Token]string{
+	TRUE:  "true",
+	FALSE: "false",
+
+	AUTO:        "auto",
+	EXTERN:      "extern",
+	INLINE:      "inline",
+	REGISTER:    "register",
+	STATIC:      "static",
+	THREADLOCAL: "_Thread_local",
+	TYPEDEF:     "typedef",
+
+	ATOMIC:   "_Atomic",
+	CONST:    "const",
+	RESTRICT: "restrict",
+	VOLATILE: "volatile",
+
+	ENUM:   "enum",
+	STRUCT: "struct",
+	UNION:  "union",
+
+	CLASS:          "@class",
+	DYNAMIC:        "@dynamic",
+	ENCODE:         "@encode",
+	END:            "@end",
+	IMPLEMENTATION: "@implementation",
+	INTERFACE:      "@interface",
+	OPTIONAL:       "@optional",
+	PACKAGE:        "@package",
+	PRIVATE:        "@private",
+	PROPERTY:       "@property",
+	PROTECTED:      "@protected",
+	PROTOCOL:       "@protocol",
+	PUBLIC:         "@public",
+	REQUIRED:       "@required",
+	SELECTOR:       "@selector",
+	SYNTHESIZE:     "@synthesize",
+}
+
 var (
 	keywords = map[string]Token{}
 	prefixed = map[rune][]string{}
 )
 
 func init() {
-	keywords["and"] = AND
-	keywords["or"] = OR
-	keywords["true"] = TRUE
-	keywords["false"] = FALSE
+	LoadTokenMap(keywordTokens)
 }
 
-// LoadTokenMap allows for extra keywords to be added to the lexer
+// LoadTokenMap allows for extra keywords to be added to the lexer. Tokens
+// for extra keywords should start above the built in ones, eg at 1000.
+// A keyword may start with a punctuation rune like @, in which case the
+// rune and the identifier following it are scanned as one token.
 func LoadTokenMap(keywordTokens map[Token]string) {
 
 	// Combine built-in tokens and keywords
@@ -169,12 +243,9 @@ func LoadTokenMap(keywordTokens map[Token]string) {
 
 	// Load Keywords
 	for k, v := range keywordTokens {
-		keywords[strings.ToLower(v)] = k
-		for _, tv := range tokens {
-			if len(tv) == 1 && v[0] == tv[0] {
-				r := rune(v[0])
-				prefixed[r] = append(prefixed[r], v)
-			}
+		keywords[v] = k
+		if r := rune(v[0]); !isLetter(r) && r != '_' {
+			prefixed[r] = append(prefixed[r], v)
 		}
 	}
 }
@@ -190,25 +261,40 @@ func (tok Token) String() string {
 // Precedence returns the operator precedence of the binary operator token.
 func (tok Token) Precedence() int {
 	switch tok {
-	case OR:
+	case LOR:
 		return 1
-	case AND:
+	case LAND:
 		return 2
-	case EQ, NEQ, EQREGEX, NEQREGEX, LT, LTE, GT, GTE:
+	case PIPE:
 		return 3
-	case PLUS, MINUS:
+	case XOR:
 		return 4
-	case MUL, DIV:
+	case AMPERSAND:
 		return 5
-	case PIPE, XOR, RSHIFT, LSHIFT, POW, PLUSPLUS, MINUSMINUS:
+	case EQEQ, NEQ:
 		return 6
+	case LT, LTE, GT, GTE:
+		return 7
+	case LSHIFT, RSHIFT:
+		return 8
+	case PLUS, MINUS:
+		return 9
+	case MUL, DIV, PERCENT:
+		return 10
 	}
 	return 0
 }
 
-// isOperator returns true for operator tokens.
+// IsOperator returns true for operator tokens.
 func (tok Token) IsOperator() bool { return tok > startOperators && tok < endOperators }
 
+// IsKeyword returns true for keyword tokens, including those added with
+// LoadTokenMap.
+func (tok Token) IsKeyword() bool {
+	kw, ok := keywords[tokens[tok]]
+	return ok && kw == tok
+}
+
 // tokstr returns a literal if provided, otherwise returns the token string.
 func tokstr(tok Token, lit string) string {
 	if lit != "" {
@@ -219,7 +305,7 @@ func tokstr(tok Token, lit string) string {
 
 // Lookup returns the token associated with a given string.
 func Lookup(ident string) Token {
-	if tok, ok := keywords[strings.ToLower(ident)]; ok {
+	if tok, ok := keywords[ident]; ok {
 		return tok
 	}
 	return IDENT
@@ -3,7 +3,6 @@ package lexer
 import (
 	"bytes"
 	"errors"
-	"fmt"
 	"io"
 	"unicode"
 )
@@ -30,10 +29,9 @@ func isLetter(ch rune) bool {
 	// return (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
 }
 
-// isDigit returns true if the rune is a digit.
+// isDigit returns true if the rune is a decimal digit.
 func isDigit(ch rune) bool {
-	// return (ch >= '0' && ch <= '9')
-	return unicode.IsNumber(ch)
+	return ch >= '0' && ch <= '9'
 }
 
 // isPrefix returns true if the rune is used as a keyword prefix
@@ -43,69 +41,13 @@ func isPrefixed(ch rune) bool {
 }
 
 // isIdentChar returns true if the rune can be used in an unquoted identifier.
-func isIdentChar(ch rune) bool { return isLetter(ch) || isDigit(ch) || ch == '_' }
+func isIdentChar(ch rune) bool { return isLetter(ch) || unicode.IsNumber(ch) || ch == '_' }
 
 // isIdentFirstChar returns true if the rune can be used as the first char in an unquoted identifer.
 func isIdentFirstChar(ch rune) bool { return isLetter(ch) }
 
 var errBadString = errors.New("bad string")
 var errBadEscape = errors.New("bad escape")
-var errBadRegex = errors.New("bad regex")
-var errInvalidIdentifier = errors.New("invalid identifier")
-
-// IsRegexOp returns true if the operator accepts a regex operand.
-func IsRegexOp(t Token) bool {
-	return (t == EQREGEX || t == NEQREGEX)
-}
-
-func ScanDelimited(r io.RuneScanner, start, end rune, escapes map[rune]rune, escapesPassThru bool) ([]byte, error) {
-
-	// Scan start delimiter.
-	if ch, _, err := r.ReadRune(); err != nil {
-		return nil, err
-	} else if ch != start {
-		return nil, fmt.Errorf("expected %s; found %s", string(start), string(ch))
-	}
-
-	var buf bytes.Buffer
-	for {
-		ch0, _, err := r.ReadRune()
-		if ch0 == end {
-			return buf.Bytes(), nil
-		} else if err != nil {
-			return buf.Bytes(), err
-		} else if ch0 == '\n' {
-			return nil, errors.New("delimited text contains new line")
-		} else if ch0 == '\\' {
-			// If the next character is an escape then write the escaped char.
-			// If it's not a valid escape then return an error.
-			ch1, _, err := r.ReadRune()
-			if err != nil {
-				return nil, err
-			}
-
-			c, ok := escapes[ch1]
-			if !ok {
-				if escapesPassThru {
-					// Unread ch1 (char after the \)
-					_ = r.UnreadRune()
-					// Write ch0 (\) to the output buffer.
-					_, _ = buf.WriteRune(ch0)
-					continue
-				} else {
-					buf.Reset()
-					_, _ = buf.WriteRune(ch0)
-					_, _ = buf.WriteRune(ch1)
-					return buf.Bytes(), errBadEscape
-				}
-			}
-
-			_, _ = buf.WriteRune(c)
-		} else {
-			_, _ = buf.WriteRune(ch0)
-		}
-	}
-}
 
 // ScanString reads a quoted string from a rune reader.
 func ScanString(r io.RuneScanner) (string, error) {
@@ -142,6 +84,89 @@ func ScanString(r io.RuneScanner) (string, error) {
 	}
 }
 
+// isEscape returns true if the rune can follow a backslash in a C
+// character constant. Octal and hex escapes are checked by CharValue.
+func isEscape(ch rune) bool {
+	switch ch {
+	case 'a', 'b', 'f', 'n', 'r', 't', 'v', '\\', '\'', '"', '?', 'x':
+		return true
+	}
+	return ch >= '0' && ch <= '7'
+}
+
+var simpleEscapes = map[rune]int64{
+	'a': 7, 'b': 8, 'f': 12, 'n': 10, 'r': 13, 't': 9, 'v': 11,
+	'\\': '\\', '\'': '\'', '"': '"', '?': '?',
+}
+
+// CharValue returns the integer value of a CHAR token literal. Multi-character
+// constants like 'abcd' are packed big-endian one byte per character, the way
+// clang evaluates them for four character codes.
+func CharValue(lit string) (int64, error) {
+	var chars []int64
+	rs := []rune(lit)
+	for i := 0; i < len(rs); i++ {
+		if rs[i] != '\\' {
+			chars = append(chars, int64(rs[i]))
+			continue
+		}
+		i++
+		if i == len(rs) {
+			return 0, errBadEscape
+		}
+		switch ch := rs[i]; {
+		case simpleEscapes[ch] != 0:
+			chars = append(chars, simpleEscapes[ch])
+		case ch >= '0' && ch <= '7':
+			v := int64(0)
+			for n := 0; n < 3 && i < len(rs) && rs[i] >= '0' && rs[i] <= '7'; n++ {
+				v = v*8 + int64(rs[i]-'0')
+				i++
+			}
+			i--
+			chars = append(chars, v)
+		case ch == 'x':
+			v, n := int64(0), 0
+			for i+1 < len(rs) && isHexDigit(rs[i+1]) {
+				i++
+				v = v*16 + hexValue(rs[i])
+				n++
+			}
+			if n == 0 {
+				return 0, errBadEscape
+			}
+			chars = append(chars, v)
+		default:
+			return 0, errBadEscape
+		}
+	}
+	if len(chars) == 0 {
+		return 0, errBadString
+	}
+	if len(chars) == 1 {
+		return chars[0], nil
+	}
+	var v int64
+	for _, ch := range chars {
+		v = v<<8 | ch&0xFF
+	}
+	return v, nil
+}
+
+func isHexDigit(ch rune) bool {
+	return ch >= '0' && ch <= '9' || ch >= 'a' && ch <= 'f' || ch >= 'A' && ch <= 'F'
+}
+
+func hexValue(ch rune) int64 {
+	switch {
+	case ch >= 'a':
+		return int64(ch-'a') + 10
+	case ch >= 'A':
+		return int64(ch-'A') + 10
+	}
+	return int64(ch - '0')
+}
+
 // ScanBareIdent reads bare identifier from a rune reader.
 func ScanBareIdent(r io.RuneScanner) string {
 	// Read every ident character into the buffer.
@@ -43,9 +43,10 @@ func (r *reader) read() (ch rune, pos Pos) {
 	}
 
 	// Read next rune from underlying reader.
-	// Any error (including io.EOF) should return as EOF.
+	// Any error (including io.EOF) should return as EOF, and since a NUL
+	// rune also reads as EOF, nothing is read once EOF has been seen.
 	ch, _, err := r.r.ReadRune()
-	if err != nil {
+	if err != nil || r.eof {
 		ch = eof
 	} else if ch == '\r' {
 		if ch, _, err := r.r.ReadRune(); err != nil {
@@ -1,45 +1,94 @@
 package schema
 
 import (
+	"math/big"
 	"strings"
 
 	"github.com/progrium/macschema/declparse"
+	"github.com/progrium/macschema/lexer"
 )
 
-func DataTypeFromAst(ti declparse.TypeInfo) (dt DataType) {
+func DataTypeFromAst(ti declparse.TypeInfo) DataType {
+	dt := dataTypeFromAst(ti)
+	if ti.Kind == declparse.TypeNamed {
+		return dt
+	}
+	base := dataTypeFromAst(ti.Base())
+	dt.Name = base.Name
+	dt.Params = base.Params
+	dt.Block = base.Block
+	dt.FuncPtr = base.FuncPtr
+	dt.IsPtr = ti.IsPtr()
+	dt.IsPtrPtr = ti.IsPtrPtr()
+	annots := make(map[declparse.TypeAnnotation]bool)
+	for t := &ti; t != nil; t = t.Elem {
+		for annot, ok := range t.Annots {
+			annots[annot] = annots[annot] || ok
+		}
+	}
+	dt.Annotations = annotStrings(annots)
+	return dt
+}
+
+func dataTypeFromAst(ti declparse.TypeInfo) (dt DataType) {
+	if ti.Kind != declparse.TypeNamed {
+		elem := dataTypeFromAst(*ti.Elem)
+		return DataType{
+			Kind:       ti.Kind.String(),
+			Elem:       &elem,
+			Len:        ti.Len,
+			Qualifiers: annotStrings(ti.Annots),
+		}
+	}
 	var params []DataType
 	for _, param := range ti.Params {
 		params = append(params, DataTypeFromAst(param))
 	}
-	var annots []string
-	for annot := range ti.Annots {
-		annots = append(annots, strings.ToLower(annot.String()))
-	}
 	dt = DataType{
 		Name:        ti.Name,
-		IsPtr:       ti.IsPtr,
-		IsPtrPtr:    ti.IsPtrPtr,
-		Annotations: annots,
+		Annotations: annotStrings(ti.Annots),
 		Params:      params,
 	}
 	if ti.Func != nil {
 		if ti.Func.IsBlock {
-			dt.Block = FuncFromAst(ti.Func)
+			dt.Block = funcFromAst(ti.Func)
+			dt.Block.Escaping = !ti.Annots[declparse.TypeAnnotNoEscape]
 		}
 		if ti.Func.IsPtr {
-			dt.FuncPtr = FuncFromAst(ti.Func)
+			dt.FuncPtr = funcFromAst(ti.Func)
 		}
 	}
 	return
 }
 
-func FuncFromAst(fn *declparse.FunctionDecl) *Func {
+func annotStrings(annots map[declparse.TypeAnnotation]bool) []string {
+	var s []string
+	for _, annot := range declparse.TypeAnnotations() {
+		if annots[annot] {
+			s = append(s, strings.ToLower(annot.String()))
+		}
+	}
+	return s
+}
+
+// The declaration converters below resolve the nullability of pointer
+// types. assumeNonnull is the Statement's AssumeNonnull: whether the
+// declaration is in an assume_nonnull region. Leave it unset when the
+// region is unknown, which makes unannotated pointers unspecified.
+
+func FuncFromAst(fn *declparse.FunctionDecl, assumeNonnull bool) *Func {
+	f := funcFromAst(fn)
+	f.resolveNullability(assumeNonnull)
+	return f
+}
+
+func funcFromAst(fn *declparse.FunctionDecl) *Func {
 	var args []Arg
 	for _, arg := range fn.Args {
 		args = append(args, ArgFromAst(arg))
 	}
 	return &Func{
-		Identifier: Identifier{Name: fn.Name},
+		Identifier: Identifier{Name: fn.Name, SwiftName: fn.SwiftName},
 		Return:     DataTypeFromAst(fn.ReturnType),
 		Args:       args,
 	}
@@ -52,11 +101,12 @@ func ArgFromAst(ai declparse.ArgInfo) Arg {
 	}
 }
 
-func PropertyFromAst(p declparse.PropertyDecl) Property {
+func PropertyFromAst(p declparse.PropertyDecl, assumeNonnull bool) Property {
 	prop := Property{
-		Name:     p.Name,
-		Type:     DataTypeFromAst(p.Type),
-		IsOutlet: p.IsOutlet,
+		Name:      p.Name,
+		Type:      DataTypeFromAst(p.Type),
+		IsOutlet:  p.IsOutlet,
+		SwiftName: p.SwiftName,
 	}
 	attrs := make(map[string]interface{})
 	for attr, val := range p.Attrs {
@@ -69,34 +119,60 @@ func PropertyFromAst(p declparse.PropertyDecl) Property {
 		attrs[attr.String()] = v
 	}
 	prop.Attrs = attrs
+	prop.resolveNullability(assumeNonnull)
 	return prop
 }
 
-func MethodFromAst(m declparse.MethodDecl) Method {
+func MethodFromAst(m declparse.MethodDecl, assumeNonnull bool) Method {
 	var args []Arg
 	for _, arg := range m.Args {
 		args = append(args, ArgFromAst(arg))
 	}
-	return Method{
-		Name:   m.Name(),
-		Return: DataTypeFromAst(m.ReturnType),
-		Args:   args,
+	method := Method{
+		Name:      m.Name(),
+		Return:    DataTypeFromAst(m.ReturnType),
+		Args:      args,
+		SwiftName: m.SwiftName,
 	}
+	method.resolveNullability(assumeNonnull)
+	return method
 }
 
-func VariableFromAst(v declparse.VariableDecl) Variable {
-	return Variable{
-		Identifier: Identifier{Name: v.Name},
+func VariableFromAst(v declparse.VariableDecl, assumeNonnull bool) Variable {
+	vv := Variable{
+		Identifier: Identifier{Name: v.Name, SwiftName: v.SwiftName},
 		Value:      v.Value,
 		Type:       DataTypeFromAst(v.Type),
 	}
+	vv.Type.resolveNullability(assumeNonnull)
+	vv.eval(nil)
+	return vv
+}
+
+// ClassFromAst returns the class an @interface declares, with no members.
+func ClassFromAst(i declparse.InterfaceDecl) Class {
+	return Class{
+		Identifier: Identifier{Name: i.Name},
+		Superclass: i.SuperName,
+		Protocols:  i.Protocols,
+	}
+}
+
+// ProtocolFromAst returns the protocol a @protocol declares, with no
+// members.
+func ProtocolFromAst(p declparse.ProtocolDecl) Class {
+	return Class{
+		Identifier: Identifier{Name: p.Name},
+		Protocols:  p.Protocols,
+	}
 }
 
 func EnumFromAst(e declparse.EnumDecl) Enum {
 	var cases []Variable
 	for _, ecase := range e.Cases {
-		cases = append(cases, VariableFromAst(ecase))
+		cases = append(cases, VariableFromAst(ecase, false))
 	}
+	evalConsts(cases, true)
 	return Enum{
 		Identifier: Identifier{Name: e.Name},
 		Type:       DataTypeFromAst(e.Type),
@@ -104,13 +180,62 @@ func EnumFromAst(e declparse.EnumDecl) Enum {
 	}
 }
 
-func StructFromAst(s declparse.StructDecl) Struct {
+func StructFromAst(s declparse.StructDecl, assumeNonnull bool) Struct {
 	var fields []Variable
 	for _, field := range s.Fields {
-		fields = append(fields, VariableFromAst(field))
+		fields = append(fields, VariableFromAst(field, assumeNonnull))
 	}
 	return Struct{
 		Identifier: Identifier{Name: s.Name},
 		Fields:     fields,
 	}
 }
+
+// eval sets the exact value of a variable whose Value is a numeric literal
+// or integer constant expression. Names are resolved with lookup.
+func (v *Variable) eval(lookup func(name string) (*big.Int, bool)) {
+	if v.Value == "" {
+		return
+	}
+	if n, err := lexer.ParseNumber(v.Value); err == nil && n.IsFloat {
+		v.FloatValue = &n.Float
+		return
+	}
+	if i, err := declparse.EvalInt(v.Value, lookup); err == nil {
+		v.IntValue = i
+	}
+}
+
+// evalConsts evaluates the values of constants that refer to each other,
+// like the cases of an enum. With implicit set, a constant without a value
+// is one more than the one before it, as in an enum declaration.
+func evalConsts(vars []Variable, implicit bool) {
+	lookup := func(name string) (*big.Int, bool) {
+		for _, v := range vars {
+			if v.Name == name && v.IntValue != nil {
+				return v.IntValue, true
+			}
+		}
+		return nil, false
+	}
+	for progress := true; progress; {
+		progress = false
+		for i := range vars {
+			v := &vars[i]
+			if v.IntValue != nil || v.FloatValue != nil {
+				continue
+			}
+			switch {
+			case v.Value != "":
+				v.eval(lookup)
+			case !implicit:
+				continue
+			case i == 0:
+				v.IntValue = big.NewInt(0)
+			case vars[i-1].IntValue != nil:
+				v.IntValue = new(big.Int).Add(vars[i-1].IntValue, big.NewInt(1))
+			}
+			progress = progress || v.IntValue != nil
+		}
+	}
+}
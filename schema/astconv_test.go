@@ -0,0 +1,154 @@
+package schema
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/progrium/macschema/declparse"
+)
+
+func TestEnumFromAst_Values(t *testing.T) {
+	p := declparse.NewStringParser(`enum NSEventMask : unsigned long long {
+		NSEventMaskLeftMouseDown = 1ULL << 1,
+		NSEventMaskLeftMouseUp,
+		NSEventMaskMouse = NSEventMaskLeftMouseDown | NSEventMaskLeftMouseUp,
+		NSEventMaskHigh = 1ULL << 63,
+		NSEventMaskAny = ~0ULL,
+		NSEventMaskUnknown = sizeof(int)
+	};`)
+	ast, err := p.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	en := EnumFromAst(*ast.Enum)
+
+	want := map[string]string{
+		"NSEventMaskLeftMouseDown": "2",
+		"NSEventMaskLeftMouseUp":   "3",
+		"NSEventMaskMouse":         "3",
+		"NSEventMaskHigh":          "9223372036854775808",
+		"NSEventMaskAny":           "18446744073709551615",
+		"NSEventMaskUnknown":       "",
+	}
+	for _, c := range en.Cases {
+		got := ""
+		if c.IntValue != nil {
+			got = c.IntValue.String()
+		}
+		if got != want[c.Name] {
+			t.Errorf("%s: exp=%q got=%q", c.Name, want[c.Name], got)
+		}
+	}
+
+	// values too big for a float64 are exact in JSON
+	b, err := json.Marshal(en.Cases[4])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v struct{ IntValue json.Number }
+	if err := json.Unmarshal(b, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.IntValue != "18446744073709551615" {
+		t.Errorf("IntValue: got=%s", v.IntValue)
+	}
+}
+
+func TestVariableFromAst_Float(t *testing.T) {
+	p := declparse.NewStringParser(`const CGFloat NSScale = 0x1p-3;`)
+	p.Hint = declparse.HintVariable
+	ast, err := p.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := VariableFromAst(*ast.Variable, false)
+	if v.FloatValue == nil || *v.FloatValue != 0.125 || v.IntValue != nil {
+		t.Errorf("unexpected value: %+v", v)
+	}
+}
+
+func TestDataTypeFromAst_Pointers(t *testing.T) {
+	p := declparse.NewStringParser(`- (void)getBytes:(const char *const _Nullable *)bytes components:(CGFloat[4])components;`)
+	ast, err := p.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := MethodFromAst(*ast.Method, false)
+
+	bytes := m.Args[0].Type
+	if bytes.Kind != "pointer" || bytes.Elem.Kind != "pointer" || bytes.Elem.Elem.Name != "char" {
+		t.Errorf("bytes: unexpected type %+v", bytes)
+	}
+	if got := strings.Join(bytes.Elem.Qualifiers, " "); got != "const _nullable" {
+		t.Errorf("bytes: inner pointer qualifiers: got=%q", got)
+	}
+	if got := strings.Join(bytes.Elem.Elem.Annotations, " "); got != "const" {
+		t.Errorf("bytes: char annotations: got=%q", got)
+	}
+	// flat view of the outermost type
+	if bytes.Name != "char" || !bytes.IsPtr || !bytes.IsPtrPtr || strings.Join(bytes.Annotations, " ") != "const _nullable" {
+		t.Errorf("bytes: unexpected flat view %+v", bytes)
+	}
+
+	components := m.Args[1].Type
+	if components.Kind != "array" || components.Len != "4" || components.Elem.Name != "CGFloat" {
+		t.Errorf("components: unexpected type %+v", components)
+	}
+	if components.Name != "CGFloat" || components.IsPtr {
+		t.Errorf("components: unexpected flat view %+v", components)
+	}
+}
+
+func TestDataTypeFromAst_Blocks(t *testing.T) {
+	p := declparse.NewStringParser(`- (void)enumerateUsingBlock:(NS_NOESCAPE void (^)(id obj))block factory:(void (^(^)(int))(void))factory;`)
+	ast, err := p.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := MethodFromAst(*ast.Method, false)
+
+	block := m.Args[0].Type.Block
+	if block == nil || block.Escaping || len(block.Args) != 1 || block.Args[0].Type.Name != "id" {
+		t.Errorf("block: unexpected type %+v", m.Args[0].Type)
+	}
+
+	factory := m.Args[1].Type.Block
+	if factory == nil || !factory.Escaping || len(factory.Args) != 1 || factory.Args[0].Type.Name != "int" {
+		t.Fatalf("factory: unexpected type %+v", m.Args[1].Type)
+	}
+	// the block it returns isn't passed to anything
+	if ret := factory.Return.Block; ret == nil || !ret.Escaping || len(ret.Args) != 0 || ret.Return.Name != "void" {
+		t.Errorf("factory: unexpected return type %+v", factory.Return)
+	}
+}
+
+func TestClassFromAst(t *testing.T) {
+	for decl, want := range map[string]Class{
+		`@interface NSWindow : NSResponder <NSAnimatablePropertyContainer, NSUserInterfaceValidations>`: {
+			Identifier: Identifier{Name: "NSWindow"},
+			Superclass: "NSResponder",
+			Protocols:  []string{"NSAnimatablePropertyContainer", "NSUserInterfaceValidations"},
+		},
+		`@interface NSArray<__covariant ObjectType> : NSObject`: {
+			Identifier: Identifier{Name: "NSArray"},
+			Superclass: "NSObject",
+		},
+		`@protocol NSWindowDelegate <NSObject>`: {
+			Identifier: Identifier{Name: "NSWindowDelegate"},
+			Protocols:  []string{"NSObject"},
+		},
+	} {
+		d, err := parseDeclaration("objc", decl, declparse.HintNone)
+		if err != nil {
+			t.Fatalf("%s: %v", decl, err)
+		}
+		if d.Class == nil {
+			t.Fatalf("%s: no class", decl)
+		}
+		if diff := deep.Equal(*d.Class, want); diff != nil {
+			t.Errorf("%s: %v", decl, diff)
+		}
+	}
+}
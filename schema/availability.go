@@ -0,0 +1,258 @@
+package schema
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Availability is when a declaration is available on a platform, with
+// versions as in 10.15. Deprecated and Obsoleted are the versions it was
+// deprecated and removed in. A declaration documented as deprecated
+// without a version is deprecated in the version it was introduced in.
+type Availability struct {
+	Platform   string
+	Introduced string `json:",omitempty"`
+	Deprecated string `json:",omitempty"`
+	Obsoleted  string `json:",omitempty"`
+	Beta       bool   `json:",omitempty"`
+}
+
+// platformRE matches the platforms of topics, as in macOS 10.0+,
+// iOS 2.0–13.0 Deprecated or Mac Catalyst 13.1+ Beta.
+var platformRE = regexp.MustCompile(`^(.*?)\s+(\d+(?:\.\d+)*)(?:\+|\s*[–-]\s*(\d+(?:\.\d+)*))?((?:\s+\w+)*)$`)
+
+// ParseAvailability parses the platforms of a topic. Ranges of versions
+// end with the version the declaration was deprecated in, or obsoleted in
+// when marked Obsoleted. A Deprecated or Beta on its own applies to every
+// platform.
+func ParseAvailability(platforms []string) []Availability {
+	var avail []Availability
+	var deprecated, beta bool
+	for _, p := range platforms {
+		p = strings.TrimSpace(p)
+		switch p {
+		case "":
+			continue
+		case "Deprecated":
+			deprecated = true
+			continue
+		case "Beta":
+			beta = true
+			continue
+		}
+		m := platformRE.FindStringSubmatch(p)
+		if m == nil {
+			avail = append(avail, Availability{Platform: p})
+			continue
+		}
+		a := Availability{Platform: m[1], Introduced: m[2]}
+		markers := strings.Fields(m[4])
+		for _, marker := range markers {
+			switch marker {
+			case "Beta":
+				a.Beta = true
+			case "Deprecated":
+				a.Deprecated = a.Introduced
+			}
+		}
+		if m[3] != "" {
+			if hasMarker(markers, "Obsoleted") {
+				a.Obsoleted = m[3]
+			} else {
+				a.Deprecated = m[3]
+			}
+		}
+		avail = append(avail, a)
+	}
+	for i := range avail {
+		if deprecated && avail[i].Deprecated == "" {
+			avail[i].Deprecated = avail[i].Introduced
+		}
+		avail[i].Beta = avail[i].Beta || beta
+	}
+	return avail
+}
+
+func hasMarker(markers []string, marker string) bool {
+	for _, m := range markers {
+		if m == marker {
+			return true
+		}
+	}
+	return false
+}
+
+// Target is a version of a platform to check availability on.
+type Target struct {
+	Platform string
+	Version  string
+}
+
+var versionRE = regexp.MustCompile(`^\d+(?:\.\d+)*$`)
+
+// ParseTarget parses a target, as in macOS 11 or Mac Catalyst 14.0.
+func ParseTarget(s string) (Target, error) {
+	i := strings.LastIndex(s, " ")
+	if i < 0 || !versionRE.MatchString(s[i+1:]) {
+		return Target{}, fmt.Errorf("target %q is not a platform and version", s)
+	}
+	return Target{Platform: strings.TrimSpace(s[:i]), Version: s[i+1:]}, nil
+}
+
+func (t Target) String() string {
+	return t.Platform + " " + t.Version
+}
+
+// AvailabilityFor returns the availability of a declaration on a platform,
+// whose name is matched ignoring case and spaces.
+func AvailabilityFor(avail []Availability, platform string) (Availability, bool) {
+	for _, a := range avail {
+		if platformKey(a.Platform) == platformKey(platform) {
+			return a, true
+		}
+	}
+	return Availability{}, false
+}
+
+func platformKey(platform string) string {
+	return strings.ToLower(strings.Join(strings.Fields(platform), ""))
+}
+
+// AvailableIn returns true if the declaration is in version of the
+// platform: introduced in it or before and not obsoleted.
+func (a Availability) AvailableIn(version string) bool {
+	return compareVersions(a.Introduced, version) <= 0 &&
+		(a.Obsoleted == "" || compareVersions(version, a.Obsoleted) < 0)
+}
+
+// DeprecatedIn returns true if the declaration is deprecated in version
+// of the platform.
+func (a Availability) DeprecatedIn(version string) bool {
+	return a.Deprecated != "" && compareVersions(a.Deprecated, version) <= 0
+}
+
+// IsAvailable returns true if a declaration is available on every
+// target. Declarations without availability are taken to be available
+// everywhere, since their topics didn't say, but those with availability
+// are only available on the platforms listed.
+func IsAvailable(avail []Availability, targets ...Target) bool {
+	if len(avail) == 0 {
+		return true
+	}
+	for _, t := range targets {
+		a, ok := AvailabilityFor(avail, t.Platform)
+		if !ok || !a.AvailableIn(t.Version) {
+			return false
+		}
+	}
+	return true
+}
+
+// FilterAvailable returns a copy of a schema with only the members that
+// are available on every target, and false if the declaration itself
+// isn't.
+func FilterAvailable(s Schema, targets ...Target) (Schema, bool) {
+	if !IsAvailable(s.Identifier().Availability, targets...) {
+		return s, false
+	}
+	filterClass := func(c *Class) *Class {
+		if c == nil {
+			return nil
+		}
+		cc := *c
+		cc.InstanceMethods = filterMethods(c.InstanceMethods, targets)
+		cc.TypeMethods = filterMethods(c.TypeMethods, targets)
+		cc.InstanceProperties = filterProperties(c.InstanceProperties, targets)
+		cc.TypeProperties = filterProperties(c.TypeProperties, targets)
+		return &cc
+	}
+	s.Class = filterClass(s.Class)
+	s.Protocol = filterClass(s.Protocol)
+	if s.Enum != nil {
+		en := *s.Enum
+		en.Cases = filterVariables(s.Enum.Cases, targets)
+		s.Enum = &en
+	}
+	if s.APICollection != nil {
+		ac := *s.APICollection
+		ac.Functions = nil
+		for _, fn := range s.APICollection.Functions {
+			if IsAvailable(fn.Availability, targets...) {
+				ac.Functions = append(ac.Functions, fn)
+			}
+		}
+		ac.Enums = nil
+		for _, en := range s.APICollection.Enums {
+			if IsAvailable(en.Availability, targets...) {
+				en.Cases = filterVariables(en.Cases, targets)
+				ac.Enums = append(ac.Enums, en)
+			}
+		}
+		s.APICollection = &ac
+	}
+	return s, true
+}
+
+func filterMethods(methods []Method, targets []Target) []Method {
+	var out []Method
+	for _, m := range methods {
+		if IsAvailable(m.Availability, targets...) {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+func filterProperties(props []Property, targets []Target) []Property {
+	var out []Property
+	for _, p := range props {
+		if IsAvailable(p.Availability, targets...) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func filterVariables(vars []Variable, targets []Target) []Variable {
+	var out []Variable
+	for _, v := range vars {
+		if IsAvailable(v.Availability, targets...) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// compareVersions compares versions like 10.15 by their numbers, with
+// missing numbers as 0, so 11 and 11.0 are the same. An empty version is
+// before any other.
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	if a == "" || b == "" {
+		switch {
+		case a == b:
+			return 0
+		case a == "":
+			return -1
+		}
+		return 1
+	}
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,98 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestParseAvailability(t *testing.T) {
+	for _, tt := range []struct {
+		platforms []string
+		want      []Availability
+	}{
+		{[]string{"macOS 10.0+", "Mac Catalyst 13.1+"}, []Availability{
+			{Platform: "macOS", Introduced: "10.0"},
+			{Platform: "Mac Catalyst", Introduced: "13.1"},
+		}},
+		{[]string{"iOS 2.0–13.0 Deprecated", "macOS 10.10-10.15"}, []Availability{
+			{Platform: "iOS", Introduced: "2.0", Deprecated: "13.0"},
+			{Platform: "macOS", Introduced: "10.10", Deprecated: "10.15"},
+		}},
+		{[]string{"macOS 10.0+", "Deprecated"}, []Availability{
+			{Platform: "macOS", Introduced: "10.0", Deprecated: "10.0"},
+		}},
+		{[]string{"macOS 14.0+ Beta", "iOS 17.0+"}, []Availability{
+			{Platform: "macOS", Introduced: "14.0", Beta: true},
+			{Platform: "iOS", Introduced: "17.0"},
+		}},
+		{[]string{"macOS 10.0–10.8 Obsoleted"}, []Availability{
+			{Platform: "macOS", Introduced: "10.0", Obsoleted: "10.8"},
+		}},
+		{[]string{"visionOS"}, []Availability{{Platform: "visionOS"}}},
+	} {
+		if diff := deep.Equal(ParseAvailability(tt.platforms), tt.want); diff != nil {
+			t.Errorf("%v: %v", tt.platforms, diff)
+		}
+	}
+}
+
+func TestIsAvailable(t *testing.T) {
+	avail := ParseAvailability([]string{"macOS 10.15+", "iOS 13.0–14.0 Deprecated"})
+	for target, want := range map[string]bool{
+		"macOS 11":        true,
+		"macOS 10.15":     true,
+		"macOS 10.14.6":   false,
+		"iOS 14":          true,
+		"iOS 12.4":        false,
+		"Mac Catalyst 14": false,
+	} {
+		tg, err := ParseTarget(target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := IsAvailable(avail, tg); got != want {
+			t.Errorf("%s: exp=%v got=%v", target, want, got)
+		}
+	}
+	if a, _ := AvailabilityFor(avail, "ios"); !a.DeprecatedIn("14.1") || a.DeprecatedIn("13.5") {
+		t.Errorf("deprecation of %v", a)
+	}
+	if !IsAvailable(nil, Target{"macOS", "11"}) {
+		t.Error("unknown availability is available")
+	}
+	if _, err := ParseTarget("macOS"); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestFilterAvailable(t *testing.T) {
+	schemas, err := ReadSchemas("testdata/api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s Schema
+	for _, s = range schemas {
+		if s.Class != nil {
+			break
+		}
+	}
+	c := s.Class
+	if diff := deep.Equal(c.Availability, []Availability{{Platform: "macOS", Introduced: "10.0"}}); diff != nil {
+		t.Fatalf("availability: %v", diff)
+	}
+	c.InstanceMethods[0].Availability = ParseAvailability([]string{"macOS 12.0+"})
+	n := len(c.InstanceMethods)
+
+	macOS11, macOS12 := Target{"macOS", "11"}, Target{"macOS", "12"}
+	got, ok := FilterAvailable(s, macOS11)
+	if !ok || len(got.Class.InstanceMethods) != n-1 || len(c.InstanceMethods) != n {
+		t.Errorf("macOS 11: ok=%v methods=%d of %d", ok, len(got.Class.InstanceMethods), n)
+	}
+	if got, _ := FilterAvailable(s, macOS12); len(got.Class.InstanceMethods) != n {
+		t.Errorf("macOS 12: methods=%d of %d", len(got.Class.InstanceMethods), n)
+	}
+	if _, ok := FilterAvailable(s, Target{"iOS", "14"}); ok {
+		t.Error("iOS 14: class is available")
+	}
+}
@@ -0,0 +1,133 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Compatibility is how a change affects code using bindings generated
+// from the old schemas, like the Go bindings of gen go. Changes are
+// ordered from the least to the most breaking.
+type Compatibility string
+
+const (
+	// Additive changes add to the API or don't change it for existing
+	// code, like new methods or deprecations.
+	Additive Compatibility = "additive"
+
+	// SourceBreaking changes make existing code fail to compile or
+	// mistake nil, like a change of an object argument's class or of the
+	// nullability of a return type, but code that was compiled still runs.
+	SourceBreaking Compatibility = "source-breaking"
+
+	// BinaryBreaking changes make code compiled with the old bindings fail
+	// or misbehave at run time, like removed methods and changes to the
+	// size or layout of types and to constant values.
+	BinaryBreaking Compatibility = "binary-breaking"
+)
+
+var compatibilityOrder = map[Compatibility]int{
+	Additive:       0,
+	SourceBreaking: 1,
+	BinaryBreaking: 2,
+}
+
+// ParseCompatibility returns the Compatibility named s.
+func ParseCompatibility(s string) (Compatibility, error) {
+	c := Compatibility(s)
+	if _, ok := compatibilityOrder[c]; !ok {
+		return "", fmt.Errorf("unknown compatibility %q", s)
+	}
+	return c, nil
+}
+
+// AtLeast returns true if c breaks as much as other or more.
+func (c Compatibility) AtLeast(other Compatibility) bool {
+	return compatibilityOrder[c] >= compatibilityOrder[other]
+}
+
+// Classify returns the compatibility of a change.
+func Classify(c Change) Compatibility {
+	switch c.Kind {
+	case ChangeAdded:
+		return Additive
+	case ChangeRemoved:
+		if c.Element == "conformance" {
+			// the methods are still there, but the type no longer
+			// satisfies the protocol's interface
+			return SourceBreaking
+		}
+		return BinaryBreaking
+	}
+	switch c.Aspect {
+	case AspectDeprecation:
+		return Additive
+	case AspectAccess:
+		if c.New == "readonly" {
+			return BinaryBreaking // the setter is gone
+		}
+		return Additive
+	case AspectReturnNullability:
+		return nullabilityCompat(c.Old, c.New, false)
+	case AspectArgumentNullability:
+		return nullabilityCompat(c.Old, c.New, true)
+	case AspectNullability:
+		if c.Element == "property" || c.Element == "class property" {
+			// the getter returns the value, and the setter of a
+			// property that isn't readonly takes it
+			compat := nullabilityCompat(c.Old, c.New, false)
+			if c.Access != "readonly" {
+				if setter := nullabilityCompat(c.Old, c.New, true); setter.AtLeast(compat) {
+					compat = setter
+				}
+			}
+			return compat
+		}
+		return nullabilityCompat(c.Old, c.New, false)
+	case AspectSuperclass:
+		for _, class := range c.Superclasses {
+			if class == c.Old {
+				// a class was put in between, and the class still
+				// inherits everything it did
+				return Additive
+			}
+		}
+		return BinaryBreaking
+	case AspectReturnType, AspectArgumentType, AspectType:
+		if c.Element != "field" && isPointerType(c.Old) && isPointerType(c.New) {
+			return SourceBreaking
+		}
+		return BinaryBreaking
+	}
+	// signature and value changes
+	return BinaryBreaking
+}
+
+// nullabilityCompat returns the compatibility of a change of nullability
+// from old to new, of a value passed in, like an argument, or out, like a
+// return value. Passing nil to what no longer takes it, or getting nil
+// from what didn't return it, breaks.
+func nullabilityCompat(old, new string, in bool) Compatibility {
+	rank := map[string]int{
+		string(NullabilityNonnull):     0,
+		string(NullabilityUnspecified): 1,
+		string(NullabilityNullable):    2,
+		string(NullabilityResettable):  2,
+	}
+	if in && rank[new] < rank[old] || !in && rank[new] > rank[old] {
+		return SourceBreaking
+	}
+	return Additive
+}
+
+// isPointerType returns true for a type in C syntax that is a pointer, an
+// object or a block, whose changes keep its size.
+func isPointerType(typ string) bool {
+	switch {
+	case strings.HasSuffix(typ, "*"), strings.Contains(typ, "(^)"),
+		typ == "id", strings.HasPrefix(typ, "id<"),
+		typ == "instancetype", typ == "Class", typ == "SEL":
+		return true
+	}
+	return false
+}
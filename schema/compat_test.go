@@ -0,0 +1,91 @@
+package schema
+
+import "testing"
+
+func TestClassify(t *testing.T) {
+	for _, tt := range []struct {
+		change Change
+		want   Compatibility
+	}{
+		{Change{Kind: ChangeAdded, Element: "method", Symbol: "-[NSWindow close]"}, Additive},
+		{Change{Kind: ChangeAdded, Element: "conformance", Symbol: "NSWindow<NSCoding>"}, Additive},
+		{Change{Kind: ChangeRemoved, Element: "method", Symbol: "-[NSWindow close]"}, BinaryBreaking},
+		{Change{Kind: ChangeRemoved, Element: "conformance", Symbol: "NSWindow<NSCoding>"}, SourceBreaking},
+		{Change{Kind: ChangeChanged, Element: "method", Aspect: AspectDeprecation, Old: "available", New: "deprecated"}, Additive},
+		{Change{Kind: ChangeChanged, Element: "method", Aspect: AspectArgumentType, Old: "NSRect", New: "NSSize"}, BinaryBreaking},
+		{Change{Kind: ChangeChanged, Element: "method", Aspect: AspectArgumentType, Old: "id", New: "NSView *"}, SourceBreaking},
+		{Change{Kind: ChangeChanged, Element: "method", Aspect: AspectReturnType, Old: "NSInteger", New: "NSUInteger"}, BinaryBreaking},
+		{Change{Kind: ChangeChanged, Element: "method", Aspect: AspectArgumentNullability, Old: "nullable", New: "nonnull"}, SourceBreaking},
+		{Change{Kind: ChangeChanged, Element: "method", Aspect: AspectArgumentNullability, Old: "unspecified", New: "nullable"}, Additive},
+		{Change{Kind: ChangeChanged, Element: "method", Aspect: AspectReturnNullability, Old: "unspecified", New: "nonnull"}, Additive},
+		{Change{Kind: ChangeChanged, Element: "method", Aspect: AspectReturnNullability, Old: "nonnull", New: "nullable"}, SourceBreaking},
+		{Change{Kind: ChangeChanged, Element: "property", Aspect: AspectNullability, Old: "unspecified", New: "nonnull"}, SourceBreaking},
+		{Change{Kind: ChangeChanged, Element: "property", Aspect: AspectNullability, Old: "nonnull", New: "nullable", Access: "readonly"}, SourceBreaking},
+		{Change{Kind: ChangeChanged, Element: "property", Aspect: AspectNullability, Old: "nullable", New: "nonnull", Access: "readonly"}, Additive},
+		{Change{Kind: ChangeChanged, Element: "class property", Aspect: AspectNullability, Old: "unspecified", New: "nonnull", Access: "readonly"}, Additive},
+		{Change{Kind: ChangeChanged, Element: "property", Aspect: AspectNullability, Old: "nonnull", New: "nullable", Access: "readwrite"}, SourceBreaking},
+		{Change{Kind: ChangeChanged, Element: "property", Aspect: AspectNullability, Old: "nullable", New: "nonnull", Access: "readwrite"}, SourceBreaking},
+		{Change{Kind: ChangeChanged, Element: "property", Aspect: AspectAccess, Old: "readwrite", New: "readonly"}, BinaryBreaking},
+		{Change{Kind: ChangeChanged, Element: "property", Aspect: AspectAccess, Old: "readonly", New: "readwrite"}, Additive},
+		{Change{Kind: ChangeChanged, Element: "field", Aspect: AspectType, Old: "NSView *", New: "id"}, BinaryBreaking},
+		{Change{Kind: ChangeChanged, Element: "enum case", Aspect: AspectValue, Old: "1", New: "2"}, BinaryBreaking},
+		{Change{Kind: ChangeChanged, Element: "class", Aspect: AspectSuperclass, Old: "NSResponder", New: "NSObject"}, BinaryBreaking},
+		{Change{Kind: ChangeChanged, Element: "class", Aspect: AspectSuperclass, Old: "NSResponder", New: "NSWindowBase", Superclasses: []string{"NSWindowBase", "NSResponder", "NSObject"}}, Additive},
+		{Change{Kind: ChangeChanged, Element: "class", Aspect: AspectSuperclass, Old: "NSResponder", New: "NSWindowBase", Superclasses: []string{"NSWindowBase", "NSObject"}}, BinaryBreaking},
+	} {
+		if got := Classify(tt.change); got != tt.want {
+			t.Errorf("%s %s: exp=%s got=%s", tt.change.Element, tt.change.Aspect, tt.want, got)
+		}
+	}
+}
+
+func TestCompatibility_AtLeast(t *testing.T) {
+	if !BinaryBreaking.AtLeast(SourceBreaking) || SourceBreaking.AtLeast(BinaryBreaking) || !Additive.AtLeast(Additive) {
+		t.Error("wrong order")
+	}
+	if _, err := ParseCompatibility("breaking"); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestDiff_Compatibility(t *testing.T) {
+	class := func(name, super string, props ...Property) Schema {
+		return Schema{Class: &Class{Identifier: Identifier{Name: name}, Superclass: super, InstanceProperties: props}}
+	}
+	prop := func(name string, n Nullability, readonly bool) Property {
+		p := Property{Name: name, Type: DataType{Kind: "pointer", Elem: &DataType{Name: "NSView"}, Nullability: n}}
+		if readonly {
+			p.Attrs = map[string]interface{}{"readonly": true}
+		}
+		return p
+	}
+	old := []Schema{
+		class("NSWindow", "NSResponder",
+			prop("contentView", NullabilityNullable, true),
+			prop("initialFirstResponder", NullabilityNullable, false)),
+		class("NSPanel", "NSWindow"),
+	}
+	new := []Schema{
+		class("NSWindowBase", "NSResponder"),
+		class("NSWindow", "NSWindowBase",
+			prop("contentView", NullabilityNonnull, true),
+			prop("initialFirstResponder", NullabilityNonnull, false)),
+		class("NSPanel", "NSResponder"),
+	}
+	got := make(map[string]Compatibility)
+	for _, c := range Diff(old, new) {
+		if c.Kind == ChangeChanged {
+			got[c.Symbol+" "+c.Aspect] = c.Compatibility
+		}
+	}
+	for sym, want := range map[string]Compatibility{
+		"NSWindow superclass":                        Additive,
+		"NSPanel superclass":                         BinaryBreaking,
+		"NSWindow.contentView nullability":           Additive,
+		"NSWindow.initialFirstResponder nullability": SourceBreaking,
+	} {
+		if got[sym] != want {
+			t.Errorf("%s: exp=%s got=%s", sym, want, got[sym])
+		}
+	}
+}
@@ -0,0 +1,471 @@
+package schema
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// ChangeKind is whether a change adds, removes or changes a declaration.
+type ChangeKind string
+
+const (
+	ChangeAdded   ChangeKind = "added"
+	ChangeRemoved ChangeKind = "removed"
+	ChangeChanged ChangeKind = "changed"
+)
+
+// Change is a difference between two versions of an API. Element is what
+// kind of declaration changed, like class, method or enum case, and Symbol
+// names it, as in -[NSWindow setTitle:], NSWindow.title for properties,
+// NSEdgeInsets.top for fields and NSWindow<NSCoding> for the protocols a
+// class conforms to. Aspect, Old and New are set for changed
+// declarations, with Arg naming the argument when the change is to one.
+// Access and Superclasses are what Classify needs to know about a changed
+// property or superclass. Compatibility is the Classify of the change.
+type Change struct {
+	Kind    ChangeKind
+	Element string
+	Symbol  string
+	Aspect  string `json:",omitempty"`
+	Arg     string `json:",omitempty"`
+	Old     string `json:",omitempty"`
+	New     string `json:",omitempty"`
+
+	// Access is readonly or readwrite for changes to properties: the
+	// access code using the old API has, readwrite only when it is in
+	// both versions.
+	Access string `json:",omitempty"`
+
+	// Superclasses are the new superclass of a class whose superclass
+	// changed and the ancestors of it that are in the new schemas.
+	Superclasses []string `json:",omitempty"`
+
+	Compatibility Compatibility
+}
+
+// The aspects of changed declarations.
+const (
+	AspectDeprecation         = "deprecation"
+	AspectSuperclass          = "superclass"
+	AspectSignature           = "signature"
+	AspectReturnType          = "return type"
+	AspectReturnNullability   = "return nullability"
+	AspectArgumentType        = "argument type"
+	AspectArgumentNullability = "argument nullability"
+	AspectType                = "type"
+	AspectNullability         = "nullability"
+	AspectAccess              = "access"
+	AspectValue               = "value"
+)
+
+// String returns the change in a line of text, starting with +, - or ~
+// for added, removed and changed declarations.
+func (c Change) String() string {
+	switch c.Kind {
+	case ChangeAdded:
+		return fmt.Sprintf("+ %s %s", c.Element, c.Symbol)
+	case ChangeRemoved:
+		return fmt.Sprintf("- %s %s", c.Element, c.Symbol)
+	}
+	aspect := c.Aspect
+	if c.Arg != "" {
+		aspect += " of " + c.Arg
+	}
+	return fmt.Sprintf("~ %s %s: %s %s -> %s", c.Element, c.Symbol, aspect, c.Old, c.New)
+}
+
+// Diff compares the declarations of two sets of schemas, like those of
+// two SDK versions, and returns the changes from old to new. They are in
+// order of the name of the declaration or the type they're part of.
+// Declarations are matched by name and compared by structure, so
+// changes to descriptions and the formatting of declarations are left
+// out.
+func Diff(old, new []Schema) []Change {
+	d := &differ{superclasses: make(map[string]string)}
+	for _, s := range new {
+		if s.Class != nil {
+			d.superclasses[s.Class.Name] = s.Class.Superclass
+		}
+	}
+	oldDecls, newDecls := topLevelDecls(old), topLevelDecls(new)
+	for _, key := range unionKeys(oldDecls, newDecls) {
+		o, inOld := oldDecls[key]
+		n, inNew := newDecls[key]
+		switch {
+		case !inOld:
+			d.add(ChangeAdded, n.element, n.name)
+		case !inNew:
+			d.add(ChangeRemoved, o.element, o.name)
+		default:
+			d.compare(o, n)
+		}
+	}
+	return d.changes
+}
+
+// topLevelDecl is a declaration of a schema, or of an API collection.
+type topLevelDecl struct {
+	element string
+	name    string
+	v       interface{}
+}
+
+func topLevelDecls(schemas []Schema) map[string]topLevelDecl {
+	decls := make(map[string]topLevelDecl)
+	add := func(element, name string, v interface{}) {
+		decls[name+" "+element] = topLevelDecl{element, name, v}
+	}
+	for _, s := range schemas {
+		switch {
+		case s.Class != nil:
+			add("class", s.Class.Name, s.Class)
+		case s.Protocol != nil:
+			add("protocol", s.Protocol.Name, s.Protocol)
+		case s.Function != nil:
+			add("function", s.Function.Name, s.Function)
+		case s.Variable != nil:
+			add("variable", s.Variable.Name, s.Variable)
+		case s.Enum != nil:
+			add("enum", s.Enum.Name, s.Enum)
+		case s.Struct != nil:
+			add("struct", s.Struct.Name, s.Struct)
+		case s.TypeAlias != nil:
+			add("type alias", s.TypeAlias.Name, s.TypeAlias)
+		}
+	}
+	// declarations in collections that have no schema of their own
+	for _, s := range schemas {
+		if s.APICollection == nil {
+			continue
+		}
+		for i := range s.APICollection.Functions {
+			fn := &s.APICollection.Functions[i]
+			if _, ok := decls[fn.Name+" function"]; !ok {
+				add("function", fn.Name, fn)
+			}
+		}
+		for i := range s.APICollection.Enums {
+			en := &s.APICollection.Enums[i]
+			if _, ok := decls[en.Name+" enum"]; !ok {
+				add("enum", en.Name, en)
+			}
+		}
+	}
+	return decls
+}
+
+type differ struct {
+	changes      []Change
+	superclasses map[string]string // of the new classes
+}
+
+func (d *differ) add(kind ChangeKind, element, symbol string) {
+	c := Change{Kind: kind, Element: element, Symbol: symbol}
+	c.Compatibility = Classify(c)
+	d.changes = append(d.changes, c)
+}
+
+func (d *differ) change(element, symbol, aspect, arg, old, new string) {
+	d.changeWith(Change{Element: element, Symbol: symbol, Aspect: aspect, Arg: arg, Old: old, New: new})
+}
+
+// changeWith adds a change with the context to classify it, if it
+// changes anything.
+func (d *differ) changeWith(c Change) {
+	if c.Old == c.New {
+		return
+	}
+	c.Kind = ChangeChanged
+	c.Compatibility = Classify(c)
+	d.changes = append(d.changes, c)
+}
+
+// ancestry returns a class and its ancestors in the new schemas.
+func (d *differ) ancestry(class string) []string {
+	var classes []string
+	seen := make(map[string]bool)
+	for class != "" && !seen[class] {
+		seen[class] = true
+		classes = append(classes, class)
+		class = d.superclasses[class]
+	}
+	return classes
+}
+
+func (d *differ) deprecation(element, symbol string, old, new bool) {
+	d.change(element, symbol, AspectDeprecation, "", deprecationString(old), deprecationString(new))
+}
+
+func deprecationString(deprecated bool) string {
+	if deprecated {
+		return "deprecated"
+	}
+	return "available"
+}
+
+func (d *differ) compare(o, n topLevelDecl) {
+	switch o := o.v.(type) {
+	case *Class:
+		d.compareClass(n.element, o, n.v.(*Class))
+	case *Func:
+		d.compareFunc(o, n.v.(*Func))
+	case *Variable:
+		d.compareVariable("variable", o.Name, *o, *n.v.(*Variable))
+	case *Enum:
+		n := n.v.(*Enum)
+		d.deprecation("enum", o.Name, o.Deprecated, n.Deprecated)
+		d.change("enum", o.Name, AspectType, "", typeString(o.Type), typeString(n.Type))
+		d.compareVariables("enum case", "", o.Cases, n.Cases)
+	case *Struct:
+		n := n.v.(*Struct)
+		d.deprecation("struct", o.Name, o.Deprecated, n.Deprecated)
+		d.compareVariables("field", o.Name+".", o.Fields, n.Fields)
+	case *TypeAlias:
+		n := n.v.(*TypeAlias)
+		d.deprecation("type alias", o.Name, o.Deprecated, n.Deprecated)
+		d.change("type alias", o.Name, AspectType, "", typeString(o.Type), typeString(n.Type))
+		d.compareVariables("constant", "", o.Values, n.Values)
+	}
+}
+
+func (d *differ) compareClass(element string, o, n *Class) {
+	d.deprecation(element, o.Name, o.Deprecated, n.Deprecated)
+	d.changeWith(Change{
+		Element:      element,
+		Symbol:       o.Name,
+		Aspect:       AspectSuperclass,
+		Old:          o.Superclass,
+		New:          n.Superclass,
+		Superclasses: d.ancestry(n.Superclass),
+	})
+
+	oldProtos, newProtos := make(map[string]bool), make(map[string]bool)
+	for _, p := range o.Protocols {
+		oldProtos[p] = true
+	}
+	for _, p := range n.Protocols {
+		newProtos[p] = true
+	}
+	for _, p := range unionKeys(oldProtos, newProtos) {
+		switch {
+		case !oldProtos[p]:
+			d.add(ChangeAdded, "conformance", o.Name+"<"+p+">")
+		case !newProtos[p]:
+			d.add(ChangeRemoved, "conformance", o.Name+"<"+p+">")
+		}
+	}
+
+	d.compareMethods("method", "-["+o.Name+" ", o.InstanceMethods, n.InstanceMethods)
+	d.compareMethods("class method", "+["+o.Name+" ", o.TypeMethods, n.TypeMethods)
+	d.compareProperties("property", o.Name+".", o.InstanceProperties, n.InstanceProperties)
+	d.compareProperties("class property", o.Name+".", o.TypeProperties, n.TypeProperties)
+}
+
+func (d *differ) compareMethods(element, prefix string, old, new []Method) {
+	oldMethods, newMethods := make(map[string]Method), make(map[string]Method)
+	for _, m := range old {
+		oldMethods[m.Name] = m
+	}
+	for _, m := range new {
+		newMethods[m.Name] = m
+	}
+	for _, name := range unionKeys(oldMethods, newMethods) {
+		o, inOld := oldMethods[name]
+		n, inNew := newMethods[name]
+		sym := prefix + name + "]"
+		switch {
+		case !inOld:
+			d.add(ChangeAdded, element, sym)
+		case !inNew:
+			d.add(ChangeRemoved, element, sym)
+		default:
+			d.deprecation(element, sym, o.Deprecated, n.Deprecated)
+			d.compareSignature(element, sym, o.Return, n.Return, o.Args, n.Args)
+		}
+	}
+}
+
+func (d *differ) compareProperties(element, prefix string, old, new []Property) {
+	oldProps, newProps := make(map[string]Property), make(map[string]Property)
+	for _, p := range old {
+		oldProps[p.Name] = p
+	}
+	for _, p := range new {
+		newProps[p.Name] = p
+	}
+	for _, name := range unionKeys(oldProps, newProps) {
+		o, inOld := oldProps[name]
+		n, inNew := newProps[name]
+		sym := prefix + name
+		switch {
+		case !inOld:
+			d.add(ChangeAdded, element, sym)
+		case !inNew:
+			d.add(ChangeRemoved, element, sym)
+		default:
+			d.deprecation(element, sym, o.Deprecated, n.Deprecated)
+			d.change(element, sym, AspectType, "", typeString(o.Type), typeString(n.Type))
+			access := propertyAccess(o)
+			if propertyAccess(n) != access {
+				access = "readonly"
+			}
+			d.changeWith(Change{
+				Element: element,
+				Symbol:  sym,
+				Aspect:  AspectNullability,
+				Old:     string(o.Type.Nullability),
+				New:     string(n.Type.Nullability),
+				Access:  access,
+			})
+			d.change(element, sym, AspectAccess, "", propertyAccess(o), propertyAccess(n))
+		}
+	}
+}
+
+// propertyAccess returns readonly or readwrite.
+func propertyAccess(p Property) string {
+	if _, ok := p.Attrs["readonly"]; ok {
+		return "readonly"
+	}
+	return "readwrite"
+}
+
+func (d *differ) compareFunc(o, n *Func) {
+	d.deprecation("function", o.Name, o.Deprecated, n.Deprecated)
+	if len(o.Args) != len(n.Args) {
+		d.change("function", o.Name, AspectSignature, "", funcSignature(*o), funcSignature(*n))
+		return
+	}
+	d.compareSignature("function", o.Name, o.Return, n.Return, o.Args, n.Args)
+}
+
+// funcSignature returns the types of a function as a declaration without
+// argument names.
+func funcSignature(fn Func) string {
+	var args []DataType
+	for _, arg := range fn.Args {
+		args = append(args, withoutNullability(arg.Type))
+	}
+	return fmt.Sprintf("%s %s(%s)", typeString(fn.Return), fn.Name, typeList(args))
+}
+
+// compareSignature compares the return and argument types of methods or
+// functions with the same number of arguments.
+func (d *differ) compareSignature(element, sym string, oldRet, newRet DataType, old, new []Arg) {
+	d.change(element, sym, AspectReturnType, "", typeString(oldRet), typeString(newRet))
+	d.change(element, sym, AspectReturnNullability, "", string(oldRet.Nullability), string(newRet.Nullability))
+	for i := 0; i < len(old) && i < len(new); i++ {
+		arg := new[i].Name
+		if arg == "" {
+			arg = fmt.Sprint(i)
+		}
+		d.change(element, sym, AspectArgumentType, arg, typeString(old[i].Type), typeString(new[i].Type))
+		d.change(element, sym, AspectArgumentNullability, arg, string(old[i].Type.Nullability), string(new[i].Type.Nullability))
+	}
+}
+
+func (d *differ) compareVariables(element, prefix string, old, new []Variable) {
+	oldVars, newVars := make(map[string]Variable), make(map[string]Variable)
+	for _, v := range old {
+		oldVars[v.Name] = v
+	}
+	for _, v := range new {
+		newVars[v.Name] = v
+	}
+	for _, name := range unionKeys(oldVars, newVars) {
+		o, inOld := oldVars[name]
+		n, inNew := newVars[name]
+		switch {
+		case !inOld:
+			d.add(ChangeAdded, element, prefix+name)
+		case !inNew:
+			d.add(ChangeRemoved, element, prefix+name)
+		default:
+			d.compareVariable(element, prefix+name, o, n)
+		}
+	}
+}
+
+func (d *differ) compareVariable(element, sym string, o, n Variable) {
+	d.deprecation(element, sym, o.Deprecated, n.Deprecated)
+	d.change(element, sym, AspectType, "", typeString(o.Type), typeString(n.Type))
+	d.change(element, sym, AspectNullability, "", string(o.Type.Nullability), string(n.Type.Nullability))
+	d.change(element, sym, AspectValue, "", variableValue(o), variableValue(n))
+}
+
+// variableValue returns the value of a constant, exactly when it is known.
+func variableValue(v Variable) string {
+	switch {
+	case v.IntValue != nil:
+		return v.IntValue.String()
+	case v.FloatValue != nil:
+		return fmt.Sprint(*v.FloatValue)
+	}
+	return v.Value
+}
+
+// typeString returns a type in C syntax without its nullability, which
+// changes are reported on their own.
+func typeString(dt DataType) string {
+	return withoutNullability(dt).String()
+}
+
+func withoutNullability(dt DataType) DataType {
+	dt.Nullability = ""
+	dt.Annotations = withoutNullabilityAnnots(dt.Annotations)
+	dt.Qualifiers = withoutNullabilityAnnots(dt.Qualifiers)
+	if dt.Elem != nil {
+		elem := withoutNullability(*dt.Elem)
+		dt.Elem = &elem
+	}
+	if len(dt.Params) > 0 {
+		params := make([]DataType, len(dt.Params))
+		for i, p := range dt.Params {
+			params[i] = withoutNullability(p)
+		}
+		dt.Params = params
+	}
+	dt.Block = funcWithoutNullability(dt.Block)
+	dt.FuncPtr = funcWithoutNullability(dt.FuncPtr)
+	return dt
+}
+
+func funcWithoutNullability(fn *Func) *Func {
+	if fn == nil {
+		return nil
+	}
+	f := *fn
+	f.Return = withoutNullability(f.Return)
+	f.Args = make([]Arg, len(fn.Args))
+	for i, arg := range fn.Args {
+		f.Args[i] = Arg{Name: arg.Name, Type: withoutNullability(arg.Type)}
+	}
+	return &f
+}
+
+func withoutNullabilityAnnots(annots []string) []string {
+	var out []string
+	for _, annot := range annots {
+		if _, ok := nullabilityAnnotations[annot]; !ok {
+			out = append(out, annot)
+		}
+	}
+	return out
+}
+
+// unionKeys returns the keys of two maps with string keys, sorted.
+func unionKeys(a, b interface{}) []string {
+	seen := make(map[string]bool)
+	for _, m := range []interface{}{a, b} {
+		for _, k := range reflect.ValueOf(m).MapKeys() {
+			seen[k.String()] = true
+		}
+	}
+	keys := make([]string, 0, len(seen))
+	for k := range seen {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,63 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestDiff(t *testing.T) {
+	old, err := ReadSchemas("testdata/api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changes := Diff(old, old); len(changes) != 0 {
+		t.Fatalf("same schemas: %v", changes)
+	}
+
+	new, err := ReadSchemas("testdata/api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kept []Schema
+	for _, s := range new {
+		switch {
+		case s.Class != nil:
+			c := s.Class
+			c.Protocols = c.Protocols[1:]
+			c.InstanceMethods[0].Args[0].Type.Name = "NSSize"
+			c.InstanceMethods = append(c.InstanceMethods, Method{Name: "close"})
+			c.TypeMethods[0].Deprecated = false
+			c.InstanceProperties[0].Type.Nullability = NullabilityNullable
+			c.InstanceProperties[1].Attrs = map[string]interface{}{"readonly": true}
+		case s.Enum != nil:
+			s.Enum.Cases = s.Enum.Cases[:2]
+		case s.Struct != nil:
+			s.Struct.Fields[0].Type.Name = "double"
+		case s.TypeAlias != nil:
+			continue
+		}
+		kept = append(kept, s)
+	}
+	kept = append(kept, Schema{Function: &Func{Identifier: Identifier{Name: "NSShowAnimationEffect"}}})
+
+	var got []string
+	for _, c := range Diff(old, kept) {
+		got = append(got, c.String())
+	}
+	want := []string{
+		"~ field NSEdgeInsets.top: type CGFloat -> double",
+		"- type alias NSModalResponse",
+		"+ function NSShowAnimationEffect",
+		"- conformance NSWindow<NSAnimatablePropertyContainer>",
+		"+ method -[NSWindow close]",
+		"~ method -[NSWindow initWithContentRect:styleMask:backing:defer:]: argument type of contentRect NSRect -> NSSize",
+		"~ class method +[NSWindow windowNumbersWithOptions:]: deprecation deprecated -> available",
+		"~ property NSWindow.delegate: access readwrite -> readonly",
+		"~ property NSWindow.title: nullability nonnull -> nullable",
+		"- enum case NSWindowStyleMaskFullScreen",
+	}
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Errorf("%v\n%q", diff, got)
+	}
+}
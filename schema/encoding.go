@@ -0,0 +1,440 @@
+package schema
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Encoding computes Objective-C runtime type encodings, like @encode does,
+// from DataTypes. Its tables can be changed or loaded from JSON to cover
+// more types: Primitives maps type names to their encoding, Types maps
+// typedefs and enums to their underlying type and Structs gives the field
+// layout of structs.
+type Encoding struct {
+	Arch       string // arm64 or x86_64
+	Primitives map[string]string
+	Types      map[string]DataType
+	Structs    map[string]Struct
+}
+
+// primitiveEncodings are the encodings of C and Objective-C types on
+// 64-bit Apple platforms. BOOL depends on the architecture.
+var primitiveEncodings = map[string]string{
+	"void":               "v",
+	"char":               "c",
+	"signed char":        "c",
+	"unsigned char":      "C",
+	"short":              "s",
+	"unsigned short":     "S",
+	"int":                "i",
+	"unsigned int":       "I",
+	"unsigned":           "I",
+	"long":               "q",
+	"unsigned long":      "Q",
+	"long long":          "q",
+	"unsigned long long": "Q",
+	"float":              "f",
+	"double":             "d",
+	"long double":        "D",
+	"bool":               "B",
+	"_Bool":              "B",
+	"int8_t":             "c",
+	"uint8_t":            "C",
+	"int16_t":            "s",
+	"uint16_t":           "S",
+	"int32_t":            "i",
+	"uint32_t":           "I",
+	"int64_t":            "q",
+	"uint64_t":           "Q",
+	"size_t":             "Q",
+	"id":                 "@",
+	"instancetype":       "@",
+	"Class":              "#",
+	"SEL":                ":",
+}
+
+// typeAliases are common Foundation and Core Graphics typedefs.
+var typeAliases = map[string]string{
+	"NSInteger":      "long",
+	"NSUInteger":     "unsigned long",
+	"CGFloat":        "double",
+	"NSTimeInterval": "double",
+	"CFIndex":        "long",
+	"CGGlyph":        "unsigned short",
+	"unichar":        "unsigned short",
+	"UniChar":        "unsigned short",
+	"OSStatus":       "int",
+	"CFTypeRef":      "id",
+}
+
+// structLayouts are common Foundation and Core Graphics structs, as name
+// and type pairs.
+var structLayouts = map[string][]string{
+	"CGPoint":           {"x", "CGFloat", "y", "CGFloat"},
+	"CGSize":            {"width", "CGFloat", "height", "CGFloat"},
+	"CGRect":            {"origin", "CGPoint", "size", "CGSize"},
+	"CGVector":          {"dx", "CGFloat", "dy", "CGFloat"},
+	"CGAffineTransform": {"a", "CGFloat", "b", "CGFloat", "c", "CGFloat", "d", "CGFloat", "tx", "CGFloat", "ty", "CGFloat"},
+	"NSRange":           {"location", "NSUInteger", "length", "NSUInteger"},
+	"NSEdgeInsets":      {"top", "CGFloat", "left", "CGFloat", "bottom", "CGFloat", "right", "CGFloat"},
+}
+
+// structAliases are typedefs of the structs above.
+var structAliases = map[string]string{
+	"NSPoint": "CGPoint",
+	"NSSize":  "CGSize",
+	"NSRect":  "CGRect",
+}
+
+// NewEncoding returns an Encoding for arch, arm64 or x86_64, with tables
+// for the primitive types and common Foundation and Core Graphics types.
+func NewEncoding(arch string) *Encoding {
+	e := &Encoding{
+		Arch:       arch,
+		Primitives: make(map[string]string),
+		Types:      make(map[string]DataType),
+		Structs:    make(map[string]Struct),
+	}
+	for name, enc := range primitiveEncodings {
+		e.Primitives[name] = enc
+	}
+	e.Primitives["BOOL"] = "B"
+	if arch == "x86_64" {
+		e.Primitives["BOOL"] = "c"
+	}
+	for name, typ := range typeAliases {
+		e.Types[name] = DataType{Name: typ}
+	}
+	for name, typ := range structAliases {
+		e.Types[name] = DataType{Name: typ}
+	}
+	for name, layout := range structLayouts {
+		st := Struct{Identifier: Identifier{Name: name}}
+		for i := 0; i < len(layout); i += 2 {
+			st.Fields = append(st.Fields, Variable{
+				Identifier: Identifier{Name: layout[i]},
+				Type:       DataType{Name: layout[i+1]},
+			})
+		}
+		e.Structs[name] = st
+	}
+	return e
+}
+
+// AddSchema adds the type a schema declares to the tables: the underlying
+// type of an enum or typedef, or the layout of a struct.
+func (e *Encoding) AddSchema(s Schema) {
+	switch {
+	case s.Enum != nil && s.Enum.Type.Name != "":
+		e.Types[s.Enum.Name] = s.Enum.Type
+	case s.TypeAlias != nil && (s.TypeAlias.Type.Name != "" || s.TypeAlias.Type.Kind != "" || s.TypeAlias.Type.Block != nil || s.TypeAlias.Type.FuncPtr != nil):
+		e.Types[s.TypeAlias.Name] = s.TypeAlias.Type
+	case s.Struct != nil && len(s.Struct.Fields) > 0:
+		e.Structs[s.Struct.Name] = *s.Struct
+	}
+}
+
+// AddSchemas adds the types declared by the Objective-C schemas in a
+// directory, like api.
+func (e *Encoding) AddSchemas(dir string) error {
+	schemas, err := ReadSchemas(dir)
+	if err != nil {
+		return err
+	}
+	for _, s := range schemas {
+		e.AddSchema(s)
+	}
+	return nil
+}
+
+// encodeMethod sets the type encodings of a method and the blocks it
+// takes, and the variant of objc_msgSend for the method. Methods with
+// types not in the tables are left without.
+func (e *Encoding) encodeMethod(m *Method) {
+	m.TypeEncoding, _ = e.Method(*m)
+	if m.TypeEncoding != "" {
+		m.MsgSend, _ = e.MsgSend(*m)
+	}
+	for _, arg := range m.Args {
+		if fn := arg.Type.Block; fn != nil {
+			fn.TypeEncoding, _ = e.Block(*fn)
+		}
+	}
+}
+
+// encodeProperty sets the attribute string of a property, if its type is
+// in the tables.
+func (e *Encoding) encodeProperty(p *Property) {
+	p.TypeEncoding, _ = e.Property(*p)
+	if fn := p.Type.Block; fn != nil {
+		fn.TypeEncoding, _ = e.Block(*fn)
+	}
+}
+
+// Type returns the encoding of a type, as in @ for NSString *, r* for
+// const char * and {CGPoint=dd} for CGPoint.
+func (e *Encoding) Type(dt DataType) (string, error) {
+	return e.encode(dt, 0, false)
+}
+
+// Method returns the encoding of a method: the return type, the receiver
+// and selector, and the arguments, as in v@:@q.
+func (e *Encoding) Method(m Method) (string, error) {
+	return e.signature(m.Return, "@:", m.Args)
+}
+
+// Block returns the signature of a block, which is passed itself as @?
+// before its arguments.
+func (e *Encoding) Block(fn Func) (string, error) {
+	return e.signature(fn.Return, "@?", fn.Args)
+}
+
+// Func returns the encoding of a C function.
+func (e *Encoding) Func(fn Func) (string, error) {
+	return e.signature(fn.Return, "", fn.Args)
+}
+
+func (e *Encoding) signature(ret DataType, self string, args []Arg) (string, error) {
+	enc, err := e.Type(ret)
+	if err != nil {
+		return "", err
+	}
+	b := &strings.Builder{}
+	b.WriteString(enc + self)
+	for _, arg := range args {
+		enc, err := e.Type(arg.Type)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", arg.Name, err)
+		}
+		b.WriteString(enc)
+	}
+	return b.String(), nil
+}
+
+// Property returns the attribute string of a property, as returned by
+// property_getAttributes without the instance variable: its type, with
+// the class of objects, then its attributes, as in T@"NSString",C,N.
+func (e *Encoding) Property(p Property) (string, error) {
+	enc, err := e.encode(p.Type, 0, true)
+	if err != nil {
+		return "", err
+	}
+	attrs := []string{"T" + enc}
+	if p.Attrs["readonly"] != nil {
+		attrs = append(attrs, "R")
+	}
+	switch {
+	case p.Attrs["copy"] != nil:
+		attrs = append(attrs, "C")
+	case p.Attrs["retain"] != nil || p.Attrs["strong"] != nil:
+		attrs = append(attrs, "&")
+	case p.Attrs["weak"] != nil:
+		attrs = append(attrs, "W")
+	case strings.HasPrefix(enc, "@") && p.Attrs["assign"] == nil:
+		// objects are strong by default
+		attrs = append(attrs, "&")
+	}
+	if p.Attrs["nonatomic"] != nil {
+		attrs = append(attrs, "N")
+	}
+	if getter, ok := p.Attrs["getter"].(string); ok {
+		attrs = append(attrs, "G"+getter)
+	}
+	if setter, ok := p.Attrs["setter"].(string); ok {
+		attrs = append(attrs, "S"+setter)
+	}
+	return strings.Join(attrs, ","), nil
+}
+
+// MsgSend returns the objc_msgSend variant that sends the message of a
+// method, which depends on its return type: on x86_64, structs returned
+// in memory use objc_msgSend_stret and long double objc_msgSend_fpret.
+// Sending any of them to nil returns zero.
+func (e *Encoding) MsgSend(m Method) (string, error) {
+	if e.Arch != "x86_64" {
+		return "objc_msgSend", nil
+	}
+	enc, err := e.Type(m.Return)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case enc == "D":
+		return "objc_msgSend_fpret", nil
+	case strings.HasPrefix(enc, "{"):
+		size, _, err := e.Sizeof(m.Return)
+		if err != nil {
+			return "", err
+		}
+		if size > 16 {
+			return "objc_msgSend_stret", nil
+		}
+	}
+	return "objc_msgSend", nil
+}
+
+// encode returns the encoding of a type inside depth levels of pointers.
+// Like clang, structs only have their fields at the first two levels.
+// With class set, objects have their class, as in @"NSString".
+func (e *Encoding) encode(dt DataType, depth int, class bool) (string, error) {
+	switch {
+	case dt.Kind == "pointer":
+		elem := *dt.Elem
+		var prefix string
+		if hasAnnot(elem, "const") {
+			prefix = "r"
+		}
+		if elem.Kind == "" && elem.Block == nil && elem.FuncPtr == nil {
+			if elem.CName() == "char" {
+				return prefix + "*", nil
+			}
+			if e.isObject(elem.Name) {
+				if class {
+					return fmt.Sprintf(`@"%s"`, elem.Name), nil
+				}
+				return "@", nil
+			}
+		}
+		enc, err := e.encode(elem, depth+1, false)
+		if err != nil {
+			return "", err
+		}
+		return prefix + "^" + enc, nil
+
+	case dt.Kind == "array":
+		enc, err := e.encode(*dt.Elem, depth, false)
+		if err != nil {
+			return "", err
+		}
+		if _, err := strconv.Atoi(dt.Len); err != nil {
+			return "", fmt.Errorf("array length %q is not a number", dt.Len)
+		}
+		return "[" + dt.Len + enc + "]", nil
+
+	case dt.Kind != "":
+		return "", fmt.Errorf("no encoding for %s types", dt.Kind)
+	case dt.Block != nil:
+		return "@?", nil
+	case dt.FuncPtr != nil:
+		return "^?", nil
+	}
+
+	if alias, ok := e.Types[dt.Name]; ok {
+		return e.encode(alias, depth, class)
+	}
+	if enc, ok := e.Primitives[dt.CName()]; ok {
+		if enc == "@" && class && dt.Name == "id" && len(dt.Params) > 0 {
+			var protocols []string
+			for _, p := range dt.Params {
+				protocols = append(protocols, "<"+p.Name+">")
+			}
+			return fmt.Sprintf(`@"%s"`, strings.Join(protocols, "")), nil
+		}
+		return enc, nil
+	}
+	if st, ok := e.Structs[dt.Name]; ok {
+		if depth > 1 {
+			return "{" + dt.Name + "}", nil
+		}
+		b := &strings.Builder{}
+		b.WriteString("{" + dt.Name + "=")
+		for _, f := range st.Fields {
+			enc, err := e.encode(f.Type, depth, false)
+			if err != nil {
+				return "", fmt.Errorf("%s.%s: %w", dt.Name, f.Name, err)
+			}
+			b.WriteString(enc)
+		}
+		b.WriteString("}")
+		return b.String(), nil
+	}
+	return "", fmt.Errorf("no encoding for type %q", dt.Name)
+}
+
+// isObject returns true for the name of a type pointers to which are
+// objects: a class, or any type that isn't in the tables.
+func (e *Encoding) isObject(name string) bool {
+	if _, ok := e.Primitives[name]; ok {
+		return false
+	}
+	if _, ok := e.Types[name]; ok {
+		return false
+	}
+	_, ok := e.Structs[name]
+	return !ok
+}
+
+// encodingSizes are the sizes of encoded primitive types on 64-bit
+// platforms, which are also their alignment.
+var encodingSizes = map[byte]int{
+	'c': 1, 'C': 1, 'B': 1, 's': 2, 'S': 2, 'i': 4, 'I': 4, 'f': 4,
+	'l': 8, 'L': 8, 'q': 8, 'Q': 8, 'd': 8, 'D': 16,
+	'@': 8, '#': 8, ':': 8, '*': 8, '^': 8, '?': 8,
+}
+
+// Sizeof returns the size and alignment of a type with C layout rules,
+// fields aligned to their size and structs padded to their alignment.
+func (e *Encoding) Sizeof(dt DataType) (size, align int, err error) {
+	switch {
+	case dt.Kind == "pointer" || dt.Block != nil || dt.FuncPtr != nil:
+		return 8, 8, nil
+	case dt.Kind == "array":
+		n, err := strconv.Atoi(dt.Len)
+		if err != nil {
+			return 0, 0, fmt.Errorf("array length %q is not a number", dt.Len)
+		}
+		size, align, err := e.Sizeof(*dt.Elem)
+		return n * size, align, err
+	case dt.Kind != "":
+		return 0, 0, fmt.Errorf("no layout for %s types", dt.Kind)
+	}
+	if alias, ok := e.Types[dt.Name]; ok {
+		return e.Sizeof(alias)
+	}
+	if st, ok := e.Structs[dt.Name]; ok {
+		align = 1
+		for _, f := range st.Fields {
+			fsize, falign, err := e.Sizeof(f.Type)
+			if err != nil {
+				return 0, 0, err
+			}
+			size = (size+falign-1)/falign*falign + fsize
+			if falign > align {
+				align = falign
+			}
+		}
+		return (size + align - 1) / align * align, align, nil
+	}
+	enc, err := e.Type(dt)
+	if err != nil {
+		return 0, 0, err
+	}
+	if enc == "v" {
+		return 0, 1, nil
+	}
+	if size, ok := encodingSizes[enc[0]]; ok {
+		return size, size, nil
+	}
+	return 0, 0, fmt.Errorf("no layout for type %q", dt.Name)
+}
+
+// CName returns the name of a named type with its sign, as in unsigned
+// int, which is kept as an annotation.
+func (dt DataType) CName() string {
+	for _, sign := range []string{"signed", "unsigned"} {
+		if hasAnnot(dt, sign) {
+			return sign + " " + dt.Name
+		}
+	}
+	return dt.Name
+}
+
+func hasAnnot(dt DataType, annot string) bool {
+	for _, a := range dt.Annotations {
+		if a == annot {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/progrium/macschema/declparse"
+)
+
+func TestEncoding_Method(t *testing.T) {
+	tests := []struct {
+		decl string
+		want string
+	}{
+		{`- (void)setTitle:(NSString *)title count:(NSInteger)count;`, "v@:@q"},
+		{`- (BOOL)isVisible;`, "B@:"},
+		{`- (const char *)UTF8String;`, "r*@:"},
+		{`- (NSRect)frameRectForContentRect:(NSRect)rect;`, "{CGRect={CGPoint=dd}{CGSize=dd}}@:{CGRect={CGPoint=dd}{CGSize=dd}}"},
+		{`- (BOOL)getBytes:(void *)buffer error:(NSError **)error;`, "B@:^v^@"},
+		{`- (void)getRects:(NSRect **)rects count:(NSUInteger *)count;`, "v@:^^{CGRect}^Q"},
+		{`- (void)getComponents:(CGFloat[4])components;`, "v@:[4d]"},
+		{`- (unsigned long long)count:(unsigned int)a flags:(unsigned short)b data:(unsigned char *)c;`, "Q@:IS^C"},
+		{`- (signed char)value:(const unsigned char *)bytes;`, "c@:r^C"},
+		{`- (void)performSelector:(SEL)sel target:(id<NSCopying>)target class:(Class)cls;`, "v@::@#"},
+		{`- (void)enumerate:(void (^)(id obj, BOOL *stop))block;`, "v@:@?"},
+		{`- (instancetype)initWithContentRect:(const NSRect *)rect;`, "@@:r^{CGRect={CGPoint=dd}{CGSize=dd}}"},
+	}
+	enc := NewEncoding("arm64")
+	for _, tt := range tests {
+		ast, err := declparse.NewStringParser(tt.decl).Parse()
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := MethodFromAst(*ast.Method, false)
+		got, err := enc.Method(m)
+		if err != nil {
+			t.Errorf("%s: %v", tt.decl, err)
+		} else if got != tt.want {
+			t.Errorf("%s: exp=%s got=%s", tt.decl, tt.want, got)
+		}
+		if m.ArgCount() != len(m.Args) {
+			t.Errorf("%s: ArgCount=%d", tt.decl, m.ArgCount())
+		}
+	}
+
+	ast, _ := declparse.NewStringParser(`- (void)foo:(NSWindowStyleMask)mask;`).Parse()
+	if _, err := enc.Method(MethodFromAst(*ast.Method, false)); err == nil {
+		t.Error("expected error for type not in the tables")
+	}
+	enc.AddSchema(Schema{Enum: &Enum{Identifier: Identifier{Name: "NSWindowStyleMask"}, Type: DataType{Name: "NSUInteger"}}})
+	if got, err := enc.Method(MethodFromAst(*ast.Method, false)); err != nil || got != "v@:Q" {
+		t.Errorf("enum: got=%s err=%v", got, err)
+	}
+}
+
+func TestEncoding_Property(t *testing.T) {
+	tests := []struct {
+		decl string
+		want string
+	}{
+		{`@property (copy, nonatomic) NSString *title;`, `T@"NSString",C,N`},
+		{`@property (readonly) NSRect frame;`, `T{CGRect={CGPoint=dd}{CGSize=dd}},R`},
+		{`@property (weak) id<NSWindowDelegate> delegate;`, `T@"<NSWindowDelegate>",W`},
+		{`@property (getter=isVisible) BOOL visible;`, `TB,GisVisible`},
+		{`@property NSView *contentView;`, `T@"NSView",&`},
+	}
+	enc := NewEncoding("arm64")
+	for _, tt := range tests {
+		ast, err := declparse.NewStringParser(tt.decl).Parse()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := enc.Property(PropertyFromAst(*ast.Property, false))
+		if err != nil {
+			t.Errorf("%s: %v", tt.decl, err)
+		} else if got != tt.want {
+			t.Errorf("%s: exp=%s got=%s", tt.decl, tt.want, got)
+		}
+	}
+}
+
+func TestEncoding_MsgSend(t *testing.T) {
+	tests := []struct {
+		decl string
+		arch string
+		want string
+	}{
+		{`- (NSRect)frame;`, "x86_64", "objc_msgSend_stret"},
+		{`- (NSPoint)origin;`, "x86_64", "objc_msgSend"},
+		{`- (NSRect)frame;`, "arm64", "objc_msgSend"},
+	}
+	for _, tt := range tests {
+		ast, err := declparse.NewStringParser(tt.decl).Parse()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := NewEncoding(tt.arch).MsgSend(MethodFromAst(*ast.Method, false))
+		if err != nil || got != tt.want {
+			t.Errorf("%s on %s: exp=%s got=%s err=%v", tt.decl, tt.arch, tt.want, got, err)
+		}
+		m := MethodFromAst(*ast.Method, false)
+		NewEncoding(tt.arch).encodeMethod(&m)
+		if m.MsgSend != tt.want {
+			t.Errorf("%s on %s: MsgSend=%s", tt.decl, tt.arch, m.MsgSend)
+		}
+	}
+}
+
+func TestEncoding_Block(t *testing.T) {
+	ast, err := declparse.NewStringParser(`- (void)enumerate:(void (^)(id obj, NSUInteger idx, BOOL *stop))block;`).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := MethodFromAst(*ast.Method, false)
+	NewEncoding("x86_64").encodeMethod(&m)
+	if got := m.Args[0].Type.Block.TypeEncoding; got != "v@?@Q^c" {
+		t.Errorf("block: got=%s", got)
+	}
+}
@@ -2,24 +2,55 @@ package schema
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"net/url"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/chromedp/cdproto/cdp"
 	"github.com/chromedp/chromedp"
+	"golang.org/x/sync/errgroup"
 )
 
-const defaultTimeout = 20 * time.Second
+const (
+	defaultTimeout = 20 * time.Second
+	fieldTimeout   = 1 * time.Second
+)
 
 type FetchOptions struct {
 	Debug   bool
 	Timeout time.Duration
 }
 
-func FetchTopic(ctx context.Context, l Lookup, opts FetchOptions) Topic {
-	u, _ := url.Parse(l.URL)
+// FetchError is returned by FetchTopic when some topic fields could not be
+// scraped. The Topic returned alongside it is still usable, but the listed
+// fields were left empty.
+type FetchError struct {
+	URL    string
+	Fields map[string]error
+}
+
+func (e *FetchError) Error() string {
+	var names []string
+	for name := range e.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return fmt.Sprintf("%s: missing %s", e.URL, strings.Join(names, ", "))
+}
+
+// FetchTopic downloads the topic for a lookup. A *FetchError is returned
+// along with the topic if only some of its fields could be fetched; any
+// other error means the topic could not be fetched at all.
+func FetchTopic(ctx context.Context, l Lookup, opts FetchOptions) (Topic, error) {
+	var t Topic
+	u, err := url.Parse(l.URL)
+	if err != nil {
+		return t, err
+	}
 
 	var copts []chromedp.ContextOption
 	if opts.Debug {
@@ -35,108 +66,169 @@ func FetchTopic(ctx context.Context, l Lookup, opts FetchOptions) Topic {
 	ctx, cancel = context.WithTimeout(ctx, to)
 	defer cancel()
 
-	var t Topic
 	t.LastFetch = time.Now()
 	t.LastVersion = Version
 	t.Path = strings.Replace(l.URL, BaseURL, "/documentation/", 1)
 
-	mustRun(ctx,
+	if err := chromedp.Run(ctx,
 		chromedp.Navigate(u.String()),
 		chromedp.WaitVisible(`main div.topictitle`),
-	)
-	dur := time.Duration(1 * time.Second)
-	short, _ := context.WithTimeout(ctx, dur)
-	go chromedp.Run(short, chromedp.Text(`main div.topictitle h1.title`, &t.Title))
-	short, _ = context.WithTimeout(ctx, dur)
-	go chromedp.Run(short, chromedp.Text(`main div.topictitle span.eyebrow`, &t.Type))
-	short, _ = context.WithTimeout(ctx, dur)
-	go chromedp.Run(short, chromedp.Text(`main div.documentation-hero div.abstract.content`, &t.Description))
-	short, _ = context.WithTimeout(ctx, dur)
-	go chromedp.Run(short, chromedp.Text(`section.declaration pre.source`, &t.Declaration))
-	short, _ = context.WithTimeout(ctx, dur)
-	go chromedp.Run(short, textList(`main div.summary div.frameworks ul li span`, &t.Frameworks))
-	short, _ = context.WithTimeout(ctx, dur)
-	go chromedp.Run(short, textList(`main div.availability span.platform span`, &t.Platforms))
-
-	short, _ = context.WithTimeout(ctx, dur)
-	err := chromedp.Run(short, chromedp.WaitVisible(`#topics`))
-	if err == nil {
-		sections := nodes(ctx, "div.doc-content > section.contenttable", nil)
-		parent := sections[0]
-		for _, section := range nodes(ctx, "div.contenttable-section", parent) {
-			var title string
-			mustRun(ctx, chromedp.Text("div.section-title h3.contenttable-title", &title, chromedp.ByQuery, chromedp.FromNode(section)))
-			for _, topic := range nodes(ctx, "div.section-content div.topic a.link", section) {
-				var ok bool
-				l := Link{Section: title}
-				mustRun(ctx,
-					chromedp.Text(topic.FullXPathByID(), &l.Name),
-					chromedp.AttributeValue(topic.FullXPathByID(), "href", &l.Path, &ok),
-				)
-				t.Topics = append(t.Topics, l)
-			}
-		}
+	); err != nil {
+		return t, fmt.Errorf("%s: %w", l.URL, err)
 	}
 
+	// Each field is scraped by its own goroutine into its own destination,
+	// so nothing in t may be read until they have all finished.
+	fields := []field{
+		{"Title", run(chromedp.Text(`main div.topictitle h1.title`, &t.Title))},
+		{"Type", run(chromedp.Text(`main div.topictitle span.eyebrow`, &t.Type))},
+		{"Description", run(chromedp.Text(`main div.documentation-hero div.abstract.content`, &t.Description))},
+		{"Declaration", run(chromedp.Text(`section.declaration pre.source`, &t.Declaration))},
+		{"Frameworks", run(textList(`main div.summary div.frameworks ul li span`, &t.Frameworks))},
+		{"Platforms", run(textList(`main div.availability span.platform span`, &t.Platforms))},
+	}
+	topics, fieldErrs, err := scrapeFields(ctx, fields, fetchLinks)
+	if err != nil {
+		return t, fmt.Errorf("%s: %w", l.URL, err)
+	}
+	t.Topics = topics
+	fetchErr := &FetchError{URL: l.URL, Fields: fieldErrs}
+
 	if t.Type == "" && t.Declaration != "" {
 		if t.Declaration[0] == '-' {
 			t.Type = "Instance Method"
+			delete(fetchErr.Fields, "Type")
 		} else if t.Declaration[0] == '+' {
 			t.Type = "Type Method"
+			delete(fetchErr.Fields, "Type")
+		}
+	}
+
+	if err, ok := fetchErr.Fields["Title"]; ok {
+		return t, fmt.Errorf("%s: title: %w", l.URL, err)
+	}
+	if len(fetchErr.Fields) > 0 {
+		return t, fetchErr
+	}
+	return t, nil
+}
+
+// field is a topic field and the action that scrapes it.
+type field struct {
+	name   string
+	scrape func(ctx context.Context) error
+}
+
+// scrapeFields runs the field actions, each limited to fieldTimeout, and
+// links concurrently. Fields that fail are returned by name, with Topics
+// for links that timed out. An error is only returned if ctx is done or
+// links fails for another reason.
+func scrapeFields(ctx context.Context, fields []field, links func(context.Context) ([]Link, error)) ([]Link, map[string]error, error) {
+	fieldErrs := make([]error, len(fields))
+	var topics []Link
+	var topicsErr error
+
+	var g errgroup.Group
+	for i, f := range fields {
+		i, f := i, f
+		g.Go(func() error {
+			short, cancel := context.WithTimeout(ctx, fieldTimeout)
+			defer cancel()
+			fieldErrs[i] = f.scrape(short)
+			return nil
+		})
+	}
+	g.Go(func() error {
+		topics, topicsErr = links(ctx)
+		return nil
+	})
+	g.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	errs := make(map[string]error)
+	for i, err := range fieldErrs {
+		if err != nil {
+			errs[fields[i].name] = err
 		}
 	}
+	if errors.Is(topicsErr, context.DeadlineExceeded) {
+		errs["Topics"] = topicsErr
+	} else if topicsErr != nil {
+		return nil, nil, topicsErr
+	}
+	return topics, errs, nil
+}
+
+// fetchLinks scrapes the links in the topics section of the current page.
+// Pages without a topics section have no links and are not an error, but
+// one that doesn't become visible in time is.
+func fetchLinks(ctx context.Context) ([]Link, error) {
+	var found []*cdp.Node
+	if err := chromedp.Run(ctx, chromedp.Nodes(`#topics`, &found, chromedp.AtLeast(0))); err != nil {
+		return nil, err
+	}
+	if len(found) == 0 {
+		return nil, nil
+	}
+	short, cancel := context.WithTimeout(ctx, fieldTimeout)
+	defer cancel()
+	if err := chromedp.Run(short, chromedp.WaitVisible(`#topics`)); err != nil {
+		return nil, err
+	}
 
-	// topics := "#topics div.contenttable-section div.section-content div.topic a.link"
-	// if os.Getenv("ALLOW_DEPRECATED") == "" {
-	// 	topics = topics + ":not(.deprecated)"
-	// }
-	// for _, n := range nodes(ctx, topics) {
-	// 	var t Topic
-	// 	var ok bool
-	// 	if err := chromedp.Run(ctx,
-	// 		chromedp.TextContent(n.FullXPathByID(), &t.Name),
-	// 		chromedp.AttributeValue(n.FullXPathByID(), "href", &t.URL, &ok),
-	// 	); err != nil {
-	// 		log.Fatal(err)
-	// 	}
-
-	// 	// Handle related consts/enums later
-	// 	if strings.HasPrefix(t.Name, "NS") ||
-	// 		strings.HasPrefix(t.Name, "CG") ||
-	// 		strings.HasPrefix(t.Name, "UI") ||
-	// 		strings.HasPrefix(t.Name, "WK") {
-	// 		continue
-	// 	}
-
-	// 	// Manual fix for less than perfect selector
-	// 	if strings.HasPrefix(t.Name, "API Reference") {
-	// 		continue
-	// 	}
-
-	// 	if t.URL != "" {
-	// 		t.URL = fmt.Sprintf("https://developer.apple.com%s", t.URL)
-	// 	}
-
-	// 	if strings.HasPrefix(t.Name, "+ ") {
-	// 		class.TypeMethods = append(class.TypeMethods, TypeMethod{Name: t.Name[2:], URL: t.URL})
-	// 	} else if strings.HasPrefix(t.Name, "- ") {
-	// 		class.InstanceMethods = append(class.InstanceMethods, InstanceMethod{Name: t.Name[2:], URL: t.URL})
-	// 	} else {
-	// 		class.Properties = append(class.Properties, Property{Name: t.Name, URL: t.URL})
-	// 	}
-	// }
-
-	return t
+	sections, err := nodes(ctx, "div.doc-content > section.contenttable", nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(sections) == 0 {
+		return nil, nil
+	}
+	var links []Link
+	contents, err := nodes(ctx, "div.contenttable-section", sections[0])
+	if err != nil {
+		return nil, err
+	}
+	for _, section := range contents {
+		var title string
+		if err := chromedp.Run(ctx, chromedp.Text("div.section-title h3.contenttable-title", &title, chromedp.ByQuery, chromedp.FromNode(section))); err != nil {
+			return nil, err
+		}
+		topics, err := nodes(ctx, "div.section-content div.topic a.link", section)
+		if err != nil {
+			return nil, err
+		}
+		for _, topic := range topics {
+			var ok bool
+			l := Link{Section: title}
+			if err := chromedp.Run(ctx,
+				chromedp.Text(topic.FullXPathByID(), &l.Name),
+				chromedp.AttributeValue(topic.FullXPathByID(), "href", &l.Path, &ok),
+			); err != nil {
+				return nil, err
+			}
+			links = append(links, l)
+		}
+	}
+	return links, nil
+}
+
+// run returns a field scraper that runs action.
+func run(action chromedp.Action) func(context.Context) error {
+	return func(ctx context.Context) error {
+		return chromedp.Run(ctx, action)
+	}
 }
 
-func nodes(ctx context.Context, sel string, fromNode *cdp.Node) []*cdp.Node {
+func nodes(ctx context.Context, sel string, fromNode *cdp.Node) ([]*cdp.Node, error) {
 	var nodes []*cdp.Node
 	task := chromedp.Nodes(sel, &nodes)
 	if fromNode != nil {
 		task = chromedp.Nodes(sel, &nodes, chromedp.ByQueryAll, chromedp.FromNode(fromNode))
 	}
-	mustRun(ctx, task)
-	return nodes
+	err := chromedp.Run(ctx, task)
+	return nodes, err
 }
 
 func textList(sel string, lst *[]string) chromedp.Tasks {
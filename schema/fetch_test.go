@@ -0,0 +1,70 @@
+package schema
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestScrapeFields(t *testing.T) {
+	var topic Topic
+	set := func(dst *string, v string) func(context.Context) error {
+		return func(context.Context) error {
+			*dst = v
+			return nil
+		}
+	}
+	timeout := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	fields := []field{
+		{"Title", set(&topic.Title, "NSWindow")},
+		{"Type", set(&topic.Type, "Class")},
+		{"Description", timeout},
+	}
+	links := func(context.Context) ([]Link, error) {
+		return []Link{{Name: "title", Path: "/documentation/appkit/nswindow/title"}}, nil
+	}
+
+	topics, errs, err := scrapeFields(context.Background(), fields, links)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if topic.Title != "NSWindow" || topic.Type != "Class" || len(topics) != 1 {
+		t.Errorf("unexpected topic %+v with links %v", topic, topics)
+	}
+	if len(errs) != 1 || !errors.Is(errs["Description"], context.DeadlineExceeded) {
+		t.Errorf("unexpected field errors %v", errs)
+	}
+}
+
+func TestScrapeFields_Links(t *testing.T) {
+	none := []field{{"Title", func(context.Context) error { return nil }}}
+
+	slow := func(ctx context.Context) ([]Link, error) {
+		return nil, context.DeadlineExceeded
+	}
+	if _, errs, err := scrapeFields(context.Background(), none, slow); err != nil || errs["Topics"] == nil {
+		t.Errorf("timed out links: got field errors %v and error %v, want missing Topics", errs, err)
+	}
+
+	broken := errors.New("no such node")
+	failing := func(ctx context.Context) ([]Link, error) {
+		return nil, broken
+	}
+	if _, _, err := scrapeFields(context.Background(), none, failing); err != broken {
+		t.Errorf("failed links: got error %v, want %v", err, broken)
+	}
+}
+
+func TestScrapeFields_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	fields := []field{{"Title", func(ctx context.Context) error { return ctx.Err() }}}
+	links := func(ctx context.Context) ([]Link, error) { return nil, ctx.Err() }
+
+	if _, _, err := scrapeFields(ctx, fields, links); err != context.Canceled {
+		t.Errorf("got error %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,232 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Symbol is an entry in the local search index. Kind is the type of its
+// topic, as in Class or Instance Method, also for schemas.
+type Symbol struct {
+	Name      string
+	Kind      string
+	Framework string
+	Lang      string
+	Path      string // lookup path, eg "appkit/nswindow"
+}
+
+// Index is a symbol index over locally downloaded topics and schemas.
+type Index struct {
+	Symbols []Symbol
+}
+
+// Match is a search result. Lower scores are better matches.
+type Match struct {
+	Symbol
+	Score int
+}
+
+const (
+	matchExact = iota
+	matchPrefix
+	matchSubstring
+	matchFuzzy
+)
+
+// BuildIndex walks the doc and api directories and indexes every topic
+// and schema found in them. Missing directories are skipped.
+func BuildIndex(docDir, apiDir string) (*Index, error) {
+	idx := &Index{}
+	seen := make(map[string]int)
+	add := func(sym Symbol) {
+		key := sym.Path + "." + sym.Lang
+		if i, ok := seen[key]; ok {
+			// topics know more about kind and framework than schemas
+			if idx.Symbols[i].Kind == "" {
+				idx.Symbols[i].Kind = sym.Kind
+			}
+			return
+		}
+		seen[key] = len(idx.Symbols)
+		idx.Symbols = append(idx.Symbols, sym)
+	}
+	if err := walkJSON(docDir, func(p, lang string, b []byte) error {
+		var t Topic
+		if err := json.Unmarshal(b, &t); err != nil {
+			return fmt.Errorf("%s: %w", p, err)
+		}
+		sym := Symbol{Name: t.Title, Kind: t.Type, Lang: lang, Path: indexPath(docDir, p, lang)}
+		if len(t.Frameworks) > 0 {
+			sym.Framework = t.Frameworks[0]
+		}
+		add(sym)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if err := walkJSON(apiDir, func(p, lang string, b []byte) error {
+		var s Schema
+		if err := json.Unmarshal(b, &s); err != nil {
+			return fmt.Errorf("%s: %w", p, err)
+		}
+		id := s.Identifier()
+		sym := Symbol{Name: id.Name, Kind: kindTopicTypes[s.Kind], Lang: lang, Path: indexPath(apiDir, p, lang)}
+		if len(id.Frameworks) > 0 {
+			sym.Framework = id.Frameworks[0]
+		}
+		add(sym)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	for i, sym := range idx.Symbols {
+		if sym.Name == "" {
+			idx.Symbols[i].Name = path.Base(sym.Path)
+		}
+		if sym.Framework == "" {
+			idx.Symbols[i].Framework = strings.Split(sym.Path, "/")[0]
+		}
+	}
+	return idx, nil
+}
+
+// kindTopicTypes are the topic types of the kinds of schemas.
+var kindTopicTypes = map[string]string{
+	"class":         "Class",
+	"protocol":      "Protocol",
+	"typealias":     "Type Alias",
+	"struct":        "Structure",
+	"enum":          "Enumeration",
+	"apicollection": "API Collection",
+}
+
+func walkJSON(dir string, fn func(path, lang string, b []byte) error) error {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil
+	}
+	return filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		var lang string
+		for _, l := range []string{"objc", "swift"} {
+			if strings.HasSuffix(p, "."+l+".json") {
+				lang = l
+			}
+		}
+		if lang == "" {
+			return nil
+		}
+		b, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		return fn(p, lang, b)
+	})
+}
+
+func indexPath(dir, p, lang string) string {
+	rel, err := filepath.Rel(dir, p)
+	if err != nil {
+		rel = p
+	}
+	return filepath.ToSlash(strings.TrimSuffix(rel, "."+lang+".json"))
+}
+
+// Search returns symbols matching the query in the given language, best
+// matches first. Matching is case-insensitive and tries exact, prefix,
+// substring and finally fuzzy subsequence matches. An empty lang matches
+// every language.
+func (idx *Index) Search(query, lang string) []Match {
+	q := strings.ToLower(query)
+	var matches []Match
+	for _, sym := range idx.Symbols {
+		if lang != "" && sym.Lang != lang {
+			continue
+		}
+		score, ok := matchScore(q, strings.ToLower(sym.Name))
+		if base, bok := matchScore(q, path.Base(sym.Path)); bok && (!ok || base < score) {
+			score, ok = base, true
+		}
+		if ok {
+			matches = append(matches, Match{Symbol: sym, Score: score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.Score != b.Score {
+			return a.Score < b.Score
+		}
+		if len(a.Name) != len(b.Name) {
+			return len(a.Name) < len(b.Name)
+		}
+		return a.Path < b.Path
+	})
+	return matches
+}
+
+// Resolve finds the single symbol for a query, returning an
+// *AmbiguousError if several symbols match it exactly.
+func (idx *Index) Resolve(query, lang string) (Symbol, error) {
+	var exact []Symbol
+	for _, m := range idx.Search(query, lang) {
+		if m.Score != matchExact {
+			break
+		}
+		exact = append(exact, m.Symbol)
+	}
+	switch len(exact) {
+	case 0:
+		return Symbol{}, fmt.Errorf("no topic found for %q; use a path like appkit/nswindow or fetch it first", query)
+	case 1:
+		return exact[0], nil
+	default:
+		return Symbol{}, &AmbiguousError{Query: query, Symbols: exact}
+	}
+}
+
+// AmbiguousError is returned when a query matches several topics.
+type AmbiguousError struct {
+	Query   string
+	Symbols []Symbol
+}
+
+func (e *AmbiguousError) Error() string {
+	var paths []string
+	for _, sym := range e.Symbols {
+		paths = append(paths, fmt.Sprintf("%s (%s)", sym.Path, sym.Kind))
+	}
+	return fmt.Sprintf("%q is ambiguous, use one of: %s", e.Query, strings.Join(paths, ", "))
+}
+
+func matchScore(q, name string) (int, bool) {
+	switch {
+	case q == name:
+		return matchExact, true
+	case strings.HasPrefix(name, q):
+		return matchPrefix, true
+	case strings.Contains(name, q):
+		return matchSubstring, true
+	}
+	// fuzzy: every rune of the query appears in order, scored by the
+	// number of skipped runes
+	gaps, i := 0, 0
+	qr := []rune(q)
+	for _, r := range name {
+		if i < len(qr) && r == qr[i] {
+			i++
+		} else if i > 0 && i < len(qr) {
+			gaps++
+		}
+	}
+	if i < len(qr) {
+		return 0, false
+	}
+	return matchFuzzy + gaps, true
+}
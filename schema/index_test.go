@@ -0,0 +1,126 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeJSON(t *testing.T, path string, v interface{}) {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, b, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIndex(t *testing.T) {
+	dir, err := ioutil.TempDir("", "macschema")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	doc := filepath.Join(dir, "doc")
+	api := filepath.Join(dir, "api")
+
+	writeJSON(t, filepath.Join(doc, "appkit/nswindow.objc.json"), Topic{Title: "NSWindow", Type: "Class", Frameworks: []string{"AppKit"}})
+	writeJSON(t, filepath.Join(doc, "appkit/nswindowcontroller.objc.json"), Topic{Title: "NSWindowController", Type: "Class", Frameworks: []string{"AppKit"}})
+	writeJSON(t, filepath.Join(doc, "appkit/nswindow/1419160-orderfront.objc.json"), Topic{Title: "orderFront:", Type: "Instance Method", Frameworks: []string{"AppKit"}})
+	writeJSON(t, filepath.Join(doc, "uikit/uiwindow.objc.json"), Topic{Title: "UIWindow", Type: "Class", Frameworks: []string{"UIKit"}})
+	writeJSON(t, filepath.Join(api, "appkit/nswindow.objc.json"), Schema{Kind: "class", Class: &Class{Identifier: Identifier{Name: "NSWindow"}}})
+	writeJSON(t, filepath.Join(api, "otherkit/nswindow.objc.json"), Schema{Kind: "class", Class: &Class{Identifier: Identifier{Name: "NSWindow"}}})
+
+	idx, err := BuildIndex(doc, api)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(idx.Symbols) != 5 {
+		t.Fatalf("got %d symbols, want 5: %+v", len(idx.Symbols), idx.Symbols)
+	}
+
+	var paths []string
+	for _, m := range idx.Search("nswin", "objc") {
+		paths = append(paths, m.Path)
+	}
+	want := []string{"appkit/nswindow", "otherkit/nswindow", "appkit/nswindowcontroller"}
+	if len(paths) != len(want) {
+		t.Fatalf("prefix search got %v, want %v", paths, want)
+	}
+	for i := range want {
+		if paths[i] != want[i] {
+			t.Fatalf("prefix search got %v, want %v", paths, want)
+		}
+	}
+
+	if m := idx.Search("nswctrl", "objc"); len(m) != 1 || m[0].Path != "appkit/nswindowcontroller" {
+		t.Errorf("fuzzy search got %+v", m)
+	}
+	if m := idx.Search("orderfront", "objc"); len(m) != 1 || m[0].Kind != "Instance Method" {
+		t.Errorf("method search got %+v", m)
+	}
+	for _, m := range idx.Search("nswindow", "objc") {
+		if m.Path == "otherkit/nswindow" && m.Kind != "Class" {
+			t.Errorf("schema kind got %q", m.Kind)
+		}
+	}
+	if m := idx.Search("nswindow", "swift"); len(m) != 0 {
+		t.Errorf("lang filter got %+v", m)
+	}
+
+	sym, err := idx.Resolve("UIWindow", "objc")
+	if err != nil || sym.Path != "uikit/uiwindow" || sym.Framework != "UIKit" {
+		t.Errorf("resolve got %+v, %v", sym, err)
+	}
+	var ambiguous *AmbiguousError
+	if _, err := idx.Resolve("NSWindow", "objc"); !errors.As(err, &ambiguous) || len(ambiguous.Symbols) != 2 {
+		t.Errorf("expected ambiguous error, got %v", err)
+	}
+	if _, err := idx.Resolve("NSNothing", "objc"); err == nil {
+		t.Error("expected error for unknown symbol")
+	}
+}
+
+func TestNewLookup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "macschema")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	chdir(t, dir)
+
+	writeJSON(t, "doc/appkit/nswindow.objc.json", Topic{Title: "NSWindow", Type: "Class"})
+	l, err := NewLookup("NSWindow", "objc")
+	if err != nil || l.DocPath != filepath.Join("doc", "appkit", "nswindow.objc.json") || l.Query != "NSWindow" {
+		t.Fatalf("got %+v, %v", l, err)
+	}
+	idx, err := cachedIndex("./doc", "./api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := cachedIndex("doc", "api"); again != idx {
+		t.Error("index built again")
+	}
+
+	if err := os.MkdirAll("other/doc", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile("other/doc/broken.objc.json", []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	chdir(t, "other")
+	if _, err := NewLookup("NSWindow", "objc"); err == nil {
+		t.Error("expected error for malformed topic")
+	}
+	if l, err := NewLookup("appkit/nswindow", "objc"); err != nil || l.Name != "nswindow" {
+		t.Errorf("path lookup got %+v, %v", l, err)
+	}
+}
@@ -0,0 +1,278 @@
+package schema
+
+//go:generate go run ../tools/jsonschema ../jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// JSONSchema is a JSON Schema (draft 2020-12) describing the JSON of Go
+// types. APIJSONSchema and DocJSONSchema describe the files in api and
+// doc, and Validate checks documents against them.
+type JSONSchema struct {
+	Schema      string                 `json:"$schema,omitempty"`
+	Ref         string                 `json:"$ref,omitempty"`
+	Title       string                 `json:"title,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Type        interface{}            `json:"type,omitempty"` // a type, or a list of types
+	Format      string                 `json:"format,omitempty"`
+	Properties  map[string]*JSONSchema `json:"properties,omitempty"`
+	Required    []string               `json:"required,omitempty"`
+	Items       *JSONSchema            `json:"items,omitempty"`
+	AnyOf       []*JSONSchema          `json:"anyOf,omitempty"`
+
+	// AdditionalProperties is false for structs, which have no other
+	// properties, and the values of maps.
+	AdditionalProperties interface{} `json:"additionalProperties,omitempty"`
+
+	Defs map[string]*JSONSchema `json:"$defs,omitempty"`
+}
+
+const jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// APIJSONSchema returns the JSON Schema of the schemas in api.
+func APIJSONSchema() *JSONSchema {
+	js := NewJSONSchema(Schema{})
+	js.Title = "macschema API schema"
+	js.Description = fmt.Sprintf("A schema of an Apple API in api, version %d.", Version)
+	return js
+}
+
+// DocJSONSchema returns the JSON Schema of the topics in doc.
+func DocJSONSchema() *JSONSchema {
+	js := NewJSONSchema(Topic{})
+	js.Title = "macschema topic"
+	js.Description = fmt.Sprintf("A topic of Apple's documentation in doc, version %d.", Version)
+	return js
+}
+
+// NewJSONSchema returns the JSON Schema of the JSON encoding/json writes
+// for the type of v, a struct. Each struct type is in $defs. Fields with
+// omitempty may be left out and others are required, and may be null
+// when they are pointers, slices or maps.
+func NewJSONSchema(v interface{}) *JSONSchema {
+	defs := make(map[string]*JSONSchema)
+	root := jsonSchemaOf(reflect.TypeOf(v), defs)
+	return &JSONSchema{Schema: jsonSchemaDraft, Ref: root.Ref, Defs: defs}
+}
+
+var (
+	timeType   = reflect.TypeOf(time.Time{})
+	bigIntType = reflect.TypeOf(big.Int{})
+)
+
+func jsonSchemaOf(t reflect.Type, defs map[string]*JSONSchema) *JSONSchema {
+	switch t {
+	case timeType:
+		return &JSONSchema{Type: "string", Format: "date-time"}
+	case bigIntType:
+		return &JSONSchema{Type: "integer"}
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return jsonSchemaOf(t.Elem(), defs)
+	case reflect.String:
+		return &JSONSchema{Type: "string"}
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &JSONSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &JSONSchema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &JSONSchema{Type: "array", Items: jsonSchemaOf(t.Elem(), defs)}
+	case reflect.Map:
+		js := &JSONSchema{Type: "object"}
+		if t.Elem().Kind() != reflect.Interface {
+			js.AdditionalProperties = jsonSchemaOf(t.Elem(), defs)
+		}
+		return js
+	case reflect.Struct:
+		ref := &JSONSchema{Ref: "#/$defs/" + t.Name()}
+		if _, ok := defs[t.Name()]; ok {
+			return ref
+		}
+		js := &JSONSchema{
+			Type:                 "object",
+			Properties:           make(map[string]*JSONSchema),
+			AdditionalProperties: false,
+		}
+		// set before the fields, which may refer to the struct
+		defs[t.Name()] = js
+		addFields(js, t, defs)
+		sort.Strings(js.Required)
+		return ref
+	}
+	// interface{}: any value
+	return &JSONSchema{}
+}
+
+// addFields adds the properties of the fields of a struct, and of the
+// structs embedded in it, as encoding/json does.
+func addFields(js *JSONSchema, t reflect.Type, defs map[string]*JSONSchema) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" || f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+		name, opts := tag, ""
+		if i := strings.Index(tag, ","); i >= 0 {
+			name, opts = tag[:i], tag[i:]
+		}
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			addFields(js, f.Type, defs)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		p := jsonSchemaOf(f.Type, defs)
+		if !strings.Contains(opts, ",omitempty") {
+			js.Required = append(js.Required, name)
+			switch f.Type.Kind() {
+			case reflect.Ptr:
+				p = &JSONSchema{AnyOf: []*JSONSchema{p, {Type: "null"}}}
+			case reflect.Slice, reflect.Map:
+				p.Type = []string{p.Type.(string), "null"}
+			}
+		}
+		js.Properties[name] = p
+	}
+}
+
+// ValidationError lists where a document doesn't match a JSON Schema.
+type ValidationError struct {
+	Errors []string // each as a JSON pointer and what is wrong there
+}
+
+func (e *ValidationError) Error() string {
+	return strings.Join(e.Errors, "\n")
+}
+
+// Validate checks a JSON document against the schema, returning a
+// ValidationError if it doesn't match. It supports the keywords the
+// schemas of NewJSONSchema use.
+func (js *JSONSchema) Validate(b []byte) error {
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return err
+	}
+	e := &ValidationError{}
+	js.validate(js, v, "", e)
+	if len(e.Errors) > 0 {
+		return e
+	}
+	return nil
+}
+
+func (js *JSONSchema) validate(root *JSONSchema, v interface{}, path string, e *ValidationError) {
+	if js.Ref != "" {
+		def, ok := root.Defs[strings.TrimPrefix(js.Ref, "#/$defs/")]
+		if !ok {
+			e.add(path, "unknown $ref %s", js.Ref)
+			return
+		}
+		def.validate(root, v, path, e)
+		return
+	}
+	if len(js.AnyOf) > 0 {
+		for _, s := range js.AnyOf {
+			sub := &ValidationError{}
+			if s.validate(root, v, path, sub); len(sub.Errors) == 0 {
+				return
+			}
+		}
+		e.add(path, "matches none of anyOf")
+		return
+	}
+	if js.Type != nil && !js.hasType(jsonType(v)) {
+		e.add(path, "expected %v, got %s", js.Type, jsonType(v))
+		return
+	}
+	switch v := v.(type) {
+	case []interface{}:
+		if js.Items != nil {
+			for i, item := range v {
+				js.Items.validate(root, item, fmt.Sprintf("%s/%d", path, i), e)
+			}
+		}
+	case map[string]interface{}:
+		for _, name := range js.Required {
+			if _, ok := v[name]; !ok {
+				e.add(path, "missing property %s", name)
+			}
+		}
+		var names []string
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			p := path + "/" + name
+			if s, ok := js.Properties[name]; ok {
+				s.validate(root, v[name], p, e)
+				continue
+			}
+			switch ap := js.AdditionalProperties.(type) {
+			case bool:
+				if !ap {
+					e.add(p, "unknown property")
+				}
+			case *JSONSchema:
+				ap.validate(root, v[name], p, e)
+			}
+		}
+	}
+}
+
+func (js *JSONSchema) hasType(t string) bool {
+	types, ok := js.Type.([]string)
+	if !ok {
+		types = []string{js.Type.(string)}
+	}
+	for _, typ := range types {
+		if typ == t || typ == "number" && t == "integer" {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonType returns the JSON Schema type of a value decoded with numbers as
+// json.Number.
+func jsonType(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if strings.ContainsAny(string(v), ".eE") {
+			return "number"
+		}
+		return "integer"
+	case []interface{}:
+		return "array"
+	}
+	return "object"
+}
+
+func (e *ValidationError) add(path, format string, args ...interface{}) {
+	if path == "" {
+		path = "/"
+	}
+	e.Errors = append(e.Errors, path+": "+fmt.Sprintf(format, args...))
+}
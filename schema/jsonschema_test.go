@@ -0,0 +1,80 @@
+package schema
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestJSONSchema_Golden(t *testing.T) {
+	for dir, js := range map[string]*JSONSchema{
+		"testdata/api": APIJSONSchema(),
+		"testdata/doc": DocJSONSchema(),
+	} {
+		n := 0
+		err := walkJSON(dir, func(p, lang string, b []byte) error {
+			n++
+			if err := js.Validate(b); err != nil {
+				t.Errorf("%s:\n%v", p, err)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n == 0 {
+			t.Errorf("%s: no files", dir)
+		}
+	}
+}
+
+func TestJSONSchema_Validate(t *testing.T) {
+	doc := `{
+		"Class": {
+			"Name": "NSWindow",
+			"Superclass": 1,
+			"InstanceMethods": [{"Name": "close", "Description": "", "Declaration": "", "Return": {"Name": "void", "Kind": null}, "Args": null, "Extra": true}]
+		},
+		"APICollection": null,
+		"Kind": "class",
+		"Version": 3
+	}`
+	err := APIJSONSchema().Validate([]byte(doc))
+	verr, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatalf("got %v", err)
+	}
+	want := []string{
+		"/: missing property PullDate",
+		"/Class/InstanceMethods/0/Extra: unknown property",
+		"/Class/InstanceMethods/0/Return/Kind: expected string, got null",
+		"/Class/Superclass: expected string, got integer",
+	}
+	if diff := deep.Equal(verr.Errors, want); diff != nil {
+		t.Error(diff)
+	}
+}
+
+// TestJSONSchema_Published checks the schemas in jsonschema are those of
+// the Go types. They are written by go generate.
+func TestJSONSchema_Published(t *testing.T) {
+	for name, js := range map[string]*JSONSchema{
+		"api.schema.json": APIJSONSchema(),
+		"doc.schema.json": DocJSONSchema(),
+	} {
+		b, err := ioutil.ReadFile(filepath.Join("../jsonschema", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := json.MarshalIndent(js, "", "  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != string(want)+"\n" {
+			t.Errorf("%s is out of date, run go generate ./schema", name)
+		}
+	}
+}
@@ -1,15 +1,11 @@
 package schema
 
 import (
-	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
-	"time"
-
-	"github.com/chromedp/cdproto/cdp"
-	"github.com/chromedp/chromedp"
+	"sync"
 )
 
 type Lookup struct {
@@ -36,28 +32,34 @@ func (l Lookup) APIExists() bool {
 	return true
 }
 
-func NewLookup(query, lang string) Lookup {
-	l := Lookup{
-		Query: query,
-		Lang:  lang,
-	}
+// NewLookup returns the lookup of a query in a language. Queries with a
+// slash are paths, like appkit/nswindow, and others are names of topics
+// or schemas in the doc and api dirs, like NSWindow.
+func NewLookup(query, lang string) (Lookup, error) {
 	path := strings.ToLower(query)
 	if !strings.Contains(path, "/") {
-		m, err := filepath.Glob(fmt.Sprintf("./doc/*/%s.%s.json", path, lang))
-		fatal(err)
-		if len(m) == 0 {
-			m, err = filepath.Glob(fmt.Sprintf("./api/*/%s.%s.json", path, lang))
-			fatal(err)
+		idx, err := cachedIndex("./doc", "./api")
+		if err != nil {
+			return Lookup{}, err
 		}
-		if len(m) == 0 {
-			m = append(m, search(path))
+		sym, err := idx.Resolve(path, lang)
+		if err != nil {
+			return Lookup{}, err
 		}
-		path = strings.Replace(m[0], "doc/", "", 1)
-		path = strings.Replace(path, "api/", "", 1)
-		path = strings.Replace(path, "documentation/", "", 1)
-		path = strings.Replace(path, ".objc.json", "", 1)
-		path = strings.Replace(path, ".swift.json", "", 1)
+		path = sym.Path
 	}
+	l := lookupPath(path, lang)
+	l.Query = query
+	return l, nil
+}
+
+// lookupPath returns the lookup of a path like appkit/nswindow.
+func lookupPath(path, lang string) Lookup {
+	l := Lookup{
+		Query: path,
+		Lang:  lang,
+	}
+	path = strings.ToLower(path)
 	l.Prefix = filepath.Dir(path)
 	l.Name = filepath.Base(path)
 	ext := fmt.Sprintf(".%s.json", lang)
@@ -67,21 +69,26 @@ func NewLookup(query, lang string) Lookup {
 	return l
 }
 
-func search(s string) string {
-	ctx, cancel := chromedp.NewExecAllocator(context.Background(), append(chromedp.DefaultExecAllocatorOptions[:],
-		chromedp.Flag("headless", true),
-		chromedp.UserAgent("Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/90.0.4430.212 Safari/537.36"))...)
-	defer cancel()
-	ctx, cancel = chromedp.NewContext(ctx) //chromedp.WithDebugf(log.Printf)
-	defer cancel()
-	ctx, cancel = context.WithTimeout(ctx, 10*time.Second)
-	defer cancel()
+// indexes are the indexes names are resolved with, by the absolute paths
+// of their doc and api dirs, so they're built once.
+var indexes sync.Map
 
-	var nodes []*cdp.Node
-	mustRun(ctx,
-		chromedp.Navigate("https://developer.apple.com/search/?q="+s),
-		chromedp.WaitVisible(`.results-summary`),
-		chromedp.Nodes(`.search-result .result-title a`, &nodes),
-	)
-	return strings.Trim(nodes[0].AttributeValue("href"), "/")
+func cachedIndex(docDir, apiDir string) (*Index, error) {
+	var key string
+	for _, dir := range []string{docDir, apiDir} {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, err
+		}
+		key += abs + string(filepath.ListSeparator)
+	}
+	if idx, ok := indexes.Load(key); ok {
+		return idx.(*Index), nil
+	}
+	idx, err := BuildIndex(docDir, apiDir)
+	if err != nil {
+		return nil, err
+	}
+	indexes.Store(key, idx)
+	return idx, nil
 }
@@ -0,0 +1,183 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Merge returns the Objective-C schema objc with the Swift view of the same
+// API from the Swift schema swift. Each member that has a counterpart in
+// Swift gets it as its Swift field and its SwiftName. Members are aligned
+// by topic URL, which Apple shares between the languages, or else by the
+// name NS_SWIFT_NAME gives them. Swift members without an Objective-C
+// counterpart are left out.
+func Merge(objc, swift Schema) (Schema, error) {
+	id, swiftID := objc.Identifier(), swift.Identifier()
+	if topicID(id.TopicURL) != topicID(swiftID.TopicURL) {
+		return objc, fmt.Errorf("merge: %s and %s are different topics", id.TopicURL, swiftID.TopicURL)
+	}
+
+	switch {
+	case objc.Class != nil:
+		objc.Class = mergeClass(*objc.Class, swiftID.Name, swift.Class)
+
+	case objc.Protocol != nil:
+		objc.Protocol = mergeClass(*objc.Protocol, swiftID.Name, swift.Protocol)
+
+	case objc.Enum != nil:
+		en := *objc.Enum
+		en.SwiftName = swiftID.Name
+		en.Cases = mergeVariables(en.Cases, swift.variables())
+		objc.Enum = &en
+
+	case objc.Struct != nil:
+		st := *objc.Struct
+		st.SwiftName = swiftID.Name
+		st.Fields = mergeVariables(st.Fields, swift.variables())
+		objc.Struct = &st
+
+	case objc.TypeAlias != nil:
+		ta := *objc.TypeAlias
+		ta.SwiftName = swiftID.Name
+		ta.Values = mergeVariables(ta.Values, swift.variables())
+		objc.TypeAlias = &ta
+
+	case objc.Function != nil:
+		fn := *objc.Function
+		if swift.Function != nil {
+			fn.SwiftName, fn.Swift = swift.Function.Name, swift.Function
+		}
+		objc.Function = &fn
+
+	case objc.Variable != nil:
+		v := *objc.Variable
+		if swift.Variable != nil {
+			v.SwiftName, v.Swift = swift.Variable.Name, swift.Variable
+		}
+		objc.Variable = &v
+
+	case objc.APICollection != nil:
+		ac := *objc.APICollection
+		ac.SwiftName = swiftID.Name
+		if swift.APICollection != nil {
+			ac.Functions = mergeFuncs(ac.Functions, swift.APICollection.Functions)
+		}
+		objc.APICollection = &ac
+	}
+	return objc, nil
+}
+
+// mergeClass returns the class or protocol c with the members of its
+// Swift view swift, which may be nil.
+func mergeClass(c Class, swiftName string, swift *Class) *Class {
+	c.SwiftName = swiftName
+	if swift != nil {
+		methods := append(append([]Method{}, swift.InstanceMethods...), swift.TypeMethods...)
+		props := append(append([]Property{}, swift.InstanceProperties...), swift.TypeProperties...)
+		c.InstanceMethods = mergeMethods(c.InstanceMethods, methods)
+		c.TypeMethods = mergeMethods(c.TypeMethods, methods)
+		c.InstanceProperties = mergeProperties(c.InstanceProperties, props)
+		c.TypeProperties = mergeProperties(c.TypeProperties, props)
+	}
+	return &c
+}
+
+// variables returns the cases, fields or values of the schema. Options
+// that are enums in Objective-C are structs in Swift.
+func (s Schema) variables() []Variable {
+	switch {
+	case s.Enum != nil:
+		return s.Enum.Cases
+	case s.Struct != nil:
+		return s.Struct.Fields
+	case s.TypeAlias != nil:
+		return s.TypeAlias.Values
+	}
+	return nil
+}
+
+// topicID returns the part of a topic URL that is the same in every
+// language, which leaves out the language query.
+func topicID(url string) string {
+	if i := strings.Index(url, "?"); i >= 0 {
+		url = url[:i]
+	}
+	return strings.ToLower(url)
+}
+
+// matcher finds the Swift counterparts of Objective-C members by topic URL
+// and then by Swift name.
+type matcher struct {
+	byTopic map[string]int
+	byName  map[string]int
+}
+
+// newMatcher indexes n Swift members by the topic URL and name member
+// returns for each of them.
+func newMatcher(n int, member func(i int) (url, name string)) matcher {
+	m := matcher{byTopic: make(map[string]int), byName: make(map[string]int)}
+	for i := 0; i < n; i++ {
+		url, name := member(i)
+		if url != "" {
+			m.byTopic[topicID(url)] = i
+		}
+		m.byName[name] = i
+	}
+	return m
+}
+
+// match returns the index of the Swift member with the topic URL or, if
+// there is none, with the Swift name given in Objective-C.
+func (m matcher) match(url, swiftName string) (int, bool) {
+	if i, ok := m.byTopic[topicID(url)]; ok && url != "" {
+		return i, true
+	}
+	if i, ok := m.byName[swiftName]; ok && swiftName != "" {
+		return i, true
+	}
+	return 0, false
+}
+
+func mergeMethods(methods, swift []Method) []Method {
+	m := newMatcher(len(swift), func(i int) (string, string) { return swift[i].TopicURL, swift[i].Name })
+	merged := append([]Method{}, methods...)
+	for i, method := range merged {
+		if j, ok := m.match(method.TopicURL, method.SwiftName); ok {
+			merged[i].SwiftName, merged[i].Swift = swift[j].Name, &swift[j]
+		}
+	}
+	return merged
+}
+
+func mergeProperties(props, swift []Property) []Property {
+	m := newMatcher(len(swift), func(i int) (string, string) { return swift[i].TopicURL, swift[i].Name })
+	merged := append([]Property{}, props...)
+	for i, prop := range merged {
+		if j, ok := m.match(prop.TopicURL, prop.SwiftName); ok {
+			merged[i].SwiftName, merged[i].Swift = swift[j].Name, &swift[j]
+		}
+	}
+	return merged
+}
+
+func mergeFuncs(funcs, swift []Func) []Func {
+	m := newMatcher(len(swift), func(i int) (string, string) { return swift[i].TopicURL, swift[i].Name })
+	merged := append([]Func{}, funcs...)
+	for i, fn := range merged {
+		if j, ok := m.match(fn.TopicURL, fn.SwiftName); ok {
+			merged[i].SwiftName, merged[i].Swift = swift[j].Name, &swift[j]
+		}
+	}
+	return merged
+}
+
+func mergeVariables(vars, swift []Variable) []Variable {
+	m := newMatcher(len(swift), func(i int) (string, string) { return swift[i].TopicURL, swift[i].Name })
+	merged := append([]Variable{}, vars...)
+	for i, v := range merged {
+		if j, ok := m.match(v.TopicURL, v.SwiftName); ok {
+			merged[i].SwiftName, merged[i].Swift = swift[j].Name, &swift[j]
+		}
+	}
+	return merged
+}
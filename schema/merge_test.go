@@ -0,0 +1,70 @@
+package schema
+
+import "testing"
+
+func TestMerge(t *testing.T) {
+	url := BaseURL + "appkit/nswindow"
+	objc := Schema{Kind: "class", Class: &Class{
+		Identifier: Identifier{Name: "NSWindow", TopicURL: url + "?language=objc"},
+		InstanceMethods: []Method{
+			{Name: "orderFront:", TopicURL: url + "/1419495-orderfront?language=objc"},
+			{Name: "close", SwiftName: "close()"},
+			{Name: "miniaturize:"},
+		},
+		InstanceProperties: []Property{
+			{Name: "visible", SwiftName: "isVisible"},
+		},
+		TypeMethods: []Method{
+			{Name: "windowWithContentViewController:", TopicURL: url + "/1419551-windowwithcontentviewcontroller?language=objc"},
+		},
+	}}
+	swift := Schema{Kind: "class", Class: &Class{
+		Identifier: Identifier{Name: "NSWindow", TopicURL: url + "?language=swift"},
+		InstanceMethods: []Method{
+			{Name: "orderFront(_:)", TopicURL: url + "/1419495-orderfront?language=swift"},
+			{Name: "close()", TopicURL: url + "/1419662-close?language=swift"},
+			{Name: "init(contentViewController:)", TopicURL: url + "/1419551-windowwithcontentviewcontroller?language=swift"},
+		},
+		InstanceProperties: []Property{
+			{Name: "isVisible", TopicURL: url + "/1419132-isvisible?language=swift"},
+		},
+	}}
+
+	s, err := Merge(objc, swift)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := s.Class
+	if c.SwiftName != "NSWindow" {
+		t.Errorf("SwiftName: got=%q", c.SwiftName)
+	}
+	want := map[string]string{
+		"orderFront:":                      "orderFront(_:)",
+		"close":                            "close()",
+		"miniaturize:":                     "",
+		"windowWithContentViewController:": "init(contentViewController:)",
+	}
+	for _, m := range append(c.InstanceMethods, c.TypeMethods...) {
+		var got string
+		if m.Swift != nil {
+			got = m.Swift.Name
+			if m.SwiftName != got {
+				t.Errorf("%s: SwiftName=%q Swift.Name=%q", m.Name, m.SwiftName, got)
+			}
+		}
+		if got != want[m.Name] {
+			t.Errorf("%s: exp=%q got=%q", m.Name, want[m.Name], got)
+		}
+	}
+	if p := c.InstanceProperties[0]; p.Swift == nil || p.Swift.TopicURL == "" {
+		t.Errorf("visible: unexpected Swift view %+v", p.Swift)
+	}
+	if objc.Class.InstanceMethods[0].Swift != nil {
+		t.Error("Merge modified its argument")
+	}
+
+	swift.Class.TopicURL = BaseURL + "appkit/nsview?language=swift"
+	if _, err := Merge(objc, swift); err == nil {
+		t.Error("expected error merging different topics")
+	}
+}
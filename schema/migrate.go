@@ -0,0 +1,221 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/progrium/macschema/declparse"
+)
+
+// Migration upgrades the JSON of documents written at version From to
+// From+1. Schema and Topic upgrade schemas and topics, and are nil when
+// their format didn't change.
+type Migration struct {
+	From        int
+	Description string
+	Schema      func(b []byte) ([]byte, error)
+	Topic       func(b []byte) ([]byte, error)
+}
+
+// Migrations are the upgrades between versions, one for each version
+// from the oldest that can be upgraded up to Version-1.
+var Migrations = []Migration{
+	{From: 2, Description: "pointers and arrays are recursive types with nullability", Schema: migratePointers},
+	{From: 3, Description: "declarations have availability parsed from their platforms", Schema: migrateAvailability},
+}
+
+// VersionError is returned for documents of a version no migration
+// upgrades: older than the first Migration or newer than Version. Old
+// schemas can be pulled again from their topics, and old topics fetched
+// again.
+type VersionError struct {
+	Version int
+}
+
+func (e *VersionError) Error() string {
+	if e.Version > Version {
+		return fmt.Sprintf("version %d is newer than %d", e.Version, Version)
+	}
+	return fmt.Sprintf("version %d is too old to migrate to %d", e.Version, Version)
+}
+
+// UnmarshalSchema decodes the JSON of a schema into s, upgrading it if it
+// is of an older version. It returns whether it was upgraded.
+func UnmarshalSchema(b []byte, s *Schema) (migrated bool, err error) {
+	var v struct{ Version int }
+	if err := json.Unmarshal(b, &v); err != nil {
+		return false, err
+	}
+	b, err = migrate(b, v.Version, func(m Migration) func([]byte) ([]byte, error) { return m.Schema })
+	if err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(b, s); err != nil {
+		return false, err
+	}
+	s.Version = Version
+	return v.Version != Version, nil
+}
+
+// UnmarshalTopic decodes the JSON of a topic into t, upgrading it if it is
+// of an older version. It returns whether it was upgraded.
+func UnmarshalTopic(b []byte, t *Topic) (migrated bool, err error) {
+	var v struct{ LastVersion int }
+	if err := json.Unmarshal(b, &v); err != nil {
+		return false, err
+	}
+	b, err = migrate(b, v.LastVersion, func(m Migration) func([]byte) ([]byte, error) { return m.Topic })
+	if err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(b, t); err != nil {
+		return false, err
+	}
+	t.LastVersion = Version
+	return v.LastVersion != Version, nil
+}
+
+// migrate applies the step of each migration from version to Version.
+func migrate(b []byte, version int, step func(Migration) func([]byte) ([]byte, error)) ([]byte, error) {
+	if version == Version {
+		return b, nil
+	}
+	if version > Version || len(Migrations) == 0 || version < Migrations[0].From {
+		return nil, &VersionError{Version: version}
+	}
+	for _, m := range Migrations[version-Migrations[0].From:] {
+		fn := step(m)
+		if fn == nil {
+			continue
+		}
+		var err error
+		if b, err = fn(b); err != nil {
+			return nil, fmt.Errorf("migrating from version %d: %w", m.From, err)
+		}
+	}
+	return b, nil
+}
+
+// migratePointers upgrades version 2 schemas, whose types only had IsPtr
+// and IsPtrPtr for pointers, to pointer types with an Elem. Nullability is
+// resolved as for declarations outside an assume_nonnull region, since
+// which region they were in is unknown.
+func migratePointers(b []byte) ([]byte, error) {
+	var s Schema
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, err
+	}
+	eachValue(reflect.ValueOf(&s), reflect.TypeOf(DataType{}), func(v reflect.Value) {
+		dt := v.Addr().Interface().(*DataType)
+		upgradePointers(dt)
+		dt.resolveNullability(false)
+	})
+	for _, c := range []*Class{s.Class, s.Protocol} {
+		if c == nil {
+			continue
+		}
+		for _, props := range [][]Property{c.InstanceProperties, c.TypeProperties} {
+			for i := range props {
+				props[i].resolveNullability(false)
+			}
+		}
+	}
+	return json.Marshal(s)
+}
+
+// migrateAvailability sets the Availability of the identifiers of version
+// 3 schemas from their platforms. Members had no platforms, so their
+// availability is left unknown until they are pulled again.
+func migrateAvailability(b []byte) ([]byte, error) {
+	var s Schema
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, err
+	}
+	eachValue(reflect.ValueOf(&s), reflect.TypeOf(Identifier{}), func(v reflect.Value) {
+		id := v.Addr().Interface().(*Identifier)
+		platforms := id.Platforms
+		if id.Deprecated {
+			platforms = append(platforms, "Deprecated")
+		}
+		id.Availability = ParseAvailability(platforms)
+	})
+	return json.Marshal(s)
+}
+
+// upgradePointers sets the Kind and Elem of a version 2 pointer type and
+// the types in it. The nullability annotations qualify the outermost
+// pointer and the others stay with the named type.
+func upgradePointers(dt *DataType) {
+	for i := range dt.Params {
+		upgradePointers(&dt.Params[i])
+	}
+	for _, fn := range []*Func{dt.Block, dt.FuncPtr} {
+		if fn == nil {
+			continue
+		}
+		upgradePointers(&fn.Return)
+		for i := range fn.Args {
+			upgradePointers(&fn.Args[i].Type)
+		}
+	}
+	sortAnnotations(dt.Annotations)
+	if !dt.IsPtr || dt.Kind != "" {
+		return
+	}
+	named := DataType{Name: dt.Name, Params: dt.Params, Block: dt.Block, FuncPtr: dt.FuncPtr}
+	var quals []string
+	for _, annot := range dt.Annotations {
+		if _, ok := nullabilityAnnotations[annot]; ok {
+			quals = append(quals, annot)
+		} else {
+			named.Annotations = append(named.Annotations, annot)
+		}
+	}
+	elem := named
+	if dt.IsPtrPtr {
+		elem = DataType{Kind: "pointer", Elem: &named}
+	}
+	dt.Kind = "pointer"
+	dt.Elem = &elem
+	dt.Qualifiers = quals
+}
+
+// sortAnnotations puts annotations in the order DataTypeFromAst gives
+// them. Version 2 wrote them in map order.
+func sortAnnotations(annots []string) {
+	order := make(map[string]int)
+	for i, annot := range declparse.TypeAnnotations() {
+		order[strings.ToLower(annot.String())] = i
+	}
+	sort.SliceStable(annots, func(i, j int) bool {
+		return order[annots[i]] < order[annots[j]]
+	})
+}
+
+// eachValue calls fn with each struct of type t in v that isn't part of
+// another of the same type.
+func eachValue(v reflect.Value, t reflect.Type, fn func(reflect.Value)) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			eachValue(v.Elem(), t, fn)
+		}
+	case reflect.Struct:
+		if v.Type() == t {
+			fn(v)
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath == "" {
+				eachValue(v.Field(i), t, fn)
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			eachValue(v.Index(i), t, fn)
+		}
+	}
+}
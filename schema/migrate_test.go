@@ -0,0 +1,116 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/progrium/macschema/declparse"
+)
+
+func TestUnmarshalSchema_Version2(t *testing.T) {
+	v2 := `{
+		"Class": {
+			"Name": "NSWindow",
+			"InstanceMethods": [{
+				"Name": "titleWithError:",
+				"Description": "",
+				"Declaration": "- (NSString * _Nullable)titleWithError:(NSError **)error;",
+				"Return": {"Name": "NSString", "IsPtr": true, "Annotations": ["_nullable"]},
+				"Args": [{"Name": "error", "Type": {"Name": "NSError", "IsPtr": true, "IsPtrPtr": true}}]
+			}],
+			"InstanceProperties": [{
+				"Name": "title",
+				"Description": "",
+				"Declaration": "@property(copy, nullable) NSString *title;",
+				"Type": {"Name": "NSString", "IsPtr": true},
+				"Attrs": {"copy": true, "nullable": true}
+			}]
+		},
+		"APICollection": null,
+		"Kind": "class",
+		"PullDate": "2021-05-01T00:00:00Z",
+		"Version": 2
+	}`
+	var s Schema
+	migrated, err := UnmarshalSchema([]byte(v2), &s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !migrated || s.Version != Version {
+		t.Fatalf("migrated=%v version=%d", migrated, s.Version)
+	}
+
+	// the types are those of the declarations pulled now
+	m := s.Class.InstanceMethods[0]
+	ast, err := declparse.NewStringParser(m.Declaration).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := MethodFromAst(*ast.Method, false)
+	if diff := deep.Equal(m.Return, want.Return); diff != nil {
+		t.Errorf("return: %v", diff)
+	}
+	if diff := deep.Equal(m.Args, want.Args); diff != nil {
+		t.Errorf("args: %v", diff)
+	}
+	p := s.Class.InstanceProperties[0]
+	ast, err = declparse.NewStringParser(p.Declaration).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantProp := PropertyFromAst(*ast.Property, false)
+	if diff := deep.Equal(p.Type, wantProp.Type); diff != nil {
+		t.Errorf("property: %v", diff)
+	}
+
+	// current schemas are left as they are
+	b, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s2 Schema
+	migrated, err = UnmarshalSchema(b, &s2)
+	if err != nil || migrated {
+		t.Fatalf("migrated=%v err=%v", migrated, err)
+	}
+	if diff := deep.Equal(s, s2); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestUnmarshalSchema_Version3(t *testing.T) {
+	v3 := `{"Enum": {"Name": "NSWindowStyleMask", "Platforms": ["macOS 10.0+"], "Deprecated": true, "Type": {}, "Cases": null}, "Version": 3}`
+	var s Schema
+	if _, err := UnmarshalSchema([]byte(v3), &s); err != nil {
+		t.Fatal(err)
+	}
+	want := []Availability{{Platform: "macOS", Introduced: "10.0", Deprecated: "10.0"}}
+	if diff := deep.Equal(s.Enum.Availability, want); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestUnmarshalSchema_VersionError(t *testing.T) {
+	for _, version := range []int{0, 1, Version + 1} {
+		var s Schema
+		b, _ := json.Marshal(map[string]int{"Version": version})
+		_, err := UnmarshalSchema(b, &s)
+		var verr *VersionError
+		if !errors.As(err, &verr) || verr.Version != version {
+			t.Errorf("version %d: got %v", version, err)
+		}
+	}
+}
+
+func TestUnmarshalTopic(t *testing.T) {
+	var topic Topic
+	migrated, err := UnmarshalTopic([]byte(`{"Title": "NSWindow", "LastVersion": 2}`), &topic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !migrated || topic.Title != "NSWindow" || topic.LastVersion != Version {
+		t.Errorf("migrated=%v topic=%+v", migrated, topic)
+	}
+}
@@ -16,7 +16,7 @@ import (
 
 const (
 	BaseURL = "https://developer.apple.com/documentation/"
-	Version = 2
+	Version = 4
 )
 
 func WithBrowserContext(ctx context.Context) (context.Context, context.CancelFunc) {
@@ -29,16 +29,51 @@ func LookupFromPath(path string) Lookup {
 		panic(err)
 	}
 	query := strings.Replace(u.Path, "/documentation/", "", 1)
-	return NewLookup(query, u.Query().Get("language"))
+	return lookupPath(query, u.Query().Get("language"))
 }
 
+// ReadTopic reads the topic of a lookup, upgrading it if it was written
+// by an older version.
 func ReadTopic(l Lookup) (t Topic, err error) {
 	var b []byte
 	b, err = ioutil.ReadFile(l.DocPath)
 	if err != nil {
 		return
 	}
-	err = json.Unmarshal(b, &t)
+	if _, err = UnmarshalTopic(b, &t); err != nil {
+		err = fmt.Errorf("%s: %w", l.DocPath, err)
+	}
+	return
+}
+
+// ReadSchema reads the schema of a lookup, upgrading it if it was written
+// by an older version.
+func ReadSchema(l Lookup) (s Schema, err error) {
+	var b []byte
+	b, err = ioutil.ReadFile(l.APIPath)
+	if err != nil {
+		return
+	}
+	if _, err = UnmarshalSchema(b, &s); err != nil {
+		err = fmt.Errorf("%s: %w", l.APIPath, err)
+	}
+	return
+}
+
+// ReadSchemas reads the Objective-C schemas in a directory, like api, and
+// the directories in it, upgrading those written by older versions.
+func ReadSchemas(dir string) (schemas []Schema, err error) {
+	err = walkJSON(dir, func(p, lang string, b []byte) error {
+		if lang != "objc" {
+			return nil
+		}
+		var s Schema
+		if _, err := UnmarshalSchema(b, &s); err != nil {
+			return fmt.Errorf("%s: %w", p, err)
+		}
+		schemas = append(schemas, s)
+		return nil
+	})
 	return
 }
 
@@ -56,29 +91,20 @@ func Stats() {
 	fmt.Println(stats)
 }
 
-func Types(path string) {
-	c := readSchema(path)
-	var types []DataType
-	collectTypes(&types, reflect.ValueOf(c))
-	uniq := make(map[string]bool)
-	for _, t := range types {
-		uniq[t.Name] = true
-	}
-	for k := range uniq {
-		fmt.Println(k)
-	}
-}
-
-func mustRun(ctx context.Context, actions ...chromedp.Action) {
-	if err := chromedp.Run(ctx, actions...); err != nil {
+func fatal(err error) {
+	if err != nil {
 		log.Fatal(err)
 	}
 }
 
-func fatal(err error) {
-	if err != nil {
-		log.Fatal(err)
+// CollectTypes returns the types of the members of schemas, like the
+// return and argument types of methods.
+func CollectTypes(schemas []Schema) []DataType {
+	var types []DataType
+	for _, s := range schemas {
+		collectTypes(&types, reflect.ValueOf(s))
 	}
+	return types
 }
 
 func collectTypes(types *[]DataType, src reflect.Value) {
@@ -102,12 +128,3 @@ func collectTypes(types *[]DataType, src reflect.Value) {
 		}
 	}
 }
-
-func readSchema(path string) Class {
-	b, err := ioutil.ReadFile(path)
-	fatal(err)
-
-	var c Class
-	fatal(json.Unmarshal(b, &c))
-	return c
-}
@@ -0,0 +1,137 @@
+package schema
+
+// Nullability is whether a pointer type may be nil or NULL.
+type Nullability string
+
+const (
+	NullabilityNonnull     Nullability = "nonnull"
+	NullabilityNullable    Nullability = "nullable"
+	NullabilityUnspecified Nullability = "unspecified"
+
+	// NullabilityResettable is for null_resettable properties, which
+	// can be set to nil but never return it.
+	NullabilityResettable Nullability = "resettable"
+)
+
+// nullabilityAnnotations maps DataType annotations to nullability.
+var nullabilityAnnotations = map[string]Nullability{
+	"_nonnull":          NullabilityNonnull,
+	"_nullable":         NullabilityNullable,
+	"_null_unspecified": NullabilityUnspecified,
+}
+
+// nullabilityAttrs maps property attributes to nullability.
+var nullabilityAttrs = map[string]Nullability{
+	"nonnull":          NullabilityNonnull,
+	"nullable":         NullabilityNullable,
+	"null_unspecified": NullabilityUnspecified,
+	"null_resettable":  NullabilityResettable,
+}
+
+func (m *Method) resolveNullability(assumeNonnull bool) {
+	m.Return.resolveNullability(assumeNonnull)
+	for i := range m.Args {
+		m.Args[i].Type.resolveNullability(assumeNonnull)
+	}
+}
+
+func (fn *Func) resolveNullability(assumeNonnull bool) {
+	fn.Return.resolveNullability(assumeNonnull)
+	for i := range fn.Args {
+		fn.Args[i].Type.resolveNullability(assumeNonnull)
+	}
+}
+
+// resolveNullability applies nullability property attributes, which take
+// precedence over the type.
+func (p *Property) resolveNullability(assumeNonnull bool) {
+	p.Type.resolveNullability(assumeNonnull)
+	for attr, n := range nullabilityAttrs {
+		if _, ok := p.Attrs[attr]; ok {
+			p.Type.Nullability = n
+		}
+	}
+}
+
+// resolveNullability sets the Nullability of dt and the types it refers
+// to if they are pointers. Annotations are used when present. Otherwise a
+// pointer is nonnull if assumeNonnull is set, as in an NS_ASSUME_NONNULL
+// region, and unspecified if not. Like clang, error out-parameters such
+// as NSError ** are nullable in those regions and other pointers to
+// pointers are left unspecified.
+func (dt *DataType) resolveNullability(assumeNonnull bool) {
+	if dt.Block != nil {
+		dt.Block.resolveNullability(assumeNonnull)
+	}
+	if dt.FuncPtr != nil {
+		dt.FuncPtr.resolveNullability(assumeNonnull)
+	}
+	// type arguments and inner pointers are not covered by assume_nonnull
+	for i := range dt.Params {
+		dt.Params[i].resolveNullability(false)
+	}
+	if dt.Elem != nil {
+		dt.Elem.resolveNullability(false)
+	}
+
+	if n, ok := dt.explicitNullability(); ok {
+		dt.Nullability = n
+		return
+	}
+	switch {
+	case !dt.IsPointer():
+		dt.Nullability = ""
+	case !assumeNonnull:
+		dt.Nullability = NullabilityUnspecified
+	case dt.isErrorOut():
+		dt.Nullability = NullabilityNullable
+		if _, ok := dt.Elem.explicitNullability(); !ok {
+			dt.Elem.Nullability = NullabilityNullable
+		}
+	case dt.Elem != nil && dt.Elem.Kind == "pointer":
+		dt.Nullability = NullabilityUnspecified
+	default:
+		dt.Nullability = NullabilityNonnull
+	}
+}
+
+// explicitNullability returns the nullability annotation of the type
+// itself, not of the types it points to.
+func (dt DataType) explicitNullability() (Nullability, bool) {
+	annots := dt.Annotations
+	if dt.Kind != "" {
+		annots = dt.Qualifiers
+	}
+	for _, annot := range annots {
+		if n, ok := nullabilityAnnotations[annot]; ok {
+			return n, true
+		}
+	}
+	return "", false
+}
+
+// isErrorOut returns true for error out-parameter types: NSError ** and
+// CFErrorRef *.
+func (dt DataType) isErrorOut() bool {
+	if dt.Kind != "pointer" {
+		return false
+	}
+	elem := dt.Elem
+	if elem.Kind == "" {
+		return elem.Name == "CFErrorRef"
+	}
+	return elem.Kind == "pointer" && elem.Elem.Kind == "" && elem.Elem.Name == "NSError"
+}
+
+// IsPointer returns true for pointers, object types, blocks and function
+// pointers. Types whose typedef hides a pointer, like CFStringRef, are
+// only known to be pointers when they have a nullability annotation.
+func (dt DataType) IsPointer() bool {
+	switch {
+	case dt.Kind == "pointer", dt.Block != nil, dt.FuncPtr != nil:
+		return true
+	case dt.Kind == "" && (dt.Name == "id" || dt.Name == "instancetype" || dt.Name == "Class"):
+		return true
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/progrium/macschema/declparse"
+)
+
+func TestResolveNullability(t *testing.T) {
+	decls := []string{
+		`- (nullable NSString *)titleForItem:(id)item error:(NSError **)error;`,
+		`- (void)setContentView:(__kindof NSView * _Null_unspecified)view;`,
+		`- (void)getBytes:(char * _Nonnull *)buffer length:(NSUInteger)length;`,
+		`- (void)beginSheet:(NSWindow *)sheet completionHandler:(void (^)(NSString * _Nullable title))handler;`,
+		`- (void)performWithOptions:(NSInteger)options completion:(void (^ _Nullable)(void))completion;`,
+		`- (NSArray<NSView *> *)subviews;`,
+		`@property(copy, null_resettable) NSColor *backgroundColor;`,
+		`@property(nullable, copy) NSString *subtitle;`,
+		`@property NSInteger level;`,
+	}
+	// the region comes from the statement, as parsed from a header
+	classFromDecls := func(region string) (c Class) {
+		for _, decl := range decls {
+			ast, err := declparse.NewStringParser(region + decl).Parse()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ast.Method != nil {
+				c.InstanceMethods = append(c.InstanceMethods, MethodFromAst(*ast.Method, ast.AssumeNonnull))
+			} else {
+				c.InstanceProperties = append(c.InstanceProperties, PropertyFromAst(*ast.Property, ast.AssumeNonnull))
+			}
+		}
+		return
+	}
+
+	tests := []struct {
+		name          string
+		dt            func(c Class) DataType
+		assumeNonnull Nullability
+		unaudited     Nullability
+	}{
+		{"nullable return", func(c Class) DataType { return c.InstanceMethods[0].Return }, NullabilityNullable, NullabilityNullable},
+		{"id arg", func(c Class) DataType { return c.InstanceMethods[0].Args[0].Type }, NullabilityNonnull, NullabilityUnspecified},
+		{"error arg", func(c Class) DataType { return c.InstanceMethods[0].Args[1].Type }, NullabilityNullable, NullabilityUnspecified},
+		{"error arg pointee", func(c Class) DataType { return *c.InstanceMethods[0].Args[1].Type.Elem }, NullabilityNullable, NullabilityUnspecified},
+		{"explicit unspecified", func(c Class) DataType { return c.InstanceMethods[1].Args[0].Type }, NullabilityUnspecified, NullabilityUnspecified},
+		{"pointer to pointer", func(c Class) DataType { return c.InstanceMethods[2].Args[0].Type }, NullabilityUnspecified, NullabilityUnspecified},
+		{"inner pointer", func(c Class) DataType { return *c.InstanceMethods[2].Args[0].Type.Elem }, NullabilityNonnull, NullabilityNonnull},
+		{"not a pointer", func(c Class) DataType { return c.InstanceMethods[2].Args[1].Type }, "", ""},
+		{"block", func(c Class) DataType { return c.InstanceMethods[3].Args[1].Type }, NullabilityNonnull, NullabilityUnspecified},
+		{"block arg", func(c Class) DataType { return c.InstanceMethods[3].Args[1].Type.Block.Args[0].Type }, NullabilityNullable, NullabilityNullable},
+		{"nullable block", func(c Class) DataType { return c.InstanceMethods[4].Args[1].Type }, NullabilityNullable, NullabilityNullable},
+		{"void return", func(c Class) DataType { return c.InstanceMethods[3].Return }, "", ""},
+		{"type argument", func(c Class) DataType { return c.InstanceMethods[5].Return.Params[0] }, NullabilityUnspecified, NullabilityUnspecified},
+		{"resettable property", func(c Class) DataType { return c.InstanceProperties[0].Type }, NullabilityResettable, NullabilityResettable},
+		{"nullable property", func(c Class) DataType { return c.InstanceProperties[1].Type }, NullabilityNullable, NullabilityNullable},
+		{"scalar property", func(c Class) DataType { return c.InstanceProperties[2].Type }, "", ""},
+	}
+	for _, assumeNonnull := range []bool{true, false} {
+		region := ""
+		if assumeNonnull {
+			region = "#pragma clang assume_nonnull begin\n"
+		}
+		c := classFromDecls(region)
+		for _, tt := range tests {
+			want := tt.unaudited
+			if assumeNonnull {
+				want = tt.assumeNonnull
+			}
+			if got := tt.dt(c).Nullability; got != want {
+				t.Errorf("%s (assumeNonnull=%v): exp=%q got=%q", tt.name, assumeNonnull, want, got)
+			}
+		}
+	}
+}
@@ -6,11 +6,16 @@ import (
 	"strings"
 
 	"github.com/progrium/macschema/declparse"
+	"github.com/progrium/macschema/swiftparse"
 )
 
-func PullSchema(l Lookup) Schema {
+// PullSchema returns the schema of a topic from the local topics. The
+// methods and properties of Objective-C schemas get their type encodings
+// from enc, unless it is nil.
+func PullSchema(l Lookup, enc *Encoding) Schema {
 	t, err := ReadTopic(l)
 	fatal(err)
+	pl := &puller{lang: l.Lang, enc: enc}
 
 	var s Schema
 	s.PullDate = t.LastFetch
@@ -18,19 +23,21 @@ func PullSchema(l Lookup) Schema {
 
 	switch t.Type {
 	case "Class":
-		schemaForClass(&s, t)
+		pl.schemaForClass(&s, t)
+	case "Protocol":
+		pl.schemaForProtocol(&s, t)
 	case "Type Alias":
-		schemaForTypeAlias(&s, t)
+		pl.schemaForTypeAlias(&s, t)
 	case "Structure":
-		schemaForStruct(&s, t)
+		pl.schemaForStruct(&s, t)
 	case "Global Variable":
 		println(t.Type)
 	case "Enumeration":
-		schemaForEnum(&s, t)
+		pl.schemaForEnum(&s, t)
 	case "Function":
 		println("TODO")
 	case "API Collection":
-		schemaForAPICollection(&s, t)
+		pl.schemaForAPICollection(&s, t)
 	default:
 		fatal(fmt.Errorf("schema not supported for %q", t.Type))
 	}
@@ -38,12 +45,133 @@ func PullSchema(l Lookup) Schema {
 	return s
 }
 
+// puller pulls the schemas of topics in a language, objc or swift.
+type puller struct {
+	lang string
+	enc  *Encoding
+}
+
+// parseDeclaration parses a declaration of a topic and sets the type
+// encodings of Objective-C methods and properties.
+func (pl *puller) parseDeclaration(decl string, hint declparse.Hint) (declaration, error) {
+	d, err := parseDeclaration(pl.lang, decl, hint)
+	if err != nil || pl.enc == nil || pl.lang == "swift" {
+		return d, err
+	}
+	if d.Method != nil {
+		pl.enc.encodeMethod(d.Method)
+	}
+	if d.Property != nil {
+		pl.enc.encodeProperty(d.Property)
+	}
+	return d, nil
+}
+
+// declaration is a declaration converted to schema types. Which fields are
+// set depends on what it declares. Swift functions and variables are
+// declared the same way as methods and properties, so they set both.
+type declaration struct {
+	Method   *Method
+	Property *Property
+	Func     *Func
+	Variable *Variable
+	Enum     *Enum
+	Struct   *Struct
+	Class    *Class    // of an @interface or @protocol, with no members
+	Type     *DataType // of a type alias
+
+	SwiftName string // of a type declaration
+}
+
+// parseDeclaration parses a declaration in lang, objc or swift. The hint
+// is for Objective-C declarations that can't be told apart by syntax.
+func parseDeclaration(lang, decl string, hint declparse.Hint) (d declaration, err error) {
+	if lang == "swift" {
+		return parseSwiftDeclaration(decl)
+	}
+
+	p := declparse.NewStringParser(decl)
+	p.Hint = hint
+	ast, err := p.Parse()
+	if err != nil {
+		return d, err
+	}
+	// Declarations in the documentation come from audited headers, in
+	// assume_nonnull regions, and only spell out nullability where a
+	// pointer may be nil. The region isn't part of the declaration.
+	assumeNonnull := true
+	switch {
+	case ast.Method != nil:
+		m := MethodFromAst(*ast.Method, assumeNonnull)
+		d.Method = &m
+	case ast.Property != nil:
+		p := PropertyFromAst(*ast.Property, assumeNonnull)
+		d.Property = &p
+	case ast.Function != nil:
+		d.Func = FuncFromAst(ast.Function, assumeNonnull)
+	case ast.Variable != nil:
+		v := VariableFromAst(*ast.Variable, assumeNonnull)
+		d.Variable = &v
+	case ast.Enum != nil:
+		en := EnumFromAst(*ast.Enum)
+		en.SwiftName = ast.SwiftName
+		d.Enum = &en
+	case ast.Struct != nil:
+		st := StructFromAst(*ast.Struct, assumeNonnull)
+		st.SwiftName = ast.SwiftName
+		d.Struct = &st
+	case ast.Interface != nil:
+		c := ClassFromAst(*ast.Interface)
+		d.Class = &c
+	case ast.Protocol != nil:
+		c := ProtocolFromAst(*ast.Protocol)
+		d.Class = &c
+	case ast.TypeAlias != nil:
+		dt := DataTypeFromAst(*ast.TypeAlias)
+		dt.resolveNullability(assumeNonnull)
+		d.Type = &dt
+	}
+	d.SwiftName = ast.SwiftName
+	return d, nil
+}
+
+func parseSwiftDeclaration(decl string) (d declaration, err error) {
+	stmt, err := swiftparse.NewStringParser(decl).Parse()
+	if err != nil {
+		return d, err
+	}
+	switch {
+	case stmt.Func != nil:
+		m := MethodFromSwift(*stmt.Func)
+		d.Method = &m
+		d.Func = FuncFromSwift(stmt.Func)
+	case stmt.Var != nil:
+		p := PropertyFromSwift(*stmt)
+		v := VariableFromSwift(*stmt.Var)
+		d.Property, d.Variable = &p, &v
+	case len(stmt.Cases) > 0:
+		v := CaseFromSwift(stmt.Cases[0])
+		d.Variable = &v
+	case stmt.Type != nil && stmt.Type.Kind == "enum":
+		en := EnumFromSwift(*stmt.Type)
+		d.Enum = &en
+	case stmt.Type != nil && stmt.Type.Kind == "struct":
+		st := StructFromSwift(*stmt.Type)
+		d.Struct = &st
+	case stmt.TypeAlias != nil:
+		dt := DataTypeFromSwift(stmt.TypeAlias.Type)
+		d.Type = &dt
+	}
+	return d, nil
+}
+
 func identifierFromTopic(t Topic) (id Identifier) {
 	id.Declaration = t.Declaration
 	id.Name = t.Title
 	id.Description = t.Description
 	id.Frameworks = t.Frameworks
 	id.TopicURL = BaseURL + strings.Replace(t.Path, "/documentation/", "", 1)
+	id.Availability = ParseAvailability(t.Platforms)
 	for _, p := range t.Platforms {
 		if p == "Deprecated" {
 			id.Deprecated = true
@@ -54,59 +182,62 @@ func identifierFromTopic(t Topic) (id Identifier) {
 	return
 }
 
-func schemaForEnum(s *Schema, t Topic) {
+func (pl *puller) schemaForEnum(s *Schema, t Topic) {
 	s.Kind = "enum"
 
 	id := identifierFromTopic(t)
 
 	var en Enum
 	if t.Declaration != "" {
-		p := declparse.NewStringParser(t.Declaration)
-		ast, err := p.Parse()
+		d, err := pl.parseDeclaration(t.Declaration, declparse.HintNone)
 		if err != nil {
 			fatal(fmt.Errorf("%s: %w [%s]", id.TopicURL, err, t.Declaration))
 		}
-		en = EnumFromAst(*ast.Enum)
+		if d.Enum != nil {
+			en = *d.Enum
+		}
 	}
 	en.Identifier = id
 
 	for _, topic := range t.Topics {
 		t, err := ReadTopic(LookupFromPath(topic.Path))
 		fatal(err)
-		if t.Type != "Enumeration Case" {
+		if t.Type != "Enumeration Case" && t.Type != "Case" {
 			continue
 		}
 		id := identifierFromTopic(t)
 		var ecase Variable
 		if t.Declaration != "" {
-			p := declparse.NewStringParser(t.Declaration)
-			p.Hint = declparse.HintEnumCase
-			ast, err := p.Parse()
+			d, err := pl.parseDeclaration(t.Declaration, declparse.HintEnumCase)
 			if err != nil {
 				fatal(fmt.Errorf("%s: %w [%s]", id.TopicURL, err, t.Declaration))
 			}
-			ecase = VariableFromAst(*ast.Variable)
+			if d.Variable != nil {
+				ecase = *d.Variable
+			}
 		}
 		ecase.Identifier = id
 		en.Cases = append(en.Cases, ecase)
 	}
+	evalConsts(en.Cases, false)
 
 	s.Enum = &en
 }
 
-func schemaForStruct(s *Schema, t Topic) {
+func (pl *puller) schemaForStruct(s *Schema, t Topic) {
 	s.Kind = "struct"
 
 	id := identifierFromTopic(t)
 
 	var st Struct
 	if t.Declaration != "" {
-		p := declparse.NewStringParser(t.Declaration)
-		ast, err := p.Parse()
+		d, err := pl.parseDeclaration(t.Declaration, declparse.HintNone)
 		if err != nil {
 			fatal(fmt.Errorf("%s: %w [%s]", id.TopicURL, err, t.Declaration))
 		}
-		st = StructFromAst(*ast.Struct)
+		if d.Struct != nil {
+			st = *d.Struct
+		}
 	}
 	st.Identifier = id
 
@@ -119,13 +250,13 @@ func schemaForStruct(s *Schema, t Topic) {
 		id := identifierFromTopic(t)
 		var prop Variable
 		if t.Declaration != "" {
-			p := declparse.NewStringParser(t.Declaration)
-			p.Hint = declparse.HintVariable
-			ast, err := p.Parse()
+			d, err := pl.parseDeclaration(t.Declaration, declparse.HintVariable)
 			if err != nil {
 				fatal(fmt.Errorf("%s: %w [%s]", id.TopicURL, err, t.Declaration))
 			}
-			prop = VariableFromAst(*ast.Variable)
+			if d.Variable != nil {
+				prop = *d.Variable
+			}
 		}
 		prop.Identifier = id
 		st.Fields = append(st.Fields, prop)
@@ -134,18 +265,20 @@ func schemaForStruct(s *Schema, t Topic) {
 	s.Struct = &st
 }
 
-func schemaForTypeAlias(s *Schema, t Topic) {
+func (pl *puller) schemaForTypeAlias(s *Schema, t Topic) {
 	s.Kind = "typealias"
 
 	var ta TypeAlias
 	ta.Identifier = identifierFromTopic(t)
 	if t.Declaration != "" {
-		p := declparse.NewStringParser(t.Declaration)
-		ast, err := p.Parse()
+		d, err := pl.parseDeclaration(t.Declaration, declparse.HintNone)
 		if err != nil {
 			fatal(fmt.Errorf("%s: %w [%s]", ta.TopicURL, err, t.Declaration))
 		}
-		ta.Type = DataTypeFromAst(*ast.TypeAlias)
+		if d.Type != nil {
+			ta.Type = *d.Type
+		}
+		ta.SwiftName = d.SwiftName
 	}
 
 	for _, topic := range t.Topics {
@@ -157,13 +290,13 @@ func schemaForTypeAlias(s *Schema, t Topic) {
 		id := identifierFromTopic(t)
 		var val Variable
 		if t.Declaration != "" {
-			p := declparse.NewStringParser(t.Declaration)
-			p.Hint = declparse.HintVariable
-			ast, err := p.Parse()
+			d, err := pl.parseDeclaration(t.Declaration, declparse.HintVariable)
 			if err != nil {
 				fatal(fmt.Errorf("%s: %w [%s]", id.TopicURL, err, t.Declaration))
 			}
-			val = VariableFromAst(*ast.Variable)
+			if d.Variable != nil {
+				val = *d.Variable
+			}
 		}
 		val.Identifier = id
 		if val.Type.Name == ta.Name {
@@ -174,10 +307,27 @@ func schemaForTypeAlias(s *Schema, t Topic) {
 	s.TypeAlias = &ta
 }
 
-func schemaForClass(s *Schema, t Topic) {
+func (pl *puller) schemaForClass(s *Schema, t Topic) {
 	s.Kind = "class"
+	c := pl.classFromTopic(t)
+	s.Class = &c
+}
+
+func (pl *puller) schemaForProtocol(s *Schema, t Topic) {
+	s.Kind = "protocol"
+	c := pl.classFromTopic(t)
+	s.Protocol = &c
+}
 
-	var c Class
+// classFromTopic returns the class or protocol of a topic with the
+// members of its sub-topics. The superclass and protocols come from its
+// declaration, when it parses.
+func (pl *puller) classFromTopic(t Topic) (c Class) {
+	if t.Declaration != "" {
+		if d, err := pl.parseDeclaration(t.Declaration, declparse.HintNone); err == nil && d.Class != nil {
+			c = *d.Class
+		}
+	}
 	c.Identifier = identifierFromTopic(t)
 	for _, topic := range t.Topics {
 		t, err := ReadTopic(LookupFromPath(topic.Path))
@@ -186,6 +336,7 @@ func schemaForClass(s *Schema, t Topic) {
 			t.Type == "Enumeration" ||
 			t.Type == "Global Variable" ||
 			t.Type == "Enumeration Case" ||
+			t.Type == "Case" ||
 			t.Type == "Macro" {
 			continue
 		}
@@ -196,50 +347,52 @@ func schemaForClass(s *Schema, t Topic) {
 			}
 		}
 		if t.Declaration != "" {
-			p := declparse.NewStringParser(t.Declaration)
-			ast, err := p.Parse()
+			d, err := pl.parseDeclaration(t.Declaration, declparse.HintNone)
 			if err != nil {
 				fatal(fmt.Errorf("%s: %w [%s]", topic.Path, err, t.Declaration))
 			}
 			url := BaseURL + strings.Replace(t.Path, "/documentation/", "", 1)
-			switch t.Type {
-			case "Type Method":
-				m := MethodFromAst(*ast.Method)
+			switch {
+			case t.Type == "Type Method" && d.Method != nil:
+				m := *d.Method
 				m.Description = t.Description
 				m.Declaration = t.Declaration
 				m.TopicURL = url
 				m.Deprecated = isDeprecated
+				m.Availability = ParseAvailability(t.Platforms)
 				c.TypeMethods = append(c.TypeMethods, m)
-			case "Instance Method":
-				m := MethodFromAst(*ast.Method)
+			case (t.Type == "Instance Method" || t.Type == "Initializer") && d.Method != nil:
+				m := *d.Method
 				m.Description = t.Description
 				m.Declaration = t.Declaration
 				m.TopicURL = url
 				m.Deprecated = isDeprecated
+				m.Availability = ParseAvailability(t.Platforms)
 				c.InstanceMethods = append(c.InstanceMethods, m)
-			case "Type Property":
-				p := PropertyFromAst(*ast.Property)
+			case t.Type == "Type Property" && d.Property != nil:
+				p := *d.Property
 				p.Description = t.Description
 				p.Declaration = t.Declaration
 				p.TopicURL = url
 				p.Deprecated = isDeprecated
+				p.Availability = ParseAvailability(t.Platforms)
 				c.TypeProperties = append(c.TypeProperties, p)
-			case "Instance Property":
-				p := PropertyFromAst(*ast.Property)
+			case t.Type == "Instance Property" && d.Property != nil:
+				p := *d.Property
 				p.Description = t.Description
 				p.Declaration = t.Declaration
 				p.TopicURL = url
 				p.Deprecated = isDeprecated
+				p.Availability = ParseAvailability(t.Platforms)
 				c.InstanceProperties = append(c.InstanceProperties, p)
 			default:
 			}
 		}
 	}
-
-	s.Class = &c
+	return c
 }
 
-func schemaForAPICollection(s *Schema, t Topic) {
+func (pl *puller) schemaForAPICollection(s *Schema, t Topic) {
 	s.Kind = "apicollection"
 
 	var ac APICollection
@@ -256,26 +409,23 @@ func schemaForAPICollection(s *Schema, t Topic) {
 			}
 		}
 		if t.Declaration != "" {
-			p := declparse.NewStringParser(t.Declaration)
-
-			if t.Type == "Function" {
-				p.Hint = declparse.HintFunction
-			} else {
+			if t.Type != "Function" {
 				panic(t.Type)
 			}
 
-			ast, err := p.Parse()
+			d, err := pl.parseDeclaration(t.Declaration, declparse.HintFunction)
 			if err != nil {
 				fatal(fmt.Errorf("%s: %w [%s]", topic.Path, err, t.Declaration))
 			}
 			url := BaseURL + strings.Replace(t.Path, "/documentation/", "", 1)
-			switch t.Type {
-			case "Function":
-				m := FuncFromAst(ast.Function)
+			switch {
+			case t.Type == "Function" && d.Func != nil:
+				m := d.Func
 				m.Description = t.Description
 				m.Declaration = t.Declaration
 				m.TopicURL = url
 				m.Deprecated = isDeprecated
+				m.Availability = ParseAvailability(t.Platforms)
 				ac.Functions = append(ac.Functions, *m)
 			default:
 				log.Println("unknown type", t.Type)
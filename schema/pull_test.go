@@ -0,0 +1,63 @@
+package schema
+
+import (
+	"os"
+	"testing"
+)
+
+// chdir changes to dir, like testdata with its doc dir, until the test ends.
+func chdir(t *testing.T, dir string) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func TestPullSchema_Nullability(t *testing.T) {
+	chdir(t, "testdata")
+	s := PullSchema(lookupPath("appkit/nswindow", "objc"), nil)
+	if s.Class == nil {
+		t.Fatalf("no class: %+v", s)
+	}
+
+	got := make(map[string]Nullability)
+	for _, m := range s.Class.InstanceMethods {
+		got[m.Name] = m.Return.Nullability
+		for _, arg := range m.Args {
+			got[m.Name+" "+arg.Name] = arg.Type.Nullability
+		}
+	}
+	for _, p := range s.Class.InstanceProperties {
+		got[p.Name] = p.Type.Nullability
+	}
+	for name, want := range map[string]Nullability{
+		"initWithContentRect:styleMask:backing:defer:": NullabilityNonnull,
+		"title":    NullabilityNonnull,
+		"delegate": NullabilityNullable,
+		"beginSheet:completionHandler: sheetWindow": NullabilityNonnull,
+		"beginSheet:completionHandler: handler":     NullabilityNonnull,
+	} {
+		if got[name] != want {
+			t.Errorf("%s: exp=%q got=%q", name, want, got[name])
+		}
+	}
+}
+
+func TestPullSchema_Encoding(t *testing.T) {
+	chdir(t, "testdata")
+	s := PullSchema(lookupPath("appkit/nswindow", "objc"), NewEncoding("x86_64"))
+	for _, m := range s.Class.InstanceMethods {
+		if m.Name != "beginSheet:completionHandler:" {
+			continue
+		}
+		if m.TypeEncoding != "v@:@@?" || m.MsgSend != "objc_msgSend" {
+			t.Errorf("%s: encoding=%s msgSend=%s", m.Name, m.TypeEncoding, m.MsgSend)
+		}
+		return
+	}
+	t.Error("beginSheet:completionHandler: not pulled")
+}
@@ -0,0 +1,246 @@
+package schema
+
+import (
+	"strings"
+
+	"github.com/progrium/macschema/swiftparse"
+)
+
+// DataTypeFromSwift converts a Swift type. Optionals are their wrapped type
+// with nullable Nullability, and implicitly unwrapped optionals unspecified.
+// Arrays and dictionaries are named Array and Dictionary with their element
+// types in Params, and function types are blocks. Specifiers and attributes
+// other than @escaping are annotations, as in inout and sendable.
+func DataTypeFromSwift(t swiftparse.Type) (dt DataType) {
+	switch t.Kind {
+	case swiftparse.TypeOptional, swiftparse.TypeUnwrapped:
+		dt = DataTypeFromSwift(*t.Elem)
+		if t.Kind == swiftparse.TypeOptional {
+			dt.Nullability = NullabilityNullable
+		} else {
+			dt.Nullability = NullabilityUnspecified
+		}
+	case swiftparse.TypeArray:
+		dt = DataType{Name: "Array", Params: []DataType{DataTypeFromSwift(*t.Elem)}}
+	case swiftparse.TypeDictionary:
+		dt = DataType{Name: "Dictionary", Params: []DataType{DataTypeFromSwift(*t.Key), DataTypeFromSwift(*t.Elem)}}
+	case swiftparse.TypeTuple:
+		dt = DataType{Kind: "tuple"}
+		for _, arg := range t.Args {
+			dt.Params = append(dt.Params, DataTypeFromSwift(arg.Type))
+		}
+	case swiftparse.TypeFunction:
+		fn := &Func{
+			Return:   DataTypeFromSwift(*t.Elem),
+			Args:     argsFromSwift(t.Args),
+			Escaping: t.HasAttr("escaping"),
+			Throws:   t.Throws,
+			Async:    t.Async,
+		}
+		dt = DataType{Block: fn}
+	case swiftparse.TypeMetatype, swiftparse.TypeComposition:
+		bare := t
+		bare.Attrs, bare.Specifier = nil, ""
+		dt = DataType{Name: bare.String()}
+	default:
+		dt = DataType{Name: t.Name}
+		for _, param := range t.Params {
+			dt.Params = append(dt.Params, DataTypeFromSwift(param))
+		}
+	}
+	var annots []string
+	if t.Specifier != "" {
+		annots = append(annots, t.Specifier)
+	}
+	for _, attr := range t.Attrs {
+		if attr.Name != "escaping" {
+			annots = append(annots, strings.ToLower(attr.Name))
+		}
+	}
+	dt.Annotations = append(annots, dt.Annotations...)
+	return dt
+}
+
+func argsFromSwift(params []swiftparse.Param) (args []Arg) {
+	for _, param := range params {
+		arg := Arg{Name: param.Name, Type: DataTypeFromSwift(param.Type)}
+		if param.Variadic {
+			arg.Type.Annotations = append(arg.Type.Annotations, "variadic")
+		}
+		args = append(args, arg)
+	}
+	return args
+}
+
+// resultFromSwift returns the result type of a function. Initializers
+// return Self, which is nullable for init? and unspecified for init!.
+func resultFromSwift(fn swiftparse.FuncDecl) DataType {
+	switch {
+	case fn.Name == "init":
+		dt := DataType{Name: "Self"}
+		switch fn.Failable {
+		case "?":
+			dt.Nullability = NullabilityNullable
+		case "!":
+			dt.Nullability = NullabilityUnspecified
+		}
+		return dt
+	case fn.Result == nil:
+		return DataType{Name: "Void"}
+	}
+	return DataTypeFromSwift(*fn.Result)
+}
+
+// FuncFromSwift converts a function. Its name includes the argument labels,
+// as in NSApplicationMain(_:_:).
+func FuncFromSwift(fn *swiftparse.FuncDecl) *Func {
+	return &Func{
+		Identifier: Identifier{Name: fn.FullName()},
+		Return:     resultFromSwift(*fn),
+		Args:       argsFromSwift(fn.Params),
+		Throws:     fn.Throws || fn.Rethrows,
+		Async:      fn.Async,
+	}
+}
+
+// MethodFromSwift converts a method, initializer or subscript. Its name
+// includes the argument labels, as in orderFront(_:).
+func MethodFromSwift(fn swiftparse.FuncDecl) Method {
+	return Method{
+		Name:   fn.FullName(),
+		Return: resultFromSwift(fn),
+		Args:   argsFromSwift(fn.Params),
+		Throws: fn.Throws || fn.Rethrows,
+		Async:  fn.Async,
+	}
+}
+
+// PropertyFromSwift converts a statement declaring a property. Its
+// modifiers and attributes map to the attributes of an Objective-C
+// property: let and get-only properties are readonly, class and static
+// ones class, weak and unowned ones weak and assign, and @NSCopying
+// ones copy.
+func PropertyFromSwift(stmt swiftparse.Statement) Property {
+	v := stmt.Var
+	prop := Property{Name: v.Name}
+	if v.Type != nil {
+		prop.Type = DataTypeFromSwift(*v.Type)
+	}
+	_, prop.IsOutlet = stmt.Attr("IBOutlet")
+
+	attrs := make(map[string]interface{})
+	if v.Let || isReadonly(v.Accessors) {
+		attrs["readonly"] = true
+	}
+	if stmt.HasModifier("class") || stmt.HasModifier("static") {
+		attrs["class"] = true
+	}
+	if stmt.HasModifier("weak") {
+		attrs["weak"] = true
+	}
+	for _, m := range stmt.Modifiers {
+		if strings.HasPrefix(m, "unowned") {
+			attrs["assign"] = true
+		}
+	}
+	if _, ok := stmt.Attr("NSCopying"); ok {
+		attrs["copy"] = true
+	}
+	prop.Attrs = attrs
+	return prop
+}
+
+// isReadonly returns true for the accessors of a property that can't be
+// set, which have a getter but no setter.
+func isReadonly(accessors []string) bool {
+	var get, set bool
+	for _, a := range accessors {
+		for _, w := range strings.Fields(a) {
+			switch w {
+			case "get", "_read", "read":
+				get = true
+			case "set", "_modify", "modify":
+				set = true
+			}
+		}
+	}
+	return get && !set
+}
+
+// VariableFromSwift converts a variable or constant.
+func VariableFromSwift(v swiftparse.VarDecl) Variable {
+	vv := Variable{
+		Identifier: Identifier{Name: v.Name},
+		Value:      v.Value,
+	}
+	if v.Type != nil {
+		vv.Type = DataTypeFromSwift(*v.Type)
+	}
+	vv.eval(nil)
+	return vv
+}
+
+// CaseFromSwift converts an enum case. Associated values are the Params of
+// its tuple type.
+func CaseFromSwift(c swiftparse.CaseDecl) Variable {
+	v := Variable{
+		Identifier: Identifier{Name: c.Name},
+		Value:      c.Value,
+	}
+	if c.Params != nil {
+		v.Type = DataTypeFromSwift(swiftparse.Type{Kind: swiftparse.TypeTuple, Args: c.Params})
+	}
+	v.eval(nil)
+	return v
+}
+
+// EnumFromSwift converts an enum and the cases in its body. Its Type is the
+// raw type, the first one it inherits. Cases of integer enums without a
+// value are one more than the case before them.
+func EnumFromSwift(t swiftparse.TypeDecl) Enum {
+	en := Enum{Identifier: Identifier{Name: t.Name}}
+	if len(t.Inherits) > 0 {
+		en.Type = DataTypeFromSwift(t.Inherits[0])
+	}
+	for _, member := range t.Members {
+		for _, c := range member.Cases {
+			en.Cases = append(en.Cases, CaseFromSwift(c))
+		}
+	}
+	evalConsts(en.Cases, isIntType(en.Type.Name))
+	return en
+}
+
+// isIntType returns true for the names of Swift integer types.
+func isIntType(name string) bool {
+	switch strings.TrimPrefix(name, "U") {
+	case "Int", "Int8", "Int16", "Int32", "Int64":
+		return true
+	}
+	return false
+}
+
+// StructFromSwift converts a struct. Its fields are the stored instance
+// properties in its body.
+func StructFromSwift(t swiftparse.TypeDecl) Struct {
+	st := Struct{Identifier: Identifier{Name: t.Name}}
+	for _, member := range t.Members {
+		v := member.Var
+		if v == nil || member.HasModifier("static") || isComputed(v.Accessors) {
+			continue
+		}
+		st.Fields = append(st.Fields, VariableFromSwift(*v))
+	}
+	return st
+}
+
+// isComputed returns true for the accessors of a computed property, which
+// are anything other than observers.
+func isComputed(accessors []string) bool {
+	for _, a := range accessors {
+		if a != "willSet" && a != "didSet" {
+			return true
+		}
+	}
+	return false
+}
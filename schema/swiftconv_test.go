@@ -0,0 +1,101 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/progrium/macschema/swiftparse"
+)
+
+func parseSwift(t *testing.T, decl string) *swiftparse.Statement {
+	t.Helper()
+	stmt, err := swiftparse.NewStringParser(decl).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stmt
+}
+
+func TestMethodFromSwift(t *testing.T) {
+	stmt := parseSwift(t, `func beginSheet(_ sheet: NSWindow, completionHandler handler: (@escaping (NSApplication.ModalResponse) -> Void)? = nil) async throws -> [String: Any]`)
+	m := MethodFromSwift(*stmt.Func)
+	if m.Name != "beginSheet(_:completionHandler:)" || !m.Async || !m.Throws {
+		t.Errorf("unexpected method: %+v", m)
+	}
+	if len(m.Args) != 2 || m.Args[0].Name != "sheet" || m.Args[1].Name != "handler" {
+		t.Fatalf("unexpected args: %+v", m.Args)
+	}
+	handler := m.Args[1].Type
+	if handler.Block == nil || !handler.Block.Escaping || handler.Nullability != NullabilityNullable {
+		t.Errorf("unexpected handler: %+v", handler)
+	} else if arg := handler.Block.Args[0].Type; arg.Name != "NSApplication.ModalResponse" {
+		t.Errorf("unexpected handler arg: %+v", arg)
+	}
+	if r := m.Return; r.Name != "Dictionary" || len(r.Params) != 2 || r.Params[0].Name != "String" || r.Params[1].Name != "Any" {
+		t.Errorf("unexpected return: %+v", r)
+	}
+
+	stmt = parseSwift(t, `init?(contentsOf url: URL)`)
+	m = MethodFromSwift(*stmt.Func)
+	if m.Name != "init(contentsOf:)" || m.Return.Name != "Self" || m.Return.Nullability != NullabilityNullable {
+		t.Errorf("unexpected init: %+v", m)
+	}
+}
+
+func TestPropertyFromSwift(t *testing.T) {
+	tests := []struct {
+		decl  string
+		attrs []string
+	}{
+		{`var title: String { get set }`, nil},
+		{`class var shared: NSApplication { get }`, []string{"class", "readonly"}},
+		{`weak var delegate: NSWindowDelegate?`, []string{"weak"}},
+		{`@NSCopying let frame: NSRect`, []string{"copy", "readonly"}},
+		{`var isVisible: Bool { get async throws }`, []string{"readonly"}},
+	}
+	for _, tt := range tests {
+		p := PropertyFromSwift(*parseSwift(t, tt.decl))
+		if len(p.Attrs) != len(tt.attrs) {
+			t.Errorf("%s: unexpected attrs: %v", tt.decl, p.Attrs)
+		}
+		for _, attr := range tt.attrs {
+			if p.Attrs[attr] != true {
+				t.Errorf("%s: missing %s in %v", tt.decl, attr, p.Attrs)
+			}
+		}
+	}
+}
+
+func TestEnumFromSwift(t *testing.T) {
+	stmt := parseSwift(t, `enum Level : Int {
+		case low = 1, medium
+		case high = 0x10
+		case max
+	}`)
+	en := EnumFromSwift(*stmt.Type)
+	if en.Type.Name != "Int" {
+		t.Errorf("unexpected type: %+v", en.Type)
+	}
+	want := map[string]string{"low": "1", "medium": "2", "high": "16", "max": "17"}
+	for _, c := range en.Cases {
+		got := ""
+		if c.IntValue != nil {
+			got = c.IntValue.String()
+		}
+		if got != want[c.Name] {
+			t.Errorf("%s: exp=%q got=%q", c.Name, want[c.Name], got)
+		}
+	}
+}
+
+func TestStructFromSwift(t *testing.T) {
+	stmt := parseSwift(t, `struct Point {
+		var x: CGFloat
+		var y: CGFloat { didSet {} }
+		var length: CGFloat { x + y }
+		static let zero: Point
+	}`)
+	st := StructFromSwift(*stmt.Type)
+	if len(st.Fields) != 2 || st.Fields[0].Name != "x" || st.Fields[1].Name != "y" {
+		t.Errorf("unexpected fields: %+v", st.Fields)
+	}
+}
@@ -0,0 +1,337 @@
+package schema
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed typemaps/*.yaml
+var typemaps embed.FS
+
+// TypeMap maps C and Objective-C types to the types of a target language.
+// It is declarative, so it can be loaded from YAML or JSON: Types gives
+// the target types of type names, and of pointers like void * and
+// const char *, and the patterns the target types of objects, pointers,
+// arrays and blocks. Patterns have placeholders in braces for the parts
+// of the type, as in *{elem}. Typedefs are resolved through Aliases and
+// the typedefs of schemas added to the map, as many levels as needed.
+type TypeMap struct {
+	Types   map[string]string `json:"types,omitempty" yaml:"types,omitempty"`
+	Aliases map[string]string `json:"aliases,omitempty" yaml:"aliases,omitempty"`
+
+	Object    string `json:"object,omitempty" yaml:"object,omitempty"`       // pointers to objects: {name}
+	Protocols string `json:"protocols,omitempty" yaml:"protocols,omitempty"` // id with protocols: {protocols}
+	Generic   string `json:"generic,omitempty" yaml:"generic,omitempty"`     // classes with type arguments: {type} {params}
+	Pointer   string `json:"pointer,omitempty" yaml:"pointer,omitempty"`     // {elem}
+	Array     string `json:"array,omitempty" yaml:"array,omitempty"`         // {elem} {len}
+	Block     string `json:"block,omitempty" yaml:"block,omitempty"`         // {params} {result}
+	Function  string `json:"function,omitempty" yaml:"function,omitempty"`   // function pointers: {params} {result}
+	Param     string `json:"param,omitempty" yaml:"param,omitempty"`         // of blocks and functions: {name} {type}
+	Self      string `json:"self,omitempty" yaml:"self,omitempty"`           // instancetype: {class}
+	Nullable  string `json:"nullable,omitempty" yaml:"nullable,omitempty"`   // nullable pointers: {type}
+
+	typedefs map[string]DataType
+	declared map[string]bool // enums and structs
+	classes  map[string]bool
+}
+
+// maxTypedefs limits how many typedefs are resolved for a type, which
+// stops cycles.
+const maxTypedefs = 32
+
+// UnmappedError is returned for types the TypeMap has no target type for.
+type UnmappedError struct {
+	Name string
+}
+
+func (e *UnmappedError) Error() string {
+	return fmt.Sprintf("no target type for %q", e.Name)
+}
+
+// DefaultTypeMap returns the built-in TypeMap for a target language: go,
+// rust or ts.
+func DefaultTypeMap(lang string) (*TypeMap, error) {
+	b, err := typemaps.ReadFile("typemaps/" + lang + ".yaml")
+	if err != nil {
+		return nil, fmt.Errorf("no type map for %q", lang)
+	}
+	m := &TypeMap{}
+	if err := yaml.Unmarshal(b, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Load reads a TypeMap in YAML or JSON from a file into m. Types and
+// patterns in the file take precedence over those already in m.
+func (m *TypeMap) Load(path string) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := yaml.Unmarshal(b, m); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// AddSchema adds the type a schema declares: a typedef, which is
+// resolved to the type it names, or an enum, struct or class, which map
+// to their name unless they are in Types.
+func (m *TypeMap) AddSchema(s Schema) {
+	m.init()
+	switch {
+	case s.TypeAlias != nil:
+		m.typedefs[s.TypeAlias.Name] = s.TypeAlias.Type
+	case s.Enum != nil:
+		m.declared[s.Enum.Name] = true
+	case s.Struct != nil:
+		m.declared[s.Struct.Name] = true
+	case s.Class != nil:
+		m.classes[s.Class.Name] = true
+	case s.APICollection != nil:
+		for _, en := range s.APICollection.Enums {
+			m.declared[en.Name] = true
+		}
+	}
+}
+
+// AddSchemas adds the types declared by the Objective-C schemas in a
+// directory, like api.
+func (m *TypeMap) AddSchemas(dir string) error {
+	schemas, err := ReadSchemas(dir)
+	if err != nil {
+		return err
+	}
+	for _, s := range schemas {
+		m.AddSchema(s)
+	}
+	return nil
+}
+
+func (m *TypeMap) init() {
+	if m.typedefs == nil {
+		m.typedefs = make(map[string]DataType)
+		m.declared = make(map[string]bool)
+		m.classes = make(map[string]bool)
+	}
+}
+
+// Resolve returns the type a typedef names, from Aliases, the typedefs
+// added to the map or the common Foundation and Core Graphics typedefs.
+func (m *TypeMap) Resolve(name string) (DataType, bool) {
+	if alias, ok := m.Aliases[name]; ok {
+		return DataType{Name: alias}, true
+	}
+	if dt, ok := m.typedefs[name]; ok {
+		return dt, true
+	}
+	if alias, ok := typeAliases[name]; ok {
+		return DataType{Name: alias}, true
+	}
+	if alias, ok := structAliases[name]; ok {
+		return DataType{Name: alias}, true
+	}
+	return DataType{}, false
+}
+
+// IsObject returns true for the name of a type pointers to which are
+// objects: a class, or any type that isn't a primitive, typedef, enum or
+// struct.
+func (m *TypeMap) IsObject(name string) bool {
+	if m.classes[name] {
+		return true
+	}
+	if _, ok := primitiveEncodings[name]; ok || name == "BOOL" {
+		return false
+	}
+	if _, ok := m.Resolve(name); ok {
+		return false
+	}
+	_, ok := structLayouts[name]
+	return !ok && !m.declared[name]
+}
+
+// Map returns the target type of dt.
+func (m *TypeMap) Map(dt DataType) (string, error) {
+	return m.mapType(dt, "", 0)
+}
+
+// MapSelf returns the target type of dt in the methods of class, where
+// instancetype is the class.
+func (m *TypeMap) MapSelf(dt DataType, class string) (string, error) {
+	return m.mapType(dt, class, 0)
+}
+
+// Unmapped returns the names of the types used by schemas that have no
+// target type.
+func (m *TypeMap) Unmapped(schemas []Schema) []string {
+	uniq := make(map[string]bool)
+	for _, dt := range CollectTypes(schemas) {
+		var unmapped *UnmappedError
+		if _, err := m.Map(dt); errors.As(err, &unmapped) {
+			uniq[unmapped.Name] = true
+		}
+	}
+	var names []string
+	for name := range uniq {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (m *TypeMap) mapType(dt DataType, class string, depth int) (t string, err error) {
+	if depth > maxTypedefs {
+		return "", fmt.Errorf("too many typedefs resolving %q", dt.Name)
+	}
+	switch {
+	case dt.Kind == "pointer":
+		t, err = m.mapPointer(*dt.Elem, class, depth)
+		if err == nil && dt.Nullability == NullabilityNullable && m.Nullable != "" {
+			t = expand(m.Nullable, "type", t)
+		}
+		return t, err
+	case dt.Kind == "array":
+		elem, err := m.mapType(*dt.Elem, class, depth)
+		if err != nil {
+			return "", err
+		}
+		if dt.Len == "" {
+			return expand(m.Pointer, "elem", elem), nil
+		}
+		return expand(m.Array, "elem", elem, "len", dt.Len), nil
+	case dt.Kind != "":
+		return "", &UnmappedError{Name: dt.Kind}
+	case dt.Block != nil:
+		return m.mapFunc(m.Block, *dt.Block, depth)
+	case dt.FuncPtr != nil:
+		return m.mapFunc(m.Function, *dt.FuncPtr, depth)
+	}
+
+	switch {
+	case dt.Name == "instancetype" && class != "" && m.Self != "":
+		return expand(m.Self, "class", m.className(class)), nil
+	case dt.Name == "id" && len(dt.Params) > 0 && m.Protocols != "":
+		var protocols []string
+		for _, p := range dt.Params {
+			protocols = append(protocols, p.Name)
+		}
+		return expand(m.Protocols, "protocols", strings.Join(protocols, " & ")), nil
+	}
+	if t, ok := m.Types[dt.CName()]; ok {
+		return t, nil
+	}
+	if alias, ok := m.Resolve(dt.Name); ok {
+		return m.mapType(alias, class, depth+1)
+	}
+	if m.declared[dt.Name] || structLayouts[dt.Name] != nil {
+		return dt.Name, nil
+	}
+	return "", &UnmappedError{Name: dt.Name}
+}
+
+func (m *TypeMap) mapPointer(elem DataType, class string, depth int) (string, error) {
+	if elem.Kind == "" && elem.Block == nil && elem.FuncPtr == nil {
+		if hasAnnot(elem, "const") {
+			if t, ok := m.Types["const "+elem.CName()+" *"]; ok {
+				return t, nil
+			}
+		}
+		if t, ok := m.Types[elem.CName()+" *"]; ok {
+			return t, nil
+		}
+		if m.IsObject(elem.CName()) {
+			return m.mapObject(elem, class, depth)
+		}
+	}
+	t, err := m.mapType(elem, class, depth)
+	if err != nil {
+		return "", err
+	}
+	return expand(m.Pointer, "elem", t), nil
+}
+
+// mapObject returns the target type of a pointer to an object. Classes in
+// Types map to their target type and others to the Object pattern.
+func (m *TypeMap) mapObject(dt DataType, class string, depth int) (string, error) {
+	if t, ok := m.Types[dt.Name]; ok {
+		return t, nil
+	}
+	name, err := m.genericName(dt, class, depth)
+	if err != nil {
+		return "", err
+	}
+	return expand(m.Object, "name", name), nil
+}
+
+// genericName returns the class name of an object type with its type
+// arguments, as in NSArray<NSString>.
+func (m *TypeMap) genericName(dt DataType, class string, depth int) (string, error) {
+	name := m.className(dt.Name)
+	if len(dt.Params) == 0 || m.Generic == "" {
+		return name, nil
+	}
+	var params []string
+	for _, p := range dt.Params {
+		var t string
+		var err error
+		if p.Kind == "pointer" && p.Elem.Kind == "" && m.IsObject(p.Elem.Name) {
+			t, err = m.genericName(*p.Elem, class, depth)
+		} else {
+			t, err = m.mapType(p, class, depth)
+		}
+		if err != nil {
+			return "", err
+		}
+		params = append(params, t)
+	}
+	return expand(m.Generic, "type", name, "params", strings.Join(params, ", ")), nil
+}
+
+func (m *TypeMap) className(name string) string {
+	if t, ok := m.Types[name]; ok {
+		return t
+	}
+	return name
+}
+
+// mapFunc returns the target type of a block or function pointer. Params
+// without a name are named after their position.
+func (m *TypeMap) mapFunc(pattern string, fn Func, depth int) (string, error) {
+	var params []string
+	for i, arg := range fn.Args {
+		if arg.Type.Name == "void" && arg.Type.Kind == "" && len(fn.Args) == 1 {
+			break
+		}
+		t, err := m.mapType(arg.Type, "", depth)
+		if err != nil {
+			return "", err
+		}
+		name := arg.Name
+		if name == "" {
+			name = fmt.Sprintf("arg%d", i)
+		}
+		params = append(params, expand(m.Param, "name", name, "type", t))
+	}
+	result, err := m.mapType(fn.Return, "", depth)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(expand(pattern, "params", strings.Join(params, ", "), "result", result)), nil
+}
+
+// expand fills in the placeholders of a pattern from pairs of names and
+// values.
+func expand(pattern string, pairs ...string) string {
+	for i := 0; i < len(pairs); i += 2 {
+		pairs[i] = "{" + pairs[i] + "}"
+	}
+	return strings.NewReplacer(pairs...).Replace(pattern)
+}
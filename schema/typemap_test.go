@@ -0,0 +1,190 @@
+package schema
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/progrium/macschema/declparse"
+)
+
+// propertyType returns the type of a property declaration.
+func propertyType(t *testing.T, decl string) DataType {
+	t.Helper()
+	ast, err := declparse.NewStringParser(decl).Parse()
+	if err != nil {
+		t.Fatalf("%s: %v", decl, err)
+	}
+	return PropertyFromAst(*ast.Property, false).Type
+}
+
+func TestTypeMap_Map(t *testing.T) {
+	decls := []string{
+		`@property NSInteger a;`,
+		`@property CGFloat a;`,
+		`@property BOOL a;`,
+		`@property id<NSCopying> a;`,
+		`@property (nullable) NSArray<NSString *> *a;`,
+		`@property SEL a;`,
+		`@property NSRect a;`,
+		`@property const void *a;`,
+		`@property const char *a;`,
+		`@property NSError **a;`,
+		`@property CGFloat a[4];`,
+		`@property void (^a)(NSUInteger idx, BOOL *stop);`,
+		`@property MyResponse a;`,
+	}
+	tests := map[string][]string{
+		"go": {
+			"int",
+			"float64",
+			"bool",
+			"Object",
+			"Object",
+			"Selector",
+			"CGRect",
+			"unsafe.Pointer",
+			"*int8",
+			"*Object",
+			"[4]float64",
+			"func(idx uint, stop *bool)",
+			"int",
+		},
+		"rust": {
+			"NSInteger",
+			"CGFloat",
+			"Bool",
+			"*mut ProtocolObject<dyn NSCopying>",
+			"*mut NSArray<NSString>",
+			"Sel",
+			"CGRect",
+			"*const c_void",
+			"*mut c_char",
+			"*mut *mut NSError",
+			"[CGFloat; 4]",
+			"*mut Block<dyn Fn(NSUInteger, *mut Bool) -> ()>",
+			"NSInteger",
+		},
+		"ts": {
+			"number",
+			"number",
+			"boolean",
+			"NSCopying",
+			"NSArray<NSString> | null",
+			"string",
+			"CGRect",
+			"Pointer<void>",
+			"string",
+			"Pointer<NSError>",
+			"number[]",
+			"(idx: number, stop: Pointer<boolean>) => void",
+			"number",
+		},
+	}
+	for lang, want := range tests {
+		m, err := DefaultTypeMap(lang)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// typedefs are resolved through each other
+		m.AddSchema(Schema{TypeAlias: &TypeAlias{Identifier: Identifier{Name: "NSModalResponse"}, Type: DataType{Name: "NSInteger"}}})
+		m.AddSchema(Schema{TypeAlias: &TypeAlias{Identifier: Identifier{Name: "MyResponse"}, Type: DataType{Name: "NSModalResponse"}}})
+		for i, decl := range decls {
+			got, err := m.Map(propertyType(t, decl))
+			if err != nil {
+				t.Errorf("%s %s: %v", lang, decl, err)
+			} else if got != want[i] {
+				t.Errorf("%s %s: exp=%s got=%s", lang, decl, want[i], got)
+			}
+		}
+	}
+}
+
+func TestTypeMap_MapSelf(t *testing.T) {
+	ast, err := declparse.NewStringParser(`- (instancetype)init;`).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dt := MethodFromAst(*ast.Method, false).Return
+	for lang, want := range map[string]string{"go": "NSWindow", "rust": "*mut NSWindow", "ts": "NSWindow"} {
+		m, err := DefaultTypeMap(lang)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, err := m.MapSelf(dt, "NSWindow"); err != nil || got != want {
+			t.Errorf("%s: exp=%s got=%s err=%v", lang, want, got, err)
+		}
+	}
+}
+
+func TestTypeMap_Unmapped(t *testing.T) {
+	m, err := DefaultTypeMap("go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Class{InstanceProperties: []Property{
+		{Name: "a", Type: propertyType(t, `@property FSRef a;`)},
+		{Name: "b", Type: propertyType(t, `@property va_list *b;`)},
+		{Name: "c", Type: propertyType(t, `@property NSString *c;`)},
+		{Name: "d", Type: propertyType(t, `@property NSWindowStyleMask d;`)},
+	}}
+	m.AddSchema(Schema{Enum: &Enum{Identifier: Identifier{Name: "NSWindowStyleMask"}}})
+	if diff := deep.Equal(m.Unmapped([]Schema{{Class: c}}), []string{"FSRef"}); diff != nil {
+		t.Error(diff)
+	}
+	// va_list is an object to the map, as any unknown type behind a pointer
+	if got, _ := m.Map(c.InstanceProperties[1].Type); got != "Object" {
+		t.Errorf("va_list *: got=%s", got)
+	}
+}
+
+func TestTypeMap_Load(t *testing.T) {
+	dir, err := ioutil.TempDir("", "typemap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	yamlFile := filepath.Join(dir, "types.yaml")
+	jsonFile := filepath.Join(dir, "types.json")
+	ioutil.WriteFile(yamlFile, []byte("types:\n  NSInteger: isize\naliases:\n  FSRef: long\n"), 0644)
+	ioutil.WriteFile(jsonFile, []byte(`{"types": {"BOOL": "bool"}, "array": "[{elem}]"}`), 0644)
+
+	m, err := DefaultTypeMap("rust")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []string{yamlFile, jsonFile} {
+		if err := m.Load(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for decl, want := range map[string]string{
+		`@property NSInteger a;`:       "isize",
+		`@property FSRef a;`:           "c_long",
+		`@property BOOL a;`:            "bool",
+		`@property CGFloat a[4];`:      "[CGFloat]",
+		`@property NSUInteger a;`:      "NSUInteger",
+		`@property NSString *a;`:       "*mut NSString",
+		`@property unsigned int a[2];`: "[c_uint]",
+	} {
+		if got, err := m.Map(propertyType(t, decl)); err != nil || got != want {
+			t.Errorf("%s: exp=%s got=%s err=%v", decl, want, got, err)
+		}
+	}
+}
+
+func TestDataType_String(t *testing.T) {
+	for decl, want := range map[string]string{
+		`@property NSArray<NSString *> *a;`:             "NSArray<NSString *> *",
+		`@property NSError **a;`:                        "NSError **",
+		`@property const char *a;`:                      "const char *",
+		`@property unsigned long long a;`:               "unsigned long long",
+		`@property id<NSCopying> a;`:                    "id<NSCopying>",
+		`@property CGFloat a[4];`:                       "CGFloat[4]",
+		`@property void (^a)(NSUInteger idx, BOOL *s);`: "void (^)(NSUInteger, BOOL *)",
+	} {
+		if got := propertyType(t, decl).String(); got != want {
+			t.Errorf("%s: exp=%s got=%s", decl, want, got)
+		}
+	}
+}
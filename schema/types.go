@@ -1,11 +1,15 @@
 package schema
 
 import (
+	"fmt"
+	"math/big"
+	"strings"
 	"time"
 )
 
 type Schema struct {
 	Class     *Class     `json:",omitempty"`
+	Protocol  *Class     `json:",omitempty"`
 	Function  *Func      `json:",omitempty"`
 	Variable  *Variable  `json:",omitempty"`
 	Enum      *Enum      `json:",omitempty"`
@@ -19,6 +23,29 @@ type Schema struct {
 	Version  int
 }
 
+// Identifier returns the identifier of the declaration held by the schema.
+func (s Schema) Identifier() Identifier {
+	switch {
+	case s.Class != nil:
+		return s.Class.Identifier
+	case s.Protocol != nil:
+		return s.Protocol.Identifier
+	case s.Function != nil:
+		return s.Function.Identifier
+	case s.Variable != nil:
+		return s.Variable.Identifier
+	case s.Enum != nil:
+		return s.Enum.Identifier
+	case s.Struct != nil:
+		return s.Struct.Identifier
+	case s.TypeAlias != nil:
+		return s.TypeAlias.Identifier
+	case s.APICollection != nil:
+		return s.APICollection.Identifier
+	}
+	return Identifier{}
+}
+
 type Identifier struct {
 	Name        string `json:",omitempty"`
 	Description string `json:",omitempty"`
@@ -27,13 +54,27 @@ type Identifier struct {
 	Frameworks []string `json:",omitempty"`
 	Platforms  []string `json:",omitempty"`
 
+	// Availability is parsed from the platforms of the topic, with
+	// Deprecated.
+	Availability []Availability `json:",omitempty"`
+
 	Deprecated bool   `json:",omitempty"`
 	TopicURL   string `json:",omitempty"`
+
+	// SwiftName is the name of the declaration in Swift, given by
+	// NS_SWIFT_NAME or found merging with the Swift schema.
+	SwiftName string `json:",omitempty"`
 }
 
+// Class is a class, or in the Protocol field of a Schema a protocol.
 type Class struct {
 	Identifier
 
+	// Superclass is the class a class inherits from. Protocols are the
+	// protocols a class adopts or a protocol inherits from.
+	Superclass string   `json:",omitempty"`
+	Protocols  []string `json:",omitempty"`
+
 	InstanceMethods    []Method   `json:",omitempty"`
 	InstanceProperties []Property `json:",omitempty"`
 
@@ -48,6 +89,12 @@ type APICollection struct {
 	Enums     []Enum `json:",omitempty"`
 }
 
+// DataType is a named type, or with Kind set a pointer to or array of
+// Elem or a Swift tuple of Params. A pointer's own qualifiers, like the
+// const in char *const, are in Qualifiers. The outermost DataType of a
+// pointer or array type also has the fields of the named type at the
+// bottom, with IsPtr and IsPtrPtr set for one or two levels of pointers
+// and the annotations of all levels.
 type DataType struct {
 	Name        string     `json:",omitempty"`
 	IsPtr       bool       `json:",omitempty"`
@@ -56,6 +103,62 @@ type DataType struct {
 	FuncPtr     *Func      `json:",omitempty"`
 	Block       *Func      `json:",omitempty"`
 	Params      []DataType `json:",omitempty"`
+
+	Kind       string    `json:",omitempty"` // pointer, array or tuple
+	Elem       *DataType `json:",omitempty"`
+	Len        string    `json:",omitempty"` // array length, empty for []
+	Qualifiers []string  `json:",omitempty"`
+
+	// Nullability is set for pointer types when converting from the AST.
+	Nullability Nullability `json:",omitempty"`
+}
+
+// String returns the type in C syntax, as in NSArray<NSString *> *.
+func (dt DataType) String() string {
+	switch {
+	case dt.Kind == "pointer":
+		elem := dt.Elem.String()
+		if strings.HasSuffix(elem, "*") {
+			return elem + "*"
+		}
+		return elem + " *"
+	case dt.Kind == "array":
+		return dt.Elem.String() + "[" + dt.Len + "]"
+	case dt.Kind == "tuple":
+		return "(" + typeList(dt.Params) + ")"
+	case dt.Block != nil:
+		return dt.Block.signature("^")
+	case dt.FuncPtr != nil:
+		return dt.FuncPtr.signature("*")
+	}
+	name := dt.CName()
+	if len(dt.Params) > 0 {
+		name += "<" + typeList(dt.Params) + ">"
+	}
+	for _, q := range dt.Annotations {
+		if q == "const" {
+			return "const " + name
+		}
+	}
+	return name
+}
+
+func typeList(types []DataType) string {
+	var s []string
+	for _, t := range types {
+		s = append(s, t.String())
+	}
+	return strings.Join(s, ", ")
+}
+
+// signature returns the type of a block or function pointer, with op as
+// the operator between the parentheses.
+func (fn Func) signature(op string) string {
+	var args []DataType
+	for _, arg := range fn.Args {
+		args = append(args, arg.Type)
+	}
+	return fmt.Sprintf("%s (%s)(%s)", fn.Return, op, typeList(args))
 }
 
 type Func struct {
@@ -63,6 +166,22 @@ type Func struct {
 
 	Return DataType
 	Args   []Arg
+
+	// Escaping is set for blocks that may be called after the function or
+	// method they are passed to returns, which is any block not marked
+	// NS_NOESCAPE.
+	Escaping bool `json:",omitempty"`
+
+	// Throws and Async are set for Swift functions that throw, or
+	// rethrow, errors and are asynchronous.
+	Throws bool `json:",omitempty"`
+	Async  bool `json:",omitempty"`
+
+	// TypeEncoding is the runtime type encoding of a block's signature.
+	TypeEncoding string `json:",omitempty"`
+
+	// Swift is the Swift view of the function in a merged schema.
+	Swift *Func `json:",omitempty"`
 }
 
 type Arg struct {
@@ -75,6 +194,14 @@ type Variable struct {
 
 	Type  DataType
 	Value string `json:",omitempty"`
+
+	// IntValue or FloatValue hold the exact value of Value when it is a
+	// constant expression.
+	IntValue   *big.Int `json:",omitempty"`
+	FloatValue *float64 `json:",omitempty"`
+
+	// Swift is the Swift view of the variable in a merged schema.
+	Swift *Variable `json:",omitempty"`
 }
 
 type Enum struct {
@@ -84,6 +211,19 @@ type Enum struct {
 	Cases []Variable
 }
 
+// IsOptions returns true for enums whose cases are bit flags: those
+// declared with NS_OPTIONS or CF_OPTIONS, or without a declaration that
+// tells, named like options, as in NSWindowStyleMask.
+func (en Enum) IsOptions() bool {
+	switch {
+	case strings.Contains(en.Declaration, "_OPTIONS("):
+		return true
+	case strings.Contains(en.Declaration, "_ENUM("):
+		return false
+	}
+	return strings.HasSuffix(en.Name, "Options") || strings.HasSuffix(en.Name, "Mask")
+}
+
 type Struct struct {
 	Identifier
 
@@ -106,6 +246,17 @@ type Property struct {
 	IsOutlet    bool   `json:",omitempty"`
 	Deprecated  bool   `json:",omitempty"`
 	TopicURL    string `json:",omitempty"`
+	SwiftName   string `json:",omitempty"`
+
+	// Availability is parsed from the platforms of the topic.
+	Availability []Availability `json:",omitempty"`
+
+	// TypeEncoding is the attribute string of the property, as in
+	// T@"NSString",C,N.
+	TypeEncoding string `json:",omitempty"`
+
+	// Swift is the Swift view of the property in a merged schema.
+	Swift *Property `json:",omitempty"`
 }
 
 type Method struct {
@@ -114,8 +265,32 @@ type Method struct {
 	Declaration string
 	Return      DataType
 	Args        []Arg
+	Throws      bool   `json:",omitempty"`
+	Async       bool   `json:",omitempty"`
 	Deprecated  bool   `json:",omitempty"`
 	TopicURL    string `json:",omitempty"`
+	SwiftName   string `json:",omitempty"`
+
+	// Availability is parsed from the platforms of the topic.
+	Availability []Availability `json:",omitempty"`
+
+	// TypeEncoding is the runtime type encoding of the method, as in
+	// v@:@q.
+	TypeEncoding string `json:",omitempty"`
+
+	// MsgSend is the objc_msgSend variant that sends the message on the
+	// architecture of the type encoding, as in objc_msgSend_stret.
+	MsgSend string `json:",omitempty"`
+
+	// Swift is the Swift view of the method in a merged schema, with
+	// its Swift signature.
+	Swift *Method `json:",omitempty"`
+}
+
+// ArgCount returns the number of arguments the method takes, one for each
+// colon in its selector.
+func (m Method) ArgCount() int {
+	return strings.Count(m.Name, ":")
 }
 
 type Topic struct {
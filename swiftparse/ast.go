@@ -0,0 +1,233 @@
+package swiftparse
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Statement is a Swift declaration with its attributes and modifiers. One
+// of the declaration fields is set.
+type Statement struct {
+	Attrs     []Attr   `json:",omitempty"`
+	Modifiers []string `json:",omitempty"` // as in class, static or unowned(unsafe)
+
+	Type      *TypeDecl      `json:",omitempty"`
+	Func      *FuncDecl      `json:",omitempty"`
+	Var       *VarDecl       `json:",omitempty"`
+	Cases     []CaseDecl     `json:",omitempty"`
+	TypeAlias *TypeAliasDecl `json:",omitempty"`
+}
+
+// Attr returns the attribute named name, as in MainActor for @MainActor.
+func (s Statement) Attr(name string) (Attr, bool) {
+	for _, attr := range s.Attrs {
+		if attr.Name == name {
+			return attr, true
+		}
+	}
+	return Attr{}, false
+}
+
+// HasModifier returns true if the declaration has the modifier.
+func (s Statement) HasModifier(modifier string) bool {
+	for _, m := range s.Modifiers {
+		if m == modifier {
+			return true
+		}
+	}
+	return false
+}
+
+// Attr is an attribute like @MainActor or @available(macOS 10.15, *).
+// Args is the text between the parentheses.
+type Attr struct {
+	Name string
+	Args string `json:",omitempty"`
+}
+
+// TypeDecl declares a class, struct, enum, protocol or actor, or extends a
+// type. Members are the declarations in its body, if it has one.
+type TypeDecl struct {
+	Kind       string        // class, struct, enum, protocol, actor or extension
+	Name       string        // maybe qualified, as in NSWindow.StyleMask
+	TypeParams []TypeParam   `json:",omitempty"`
+	Inherits   []Type        `json:",omitempty"`
+	Where      []Requirement `json:",omitempty"`
+	Members    []Statement   `json:",omitempty"`
+}
+
+// TypeParam is a generic parameter with an optional constraint, as in
+// Element : Hashable.
+type TypeParam struct {
+	Name       string
+	Constraint *Type `json:",omitempty"`
+}
+
+// Requirement is a requirement of a where clause, a conformance as in
+// T : Equatable or with Same set a same-type requirement as in
+// T.Element == Int.
+type Requirement struct {
+	Name string
+	Same bool `json:",omitempty"`
+	Type Type
+}
+
+// FuncDecl declares a function or method. Initializers and subscripts are
+// named init and subscript. A failable initializer has Failable set to ?
+// or, for init!, to !. Subscripts have Accessors like properties.
+type FuncDecl struct {
+	Name       string
+	Failable   string        `json:",omitempty"`
+	TypeParams []TypeParam   `json:",omitempty"`
+	Params     []Param       `json:",omitempty"`
+	Async      bool          `json:",omitempty"`
+	Throws     bool          `json:",omitempty"`
+	Rethrows   bool          `json:",omitempty"`
+	Result     *Type         `json:",omitempty"`
+	Where      []Requirement `json:",omitempty"`
+	Accessors  []string      `json:",omitempty"`
+}
+
+// FullName returns the name with the argument labels, which is how Swift
+// refers to functions, as in orderFront(_:) and init(coder:).
+func (f FuncDecl) FullName() string {
+	b := &strings.Builder{}
+	b.WriteString(f.Name + "(")
+	for _, p := range f.Params {
+		b.WriteString(p.argLabel(f.Name == "subscript" || !isWord(f.Name)) + ":")
+	}
+	b.WriteString(")")
+	return b.String()
+}
+
+// Param is a parameter of a function, or an element of a tuple or function
+// type. Label is set when the argument label differs from Name, and is _
+// for arguments without a label.
+type Param struct {
+	Label    string `json:",omitempty"`
+	Name     string `json:",omitempty"`
+	Type     Type
+	Variadic bool   `json:",omitempty"`
+	Default  string `json:",omitempty"`
+}
+
+// argLabel returns the argument label of a parameter, or _ for none. The
+// parameters of subscripts and operators only have explicit labels.
+func (p Param) argLabel(explicit bool) string {
+	switch {
+	case p.Label != "":
+		return p.Label
+	case explicit || p.Name == "":
+		return "_"
+	}
+	return p.Name
+}
+
+// VarDecl declares a variable or, with Let set, a constant. Accessors are
+// the accessors of a property requirement or computed property, like get
+// and set, with any effects as in get async.
+type VarDecl struct {
+	Let       bool `json:",omitempty"`
+	Name      string
+	Type      *Type    `json:",omitempty"`
+	Value     string   `json:",omitempty"`
+	Accessors []string `json:",omitempty"`
+}
+
+// CaseDecl is an enum case with its associated values or raw value.
+type CaseDecl struct {
+	Name   string
+	Params []Param `json:",omitempty"`
+	Value  string  `json:",omitempty"`
+}
+
+type TypeAliasDecl struct {
+	Name       string
+	TypeParams []TypeParam `json:",omitempty"`
+	Type       Type
+}
+
+// TypeKind is the kind of a Type.
+type TypeKind int
+
+const (
+	TypeNamed       TypeKind = iota
+	TypeOptional             // Elem?
+	TypeUnwrapped            // Elem!, implicitly unwrapped
+	TypeArray                // [Elem]
+	TypeDictionary           // [Key: Elem]
+	TypeTuple                // (Args)
+	TypeFunction             // (Args) -> Elem
+	TypeMetatype             // Elem.Type or Elem.Protocol, by Name
+	TypeComposition          // Params joined by &
+)
+
+var typeKinds = map[TypeKind]string{
+	TypeNamed:       "named",
+	TypeOptional:    "optional",
+	TypeUnwrapped:   "unwrapped",
+	TypeArray:       "array",
+	TypeDictionary:  "dictionary",
+	TypeTuple:       "tuple",
+	TypeFunction:    "function",
+	TypeMetatype:    "metatype",
+	TypeComposition: "composition",
+}
+
+func (k TypeKind) String() string {
+	return typeKinds[k]
+}
+
+func (k TypeKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+func (k *TypeKind) UnmarshalText(b []byte) error {
+	for kind, s := range typeKinds {
+		if s == string(b) {
+			*k = kind
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown type kind %q", b)
+}
+
+// Type is a named type with its generic arguments, or with Kind set a type
+// built from others: Elem is the wrapped, element, value, result or
+// instance type, Key a dictionary key type and Args the elements of a
+// tuple or parameters of a function type. Attrs and Specifier apply to
+// the type as a whole, as in @escaping () -> Void and inout Int.
+type Type struct {
+	Name   string `json:",omitempty"`
+	Params []Type `json:",omitempty"`
+
+	Kind TypeKind `json:",omitempty"`
+	Elem *Type    `json:",omitempty"`
+	Key  *Type    `json:",omitempty"`
+	Args []Param  `json:",omitempty"`
+
+	Attrs     []Attr `json:",omitempty"`
+	Specifier string `json:",omitempty"` // inout, some, any, ...
+	Async     bool   `json:",omitempty"`
+	Throws    bool   `json:",omitempty"`
+}
+
+// HasAttr returns true if the type has the attribute, as in escaping for
+// @escaping.
+func (t Type) HasAttr(name string) bool {
+	for _, attr := range t.Attrs {
+		if attr.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Unwrapped returns the type wrapped by an optional or implicitly
+// unwrapped optional, or the type itself.
+func (t Type) Unwrapped() Type {
+	for t.Kind == TypeOptional || t.Kind == TypeUnwrapped {
+		t = *t.Elem
+	}
+	return t
+}
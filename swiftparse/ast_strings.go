@@ -0,0 +1,244 @@
+package swiftparse
+
+import (
+	"strings"
+)
+
+func (s Statement) String() string {
+	b := &strings.Builder{}
+	for _, attr := range s.Attrs {
+		b.WriteString(attr.String() + " ")
+	}
+	for _, m := range s.Modifiers {
+		b.WriteString(m + " ")
+	}
+	switch {
+	case s.Type != nil:
+		b.WriteString(s.Type.String())
+	case s.Func != nil:
+		b.WriteString(s.Func.String())
+	case s.Var != nil:
+		b.WriteString(s.Var.String())
+	case len(s.Cases) > 0:
+		var cases []string
+		for _, c := range s.Cases {
+			cases = append(cases, c.String())
+		}
+		b.WriteString("case " + strings.Join(cases, ", "))
+	case s.TypeAlias != nil:
+		b.WriteString(s.TypeAlias.String())
+	}
+	return b.String()
+}
+
+func (a Attr) String() string {
+	if a.Args == "" {
+		return "@" + a.Name
+	}
+	return "@" + a.Name + "(" + a.Args + ")"
+}
+
+func (t TypeDecl) String() string {
+	b := &strings.Builder{}
+	b.WriteString(t.Kind + " " + t.Name + typeParams(t.TypeParams))
+	if len(t.Inherits) > 0 {
+		var types []string
+		for _, typ := range t.Inherits {
+			types = append(types, typ.String())
+		}
+		b.WriteString(" : " + strings.Join(types, ", "))
+	}
+	b.WriteString(where(t.Where))
+	if len(t.Members) > 0 {
+		b.WriteString(" {")
+		for _, m := range t.Members {
+			b.WriteString("\n    " + strings.ReplaceAll(m.String(), "\n", "\n    "))
+		}
+		b.WriteString("\n}")
+	}
+	return b.String()
+}
+
+func typeParams(params []TypeParam) string {
+	if len(params) == 0 {
+		return ""
+	}
+	var p []string
+	for _, param := range params {
+		if param.Constraint != nil {
+			p = append(p, param.Name+" : "+param.Constraint.String())
+		} else {
+			p = append(p, param.Name)
+		}
+	}
+	return "<" + strings.Join(p, ", ") + ">"
+}
+
+func where(reqs []Requirement) string {
+	if len(reqs) == 0 {
+		return ""
+	}
+	var r []string
+	for _, req := range reqs {
+		op := " : "
+		if req.Same {
+			op = " == "
+		}
+		r = append(r, req.Name+op+req.Type.String())
+	}
+	return " where " + strings.Join(r, ", ")
+}
+
+func (f FuncDecl) String() string {
+	b := &strings.Builder{}
+	if f.Name != "init" && f.Name != "subscript" {
+		b.WriteString("func ")
+	}
+	b.WriteString(f.Name + f.Failable)
+	if !isWord(f.Name) {
+		// operators are apart from their parameters
+		b.WriteString(" ")
+	}
+	b.WriteString(typeParams(f.TypeParams))
+	b.WriteString("(" + params(f.Params) + ")")
+	if f.Async {
+		b.WriteString(" async")
+	}
+	if f.Throws {
+		b.WriteString(" throws")
+	}
+	if f.Rethrows {
+		b.WriteString(" rethrows")
+	}
+	if f.Result != nil {
+		b.WriteString(" -> " + f.Result.String())
+	}
+	b.WriteString(where(f.Where))
+	b.WriteString(accessors(f.Accessors))
+	return b.String()
+}
+
+func params(params []Param) string {
+	var p []string
+	for _, param := range params {
+		p = append(p, param.String())
+	}
+	return strings.Join(p, ", ")
+}
+
+func accessors(accessors []string) string {
+	if len(accessors) == 0 {
+		return ""
+	}
+	return " { " + strings.Join(accessors, " ") + " }"
+}
+
+func (p Param) String() string {
+	b := &strings.Builder{}
+	if p.Label != "" {
+		b.WriteString(p.Label + " ")
+	}
+	if p.Name != "" {
+		b.WriteString(p.Name + ": ")
+	}
+	b.WriteString(p.Type.String())
+	if p.Variadic {
+		b.WriteString("...")
+	}
+	if p.Default != "" {
+		b.WriteString(" = " + p.Default)
+	}
+	return b.String()
+}
+
+func (v VarDecl) String() string {
+	b := &strings.Builder{}
+	if v.Let {
+		b.WriteString("let ")
+	} else {
+		b.WriteString("var ")
+	}
+	b.WriteString(v.Name)
+	if v.Type != nil {
+		b.WriteString(": " + v.Type.String())
+	}
+	if v.Value != "" {
+		b.WriteString(" = " + v.Value)
+	}
+	b.WriteString(accessors(v.Accessors))
+	return b.String()
+}
+
+func (c CaseDecl) String() string {
+	s := c.Name
+	if len(c.Params) > 0 {
+		s += "(" + params(c.Params) + ")"
+	}
+	if c.Value != "" {
+		s += " = " + c.Value
+	}
+	return s
+}
+
+func (t TypeAliasDecl) String() string {
+	return "typealias " + t.Name + typeParams(t.TypeParams) + " = " + t.Type.String()
+}
+
+func (t Type) String() string {
+	b := &strings.Builder{}
+	for _, attr := range t.Attrs {
+		b.WriteString(attr.String() + " ")
+	}
+	if t.Specifier != "" {
+		b.WriteString(t.Specifier + " ")
+	}
+
+	switch t.Kind {
+	case TypeNamed:
+		b.WriteString(t.Name)
+		if len(t.Params) > 0 {
+			var p []string
+			for _, param := range t.Params {
+				p = append(p, param.String())
+			}
+			b.WriteString("<" + strings.Join(p, ", ") + ">")
+		}
+	case TypeOptional:
+		b.WriteString(t.Elem.operand() + "?")
+	case TypeUnwrapped:
+		b.WriteString(t.Elem.operand() + "!")
+	case TypeArray:
+		b.WriteString("[" + t.Elem.String() + "]")
+	case TypeDictionary:
+		b.WriteString("[" + t.Key.String() + ": " + t.Elem.String() + "]")
+	case TypeTuple:
+		b.WriteString("(" + params(t.Args) + ")")
+	case TypeFunction:
+		b.WriteString("(" + params(t.Args) + ")")
+		if t.Async {
+			b.WriteString(" async")
+		}
+		if t.Throws {
+			b.WriteString(" throws")
+		}
+		b.WriteString(" -> " + t.Elem.String())
+	case TypeMetatype:
+		b.WriteString(t.Elem.operand() + "." + t.Name)
+	case TypeComposition:
+		var p []string
+		for _, param := range t.Params {
+			p = append(p, param.operand())
+		}
+		b.WriteString(strings.Join(p, " & "))
+	}
+	return b.String()
+}
+
+// operand returns the type in parentheses if it would not bind to a
+// postfix like ? or an operator like &, as in ((Int) -> Void)?.
+func (t Type) operand() string {
+	if len(t.Attrs) > 0 || t.Specifier != "" || t.Kind == TypeFunction || t.Kind == TypeComposition {
+		return "(" + t.String() + ")"
+	}
+	return t.String()
+}
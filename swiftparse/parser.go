@@ -0,0 +1,368 @@
+package swiftparse
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/progrium/macschema/lexer"
+)
+
+// Parser parses Swift declarations, as shown in the documentation or
+// written in interface files, using the Objective-C lexer. Operators are
+// scanned one rune at a time so that nested generics like Set<Set<Int>>
+// close, which means -> is scanned as - and > and == as = and =.
+type Parser struct {
+	tb *lexer.TokenBuffer
+}
+
+func NewParser(r io.Reader) *Parser {
+	tb := lexer.NewTokenBuffer(r)
+	tb.IgnoreWhitespace = true
+	tb.IgnoreComments = true
+	tb.OneRuneOperators(true)
+	return &Parser{tb: tb}
+}
+
+func NewStringParser(s string) *Parser {
+	return NewParser(bytes.NewBufferString(s))
+}
+
+// Parse parses the next declaration. It returns io.EOF when there are no
+// declarations left.
+func (p *Parser) Parse() (*Statement, error) {
+	p.skipEmpty()
+	if tok, _, _ := p.tb.Peek(); tok == lexer.EOF {
+		return nil, io.EOF
+	}
+	return p.parseStatement()
+}
+
+// skipEmpty skips semicolons and compiler directives like #if, which
+// don't declare anything.
+func (p *Parser) skipEmpty() {
+	for {
+		tok, _, _ := p.tb.Scan()
+		if tok != lexer.SEMICOLON && tok != lexer.DIRECTIVE {
+			p.tb.Unscan()
+			return
+		}
+	}
+}
+
+func (p *Parser) parseStatement() (*Statement, error) {
+	stmt := &Statement{}
+
+	var err error
+	if stmt.Attrs, err = p.parseAttrs(); err != nil {
+		return nil, err
+	}
+	if stmt.Modifiers, err = p.parseModifiers(); err != nil {
+		return nil, err
+	}
+
+	keyword, pos, ok := p.word()
+	if !ok {
+		tok, pos, lit := p.tb.Scan()
+		return nil, fmt.Errorf("unable to parse start token: %s %s at %v", tok, lit, pos)
+	}
+	switch keyword {
+	case "class", "struct", "enum", "protocol", "actor", "extension":
+		stmt.Type, err = p.parseTypeDecl(keyword)
+	case "func", "init", "subscript":
+		stmt.Func, err = p.parseFunc(keyword)
+	case "var", "let":
+		stmt.Var, err = p.parseVar(keyword == "let")
+	case "case":
+		stmt.Cases, err = p.parseCases()
+	case "typealias":
+		stmt.TypeAlias, err = p.parseTypeAlias()
+	default:
+		return nil, fmt.Errorf("unable to parse start token: %s at %v", keyword, pos)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if tok, _, _ := p.tb.Scan(); tok != lexer.SEMICOLON {
+		p.tb.Unscan()
+	}
+	return stmt, nil
+}
+
+// parseAttrs parses attributes like @MainActor and @available(...).
+func (p *Parser) parseAttrs() (attrs []Attr, err error) {
+	for {
+		if tok, _, _ := p.tb.Scan(); tok != lexer.ATSIGN {
+			p.tb.Unscan()
+			return attrs, nil
+		}
+		var attr Attr
+		name, pos, ok := p.word()
+		if !ok {
+			tok, pos, lit := p.tb.Scan()
+			return nil, fmt.Errorf("found %s (%q), expected attribute name at %v", tok, lit, pos)
+		}
+		attr.Name = name
+		// arguments follow the name directly, unlike the parameters of a
+		// function type as in @Sendable ()
+		end := lexer.Pos{Line: pos.Line, Char: pos.Char + utf8.RuneCountInString(name)}
+		if tok, pos, _ := p.tb.Scan(); tok == lexer.LPAREN && pos == end {
+			if attr.Args, err = p.scanExpr(false, lexer.RPAREN); err != nil {
+				return nil, err
+			}
+			if err := p.expectToken(lexer.RPAREN); err != nil {
+				return nil, err
+			}
+		} else {
+			p.tb.Unscan()
+		}
+		attrs = append(attrs, attr)
+	}
+}
+
+// modifiers are the declaration modifiers. Those in parentheses take an
+// argument, as in unowned(unsafe) and private(set).
+var modifiers = map[string]bool{
+	"open": true, "public": true, "package": true, "internal": true, "fileprivate": true, "private": true,
+	"class": true, "static": true, "final": true, "required": true, "convenience": true, "override": true,
+	"optional": true, "dynamic": true, "lazy": true, "mutating": true, "nonmutating": true,
+	"weak": true, "unowned": true, "nonisolated": true, "indirect": true, "distributed": true,
+	"prefix": true, "postfix": true, "infix": true,
+}
+
+// declKeywords start the declarations that modifiers apply to.
+var declKeywords = map[string]bool{
+	"class": true, "struct": true, "enum": true, "protocol": true, "actor": true, "extension": true,
+	"func": true, "init": true, "subscript": true, "var": true, "let": true, "case": true, "typealias": true,
+}
+
+func (p *Parser) parseModifiers() (mods []string, err error) {
+	for {
+		m, _, ok := p.word()
+		if !ok {
+			return mods, nil
+		}
+		if !modifiers[m] {
+			p.tb.Unscan()
+			return mods, nil
+		}
+		if m == "class" {
+			// class is a modifier of class members
+			if next := p.peekWord(); !declKeywords[next] || next == "class" {
+				p.tb.Unscan()
+				return mods, nil
+			}
+		}
+		if tok, _, _ := p.tb.Scan(); tok == lexer.LPAREN {
+			arg, err := p.expectWord()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectToken(lexer.RPAREN); err != nil {
+				return nil, err
+			}
+			m += "(" + arg + ")"
+		} else {
+			p.tb.Unscan()
+		}
+		mods = append(mods, m)
+	}
+}
+
+// word scans an identifier or keyword, or leaves the next token if it
+// isn't one. Swift keywords are identifiers to the lexer, but some are C
+// keywords, like static, and some Swift names are, like register.
+// Identifiers in backticks keep them.
+func (p *Parser) word() (string, lexer.Pos, bool) {
+	tok, pos, lit := p.tb.Scan()
+	switch {
+	case tok == lexer.IDENT && isIdent(lit):
+		return lit, pos, true
+	case tok.IsKeyword() && !strings.HasPrefix(tok.String(), "@"), tok == lexer.TRUE, tok == lexer.FALSE:
+		return tok.String(), pos, true
+	case tok == lexer.ILLEGAL && lit == "`":
+		if name, _, ok := p.word(); ok {
+			if tok, _, lit := p.tb.Scan(); tok == lexer.ILLEGAL && lit == "`" {
+				return "`" + name + "`", pos, true
+			}
+			p.tb.Unscan()
+			p.tb.Unscan()
+		}
+	}
+	p.tb.Unscan()
+	return "", pos, false
+}
+
+func (p *Parser) expectWord() (string, error) {
+	w, _, ok := p.word()
+	if !ok {
+		tok, pos, lit := p.tb.Scan()
+		return "", fmt.Errorf("found %s (%q), expected identifier at %v", tok, lit, pos)
+	}
+	return w, nil
+}
+
+// expectName scans a name that may be qualified, as in NSWindow.StyleMask.
+func (p *Parser) expectName() (string, error) {
+	name, err := p.expectWord()
+	if err != nil {
+		return "", err
+	}
+	for {
+		if tok, _, _ := p.tb.Scan(); tok != lexer.DOT {
+			p.tb.Unscan()
+			return name, nil
+		}
+		next, err := p.expectWord()
+		if err != nil {
+			return "", err
+		}
+		name += "." + next
+	}
+}
+
+// peekWord returns the next word without scanning it.
+func (p *Parser) peekWord() string {
+	w, _, ok := p.word()
+	if ok {
+		p.tb.Unscan()
+	}
+	return w
+}
+
+// isWord scans the next word if it is w.
+func (p *Parser) isWord(w string) bool {
+	next, _, ok := p.word()
+	if ok && next == w {
+		return true
+	}
+	if ok {
+		p.tb.Unscan()
+	}
+	return false
+}
+
+func (p *Parser) expectToken(t lexer.Token) error {
+	tok, pos, lit := p.tb.Scan()
+	if tok != t {
+		return fmt.Errorf("found %s (%q), expected token %s at %v", tok, lit, t, pos)
+	}
+	return nil
+}
+
+// isArrow scans the next tokens if they are ->.
+func (p *Parser) isArrow() bool {
+	if tok, _, _ := p.tb.Scan(); tok != lexer.MINUS {
+		p.tb.Unscan()
+		return false
+	}
+	if tok, _, _ := p.tb.Scan(); tok != lexer.GT {
+		p.tb.Unscan()
+		p.tb.Unscan()
+		return false
+	}
+	return true
+}
+
+// scanExpr returns the text of an expression, like a default argument or
+// the arguments of an attribute, up to one of the stop tokens outside of
+// brackets. Tokens are separated by a space where the source had space
+// between them. With lines set the expression also ends at a new line, as
+// a statement does.
+func (p *Parser) scanExpr(lines bool, stop ...lexer.Token) (string, error) {
+	b := &strings.Builder{}
+	var end lexer.Pos
+	var depth int
+	for first := true; ; first = false {
+		tok, pos, lit := p.tb.Scan()
+		if tok == lexer.EOF || tok == lexer.SEMICOLON || depth <= 0 && isToken(tok, stop...) ||
+			depth < 0 || lines && !first && depth == 0 && pos.Line != end.Line {
+			p.tb.Unscan()
+			break
+		}
+		switch tok {
+		case lexer.ILLEGAL, lexer.BADSTRING, lexer.BADESCAPE, lexer.DIRECTIVE:
+			if tok != lexer.ILLEGAL || lit != "`" {
+				return "", fmt.Errorf("found %s (%q) in expression at %v", tok, lit, pos)
+			}
+		case lexer.LPAREN, lexer.LBRACKET, lexer.LCURLY:
+			depth++
+		case lexer.RPAREN, lexer.RBRACKET, lexer.RCURLY:
+			if depth--; depth < 0 {
+				p.tb.Unscan()
+				return b.String(), nil
+			}
+		}
+		text := tokenText(tok, lit)
+		if !first && pos != end {
+			b.WriteString(" ")
+		}
+		end = lexer.Pos{Line: pos.Line, Char: pos.Char + utf8.RuneCountInString(text)}
+		b.WriteString(text)
+	}
+	return b.String(), nil
+}
+
+// skipBody skips a body in braces after its opening brace.
+func (p *Parser) skipBody() error {
+	for depth := 1; depth > 0; {
+		switch tok, pos, _ := p.tb.Scan(); tok {
+		case lexer.EOF:
+			return fmt.Errorf("found EOF, expected } at %v", pos)
+		case lexer.LCURLY:
+			depth++
+		case lexer.RCURLY:
+			depth--
+		}
+	}
+	return nil
+}
+
+func tokenText(tok lexer.Token, lit string) string {
+	switch tok {
+	case lexer.STRING:
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(lit) + `"`
+	case lexer.CHAR:
+		return "'" + lit + "'"
+	case lexer.ATSTRING:
+		return `@"` + lit + `"`
+	}
+	if lit == "" {
+		return tok.String()
+	}
+	return lit
+}
+
+func isToken(tok lexer.Token, toks ...lexer.Token) bool {
+	for _, t := range toks {
+		if tok == t {
+			return true
+		}
+	}
+	return false
+}
+
+// isIdent reports whether s is an identifier. The scanner also produces
+// IDENT tokens for quoted names, which are not valid in declarations.
+func isIdent(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, ch := range s {
+		if ch == '_' || unicode.IsLetter(ch) || i > 0 && unicode.IsDigit(ch) {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// isWord returns true if s is an identifier, possibly in backticks, rather
+// than an operator.
+func isWord(s string) bool {
+	return isIdent(strings.Trim(s, "`"))
+}
@@ -0,0 +1,94 @@
+package swiftparse
+
+import (
+	"fmt"
+
+	"github.com/progrium/macschema/lexer"
+)
+
+// parseFunc parses a function, initializer or subscript after its keyword.
+// A body is skipped.
+func (p *Parser) parseFunc(keyword string) (fn *FuncDecl, err error) {
+	fn = &FuncDecl{Name: keyword}
+	switch keyword {
+	case "func":
+		if fn.Name, err = p.expectFuncName(); err != nil {
+			return nil, err
+		}
+	case "init":
+		switch tok, _, _ := p.tb.Scan(); tok {
+		case lexer.QUESTION:
+			fn.Failable = "?"
+		case lexer.NOT:
+			fn.Failable = "!"
+		default:
+			p.tb.Unscan()
+		}
+	}
+
+	if isWord(fn.Name) {
+		if fn.TypeParams, err = p.parseTypeParams(); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expectToken(lexer.LPAREN); err != nil {
+		return nil, err
+	}
+	if fn.Params, err = p.expectParams(true); err != nil {
+		return nil, err
+	}
+
+	fn.Async = p.isWord("async")
+	switch {
+	case p.isWord("throws"):
+		fn.Throws = true
+	case p.isWord("rethrows"):
+		fn.Rethrows = true
+	}
+	if p.isArrow() {
+		if fn.Result, err = p.expectType(); err != nil {
+			return nil, err
+		}
+	} else if keyword == "subscript" {
+		tok, pos, lit := p.tb.Scan()
+		return nil, fmt.Errorf("found %s (%q), expected -> after subscript parameters at %v", tok, lit, pos)
+	}
+	if fn.Where, err = p.parseWhere(); err != nil {
+		return nil, err
+	}
+
+	if keyword == "subscript" {
+		if fn.Accessors, err = p.parseAccessors(); err != nil {
+			return nil, err
+		}
+		return fn, nil
+	}
+	if tok, _, _ := p.tb.Scan(); tok == lexer.LCURLY {
+		if err := p.skipBody(); err != nil {
+			return nil, err
+		}
+	} else {
+		p.tb.Unscan()
+	}
+	return fn, nil
+}
+
+// expectFuncName scans the name of a function, which may be an operator
+// like ==.
+func (p *Parser) expectFuncName() (string, error) {
+	if name, _, ok := p.word(); ok {
+		return name, nil
+	}
+	var name string
+	for {
+		tok, pos, lit := p.tb.Scan()
+		if tok == lexer.LPAREN && name != "" {
+			p.tb.Unscan()
+			return name, nil
+		}
+		if !tok.IsOperator() && tok != lexer.QUESTION && tok != lexer.DOT {
+			return "", fmt.Errorf("found %s (%q), expected function name at %v", tok, lit, pos)
+		}
+		name += tok.String()
+	}
+}
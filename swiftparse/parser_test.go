@@ -0,0 +1,442 @@
+package swiftparse
+
+import (
+	"io"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+var tests = []struct {
+	s string
+	n *Statement
+}{
+	{
+		s: `@MainActor class NSWindow : NSResponder`,
+		n: &Statement{
+			Attrs: []Attr{{Name: "MainActor"}},
+			Type: &TypeDecl{
+				Kind:     "class",
+				Name:     "NSWindow",
+				Inherits: []Type{{Name: "NSResponder"}},
+			},
+		},
+	},
+
+	{
+		s: `enum NSWindow.BackingStoreType : UInt, @unchecked Sendable`,
+		n: &Statement{
+			Type: &TypeDecl{
+				Kind: "enum",
+				Name: "NSWindow.BackingStoreType",
+				Inherits: []Type{
+					{Name: "UInt"},
+					{Name: "Sendable", Attrs: []Attr{{Name: "unchecked"}}},
+				},
+			},
+		},
+	},
+
+	{
+		s: `func orderFront(_ sender: Any?)`,
+		n: &Statement{
+			Func: &FuncDecl{
+				Name: "orderFront",
+				Params: []Param{
+					{Label: "_", Name: "sender", Type: Type{Kind: TypeOptional, Elem: &Type{Name: "Any"}}},
+				},
+			},
+		},
+	},
+
+	{
+		s: `convenience init?(contentRect: NSRect, styleMask style: NSWindow.StyleMask)`,
+		n: &Statement{
+			Modifiers: []string{"convenience"},
+			Func: &FuncDecl{
+				Name:     "init",
+				Failable: "?",
+				Params: []Param{
+					{Name: "contentRect", Type: Type{Name: "NSRect"}},
+					{Label: "styleMask", Name: "style", Type: Type{Name: "NSWindow.StyleMask"}},
+				},
+			},
+		},
+	},
+
+	{
+		s: `class func windowNumbers(options: NSWindow.NumberListOptions = []) -> [NSNumber]?`,
+		n: &Statement{
+			Modifiers: []string{"class"},
+			Func: &FuncDecl{
+				Name: "windowNumbers",
+				Params: []Param{
+					{Name: "options", Type: Type{Name: "NSWindow.NumberListOptions"}, Default: "[]"},
+				},
+				Result: &Type{Kind: TypeOptional, Elem: &Type{Kind: TypeArray, Elem: &Type{Name: "NSNumber"}}},
+			},
+		},
+	},
+
+	{
+		s: `func beginSheet(_ sheetWindow: NSWindow, completionHandler handler: ((NSApplication.ModalResponse) -> Void)? = nil)`,
+		n: &Statement{
+			Func: &FuncDecl{
+				Name: "beginSheet",
+				Params: []Param{
+					{Label: "_", Name: "sheetWindow", Type: Type{Name: "NSWindow"}},
+					{
+						Label: "completionHandler",
+						Name:  "handler",
+						Type: Type{
+							Kind: TypeOptional,
+							Elem: &Type{
+								Kind: TypeFunction,
+								Args: []Param{{Type: Type{Name: "NSApplication.ModalResponse"}}},
+								Elem: &Type{Name: "Void"},
+							},
+						},
+						Default: "nil",
+					},
+				},
+			},
+		},
+	},
+
+	{
+		s: `func data(from url: URL, delegate: (any URLSessionTaskDelegate)? = nil) async throws -> (Data, URLResponse)`,
+		n: &Statement{
+			Func: &FuncDecl{
+				Name: "data",
+				Params: []Param{
+					{Label: "from", Name: "url", Type: Type{Name: "URL"}},
+					{
+						Name:    "delegate",
+						Type:    Type{Kind: TypeOptional, Elem: &Type{Name: "URLSessionTaskDelegate", Specifier: "any"}},
+						Default: "nil",
+					},
+				},
+				Async:  true,
+				Throws: true,
+				Result: &Type{Kind: TypeTuple, Args: []Param{{Type: Type{Name: "Data"}}, {Type: Type{Name: "URLResponse"}}}},
+			},
+		},
+	},
+
+	{
+		s: `func sorted<T : Comparable>(_ values: T...) rethrows -> [T] where T.Element == Int`,
+		n: &Statement{
+			Func: &FuncDecl{
+				Name:       "sorted",
+				TypeParams: []TypeParam{{Name: "T", Constraint: &Type{Name: "Comparable"}}},
+				Params:     []Param{{Label: "_", Name: "values", Type: Type{Name: "T"}, Variadic: true}},
+				Rethrows:   true,
+				Result:     &Type{Kind: TypeArray, Elem: &Type{Name: "T"}},
+				Where:      []Requirement{{Name: "T.Element", Same: true, Type: Type{Name: "Int"}}},
+			},
+		},
+	},
+
+	{
+		s: `func perform(_ block: @escaping @Sendable () -> Void, values: inout [String: Any])`,
+		n: &Statement{
+			Func: &FuncDecl{
+				Name: "perform",
+				Params: []Param{
+					{
+						Label: "_",
+						Name:  "block",
+						Type: Type{
+							Kind:  TypeFunction,
+							Elem:  &Type{Name: "Void"},
+							Attrs: []Attr{{Name: "escaping"}, {Name: "Sendable"}},
+						},
+					},
+					{
+						Name: "values",
+						Type: Type{
+							Kind:      TypeDictionary,
+							Key:       &Type{Name: "String"},
+							Elem:      &Type{Name: "Any"},
+							Specifier: "inout",
+						},
+					},
+				},
+			},
+		},
+	},
+
+	{
+		s: `static func == (lhs: NSWindow.StyleMask, rhs: NSWindow.StyleMask) -> Bool`,
+		n: &Statement{
+			Modifiers: []string{"static"},
+			Func: &FuncDecl{
+				Name: "==",
+				Params: []Param{
+					{Name: "lhs", Type: Type{Name: "NSWindow.StyleMask"}},
+					{Name: "rhs", Type: Type{Name: "NSWindow.StyleMask"}},
+				},
+				Result: &Type{Name: "Bool"},
+			},
+		},
+	},
+
+	{
+		s: `subscript(key: String) -> Any? { get set }`,
+		n: &Statement{
+			Func: &FuncDecl{
+				Name:      "subscript",
+				Params:    []Param{{Name: "key", Type: Type{Name: "String"}}},
+				Result:    &Type{Kind: TypeOptional, Elem: &Type{Name: "Any"}},
+				Accessors: []string{"get", "set"},
+			},
+		},
+	},
+
+	{
+		s: `@IBOutlet unowned(unsafe) var delegate: NSWindowDelegate? { get set }`,
+		n: &Statement{
+			Attrs:     []Attr{{Name: "IBOutlet"}},
+			Modifiers: []string{"unowned(unsafe)"},
+			Var: &VarDecl{
+				Name:      "delegate",
+				Type:      &Type{Kind: TypeOptional, Elem: &Type{Name: "NSWindowDelegate"}},
+				Accessors: []string{"get", "set"},
+			},
+		},
+	},
+
+	{
+		s: `static let zero = CGPoint(x: 0, y: 0)`,
+		n: &Statement{
+			Modifiers: []string{"static"},
+			Var: &VarDecl{
+				Let:   true,
+				Name:  "zero",
+				Value: "CGPoint(x: 0, y: 0)",
+			},
+		},
+	},
+
+	{
+		s: `var windowClass: AnyClass.Type { get async throws }`,
+		n: &Statement{
+			Var: &VarDecl{
+				Name:      "windowClass",
+				Type:      &Type{Kind: TypeMetatype, Name: "Type", Elem: &Type{Name: "AnyClass"}},
+				Accessors: []string{"get async throws"},
+			},
+		},
+	},
+
+	{
+		s: `case buffered = 2`,
+		n: &Statement{
+			Cases: []CaseDecl{{Name: "buffered", Value: "2"}},
+		},
+	},
+
+	{
+		s: `typealias NSWindow.PersistableFrameDescriptor = String`,
+		n: &Statement{
+			TypeAlias: &TypeAliasDecl{
+				Name: "NSWindow.PersistableFrameDescriptor",
+				Type: Type{Name: "String"},
+			},
+		},
+	},
+
+	{
+		s: `func NSApplicationMain(_ argc: Int32, _ argv: UnsafeMutablePointer<UnsafeMutablePointer<CChar>?>) -> Int32`,
+		n: &Statement{
+			Func: &FuncDecl{
+				Name: "NSApplicationMain",
+				Params: []Param{
+					{Label: "_", Name: "argc", Type: Type{Name: "Int32"}},
+					{
+						Label: "_",
+						Name:  "argv",
+						Type: Type{
+							Name: "UnsafeMutablePointer",
+							Params: []Type{{
+								Kind: TypeOptional,
+								Elem: &Type{Name: "UnsafeMutablePointer", Params: []Type{{Name: "CChar"}}},
+							}},
+						},
+					},
+				},
+				Result: &Type{Name: "Int32"},
+			},
+		},
+	},
+
+	{
+		s: "enum Shape : Equatable {\n    case circle(radius: Double), square(Double)\n    indirect case group([Shape])\n    static var unit: Shape { get }\n}",
+		n: &Statement{
+			Type: &TypeDecl{
+				Kind:     "enum",
+				Name:     "Shape",
+				Inherits: []Type{{Name: "Equatable"}},
+				Members: []Statement{
+					{Cases: []CaseDecl{
+						{Name: "circle", Params: []Param{{Name: "radius", Type: Type{Name: "Double"}}}},
+						{Name: "square", Params: []Param{{Type: Type{Name: "Double"}}}},
+					}},
+					{Modifiers: []string{"indirect"}, Cases: []CaseDecl{
+						{Name: "group", Params: []Param{{Type: Type{Kind: TypeArray, Elem: &Type{Name: "Shape"}}}}},
+					}},
+					{Modifiers: []string{"static"}, Var: &VarDecl{Name: "unit", Type: &Type{Name: "Shape"}, Accessors: []string{"get"}}},
+				},
+			},
+		},
+	},
+}
+
+func TestParser(t *testing.T) {
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, err := NewStringParser(tt.s).Parse()
+			if err != nil {
+				t.Fatal("parse:", err)
+			}
+			if diff := deep.Equal(got, tt.n); diff != nil {
+				t.Error("diff:", diff)
+			}
+			if s := got.String(); s != tt.s {
+				t.Errorf("string: got %q", s)
+			}
+		})
+	}
+}
+
+// roundTrips only need to survive a round trip through String().
+var roundTrips = []string{
+	`@available(macOS, introduced: 10.0, deprecated: 11.0, message: "Use \"x\" instead") func old()`,
+	`func makeView() -> some View & Equatable`,
+	`func handle(_ handler: @escaping (inout [String: Any], Int) throws -> (any NSCopying)?)`,
+	`var backgroundColor: NSColor! { get set }`,
+	`var observers: [NSKeyValueObservation] = [] { willSet didSet }`,
+	`protocol NSWindowDelegate : NSObjectProtocol`,
+	`optional func windowShouldClose(_ sender: NSWindow) -> Bool`,
+	`struct Pair<Key : Hashable, Value> where Value : Equatable`,
+	`typealias Handler<T> = (Result<T, Error>) -> Void`,
+	`var types: [AnyObject.Type: (NSObject & NSCopying).Protocol] { get }`,
+	`var ` + "`default`" + `: NotificationCenter { get }`,
+	`func register(_ nib: NSNib?, forIdentifier identifier: NSUserInterfaceItemIdentifier)`,
+}
+
+func TestParser_RoundTrip(t *testing.T) {
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) { testRoundTrip(t, tt.s) })
+	}
+	for _, s := range roundTrips {
+		t.Run(s, func(t *testing.T) { testRoundTrip(t, s) })
+	}
+}
+
+func FuzzRoundTrip(f *testing.F) {
+	for _, tt := range tests {
+		f.Add(tt.s)
+	}
+	for _, s := range roundTrips {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		stmt, err := NewStringParser(s).Parse()
+		if err != nil {
+			return
+		}
+		testRoundTrip(t, stmt.String())
+	})
+}
+
+// Parse(s).String() must parse back to the same AST.
+func testRoundTrip(t *testing.T, s string) {
+	stmt, err := NewStringParser(s).Parse()
+	if err != nil {
+		t.Fatal("parse:", err)
+	}
+	again, err := NewStringParser(stmt.String()).Parse()
+	if err != nil {
+		t.Fatalf("parse %q: %v", stmt.String(), err)
+	}
+	if diff := deep.Equal(again, stmt); diff != nil {
+		t.Errorf("%q: diff: %v", stmt.String(), diff)
+	}
+}
+
+func TestParser_Declarations(t *testing.T) {
+	src := `#if os(macOS)
+struct CGPoint {
+    var x: CGFloat
+    var y: CGFloat = 0
+    init()
+    func applying(_ t: CGAffineTransform) -> CGPoint {
+        return CGPoint(x: x * t.a, y: y * t.d)
+    }
+}
+#endif
+let NSAppKitVersionNumber: NSAppKitVersion; func NSBeep()
+`
+	p := NewStringParser(src)
+	var got []string
+	for {
+		stmt, err := p.Parse()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, stmt.String())
+	}
+	want := []string{
+		"struct CGPoint {\n    var x: CGFloat\n    var y: CGFloat = 0\n    init()\n    func applying(_ t: CGAffineTransform) -> CGPoint\n}",
+		"let NSAppKitVersionNumber: NSAppKitVersion",
+		"func NSBeep()",
+	}
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestFuncDecl_FullName(t *testing.T) {
+	tests := map[string]string{
+		`func orderFront(_ sender: Any?)`:                                   "orderFront(_:)",
+		`init(contentRect: NSRect, styleMask style: NSWindow.StyleMask)`:    "init(contentRect:styleMask:)",
+		`func makeKeyAndOrderFront()`:                                       "makeKeyAndOrderFront()",
+		`subscript(key: String, default value: Any) -> Any { get }`:         "subscript(_:default:)",
+		`static func == (lhs: NSWindow.StyleMask, rhs: NSWindow.StyleMask)`: "==(_:_:)",
+		`func data(from url: URL, delegate: URLSessionTaskDelegate?) async`: "data(from:delegate:)",
+	}
+	for s, want := range tests {
+		stmt, err := NewStringParser(s).Parse()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := stmt.Func.FullName(); got != want {
+			t.Errorf("%s: got %s, want %s", s, got, want)
+		}
+	}
+}
+
+// Parsing arbitrary input must return an error rather than panic, and
+// whatever parses must render.
+func FuzzParser(f *testing.F) {
+	for _, tt := range tests {
+		f.Add(tt.s)
+	}
+	for _, s := range roundTrips {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		p := NewStringParser(s)
+		for {
+			stmt, err := p.Parse()
+			if err != nil {
+				return
+			}
+			_ = stmt.String()
+		}
+	})
+}
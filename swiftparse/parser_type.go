@@ -0,0 +1,312 @@
+package swiftparse
+
+import (
+	"fmt"
+
+	"github.com/progrium/macschema/lexer"
+)
+
+// specifiers come before a type and apply to all of it.
+var specifiers = map[string]bool{
+	"inout": true, "some": true, "any": true, "borrowing": true, "consuming": true,
+	"sending": true, "isolated": true, "__owned": true, "__shared": true,
+}
+
+// expectType parses a type: attributes and a specifier, then a composition
+// of types with their postfixes, as in @escaping (Int) -> Void, any P & Q
+// and [String: Any]?.
+func (p *Parser) expectType() (*Type, error) {
+	attrs, err := p.parseAttrs()
+	if err != nil {
+		return nil, err
+	}
+	var specifier string
+	if w := p.peekWord(); specifiers[w] {
+		specifier, _ = p.expectWord()
+	}
+
+	t, err := p.expectPostfixType()
+	if err != nil {
+		return nil, err
+	}
+	if tok, _, _ := p.tb.Peek(); tok == lexer.AMPERSAND {
+		t = &Type{Kind: TypeComposition, Params: []Type{*t}}
+		for {
+			if tok, _, _ := p.tb.Scan(); tok != lexer.AMPERSAND {
+				p.tb.Unscan()
+				break
+			}
+			member, err := p.expectPostfixType()
+			if err != nil {
+				return nil, err
+			}
+			t.Params = append(t.Params, *member)
+		}
+	}
+
+	// a type in parentheses may have its own, as in inout (@escaping () -> Void)
+	t.Attrs = append(attrs, t.Attrs...)
+	if specifier != "" {
+		if t.Specifier != "" {
+			return nil, fmt.Errorf("found %s, expected one specifier for %s", specifier, t)
+		}
+		t.Specifier = specifier
+	}
+	return t, nil
+}
+
+// expectPostfixType parses a type with its postfixes: ? and ! for optionals
+// and .Type and .Protocol for metatypes.
+func (p *Parser) expectPostfixType() (*Type, error) {
+	t, err := p.expectPrimaryType()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok, _, _ := p.tb.Scan()
+		switch tok {
+		case lexer.QUESTION:
+			t = &Type{Kind: TypeOptional, Elem: t}
+		case lexer.NOT:
+			t = &Type{Kind: TypeUnwrapped, Elem: t}
+		case lexer.DOT:
+			name, err := p.expectWord()
+			if err != nil {
+				return nil, err
+			}
+			if name != "Type" && name != "Protocol" {
+				return nil, fmt.Errorf("found %q, expected Type or Protocol after %s", name, t)
+			}
+			t = &Type{Kind: TypeMetatype, Name: name, Elem: t}
+		default:
+			p.tb.Unscan()
+			return t, nil
+		}
+	}
+}
+
+// expectPrimaryType parses a named type, an array or dictionary type, or a
+// type in parentheses, which is a tuple or function type.
+func (p *Parser) expectPrimaryType() (*Type, error) {
+	tok, pos, lit := p.tb.Scan()
+	switch tok {
+	case lexer.LBRACKET:
+		elem, err := p.expectType()
+		if err != nil {
+			return nil, err
+		}
+		t := &Type{Kind: TypeArray, Elem: elem}
+		if tok, _, _ := p.tb.Scan(); tok == lexer.COLON {
+			value, err := p.expectType()
+			if err != nil {
+				return nil, err
+			}
+			t = &Type{Kind: TypeDictionary, Key: elem, Elem: value}
+		} else {
+			p.tb.Unscan()
+		}
+		if err := p.expectToken(lexer.RBRACKET); err != nil {
+			return nil, err
+		}
+		return t, nil
+
+	case lexer.LPAREN:
+		args, err := p.expectParams(false)
+		if err != nil {
+			return nil, err
+		}
+		t := &Type{Kind: TypeFunction, Args: args}
+		t.Async = p.isWord("async")
+		t.Throws = p.isWord("throws")
+		if p.isArrow() {
+			if t.Elem, err = p.expectType(); err != nil {
+				return nil, err
+			}
+			return t, nil
+		}
+		if t.Async || t.Throws {
+			return nil, fmt.Errorf("expected -> after function type %s at %v", params(args), pos)
+		}
+		if len(args) == 1 && args[0].Name == "" && args[0].Label == "" && !args[0].Variadic && args[0].Default == "" {
+			// a type in parentheses is that type
+			return &args[0].Type, nil
+		}
+		return &Type{Kind: TypeTuple, Args: args}, nil
+	}
+
+	p.tb.Unscan()
+	name, err := p.expectWord()
+	if err != nil {
+		return nil, fmt.Errorf("found %s (%q), expected type at %v", tok, lit, pos)
+	}
+	t := &Type{Name: name}
+	for {
+		// qualified names, but not metatypes
+		if tok, _, _ := p.tb.Scan(); tok != lexer.DOT {
+			p.tb.Unscan()
+			break
+		}
+		if next := p.peekWord(); next == "" || next == "Type" || next == "Protocol" {
+			p.tb.Unscan()
+			break
+		}
+		next, _ := p.expectWord()
+		t.Name += "." + next
+	}
+	if tok, _, _ := p.tb.Scan(); tok == lexer.LT {
+		for {
+			param, err := p.expectType()
+			if err != nil {
+				return nil, err
+			}
+			t.Params = append(t.Params, *param)
+			if tok, _, _ := p.tb.Scan(); tok != lexer.COMMA {
+				p.tb.Unscan()
+				break
+			}
+		}
+		if err := p.expectToken(lexer.GT); err != nil {
+			return nil, err
+		}
+	} else {
+		p.tb.Unscan()
+	}
+	return t, nil
+}
+
+// expectParams parses parameters after their opening parenthesis, up to
+// and including the closing one. Declared parameters have a name and may
+// have a label and default, while those of function types may have only a
+// type.
+func (p *Parser) expectParams(declared bool) (params []Param, err error) {
+	if tok, _, _ := p.tb.Scan(); tok == lexer.RPAREN {
+		return nil, nil
+	}
+	p.tb.Unscan()
+
+	for {
+		var param Param
+		if param.Label, param.Name = p.paramNames(); param.Name == "" && declared {
+			tok, pos, lit := p.tb.Scan()
+			return nil, fmt.Errorf("found %s (%q), expected parameter name at %v", tok, lit, pos)
+		}
+		typ, err := p.expectType()
+		if err != nil {
+			return nil, err
+		}
+		param.Type = *typ
+		if tok, _, _ := p.tb.Scan(); tok == lexer.VARARG {
+			param.Variadic = true
+		} else {
+			p.tb.Unscan()
+		}
+		if tok, _, _ := p.tb.Scan(); tok == lexer.EQ {
+			if param.Default, err = p.scanExpr(false, lexer.COMMA, lexer.RPAREN); err != nil {
+				return nil, err
+			}
+		} else {
+			p.tb.Unscan()
+		}
+		params = append(params, param)
+
+		if tok, _, _ := p.tb.Scan(); tok != lexer.COMMA {
+			p.tb.Unscan()
+			break
+		}
+	}
+
+	if err := p.expectToken(lexer.RPAREN); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// paramNames scans the label and name of a parameter, which come before a
+// colon, if there are any.
+func (p *Parser) paramNames() (label, name string) {
+	first, _, ok := p.word()
+	if !ok {
+		return "", ""
+	}
+	if tok, _, _ := p.tb.Scan(); tok == lexer.COLON {
+		return "", first
+	}
+	p.tb.Unscan()
+	second, _, ok := p.word()
+	if !ok {
+		p.tb.Unscan()
+		return "", ""
+	}
+	if tok, _, _ := p.tb.Scan(); tok == lexer.COLON {
+		return first, second
+	}
+	p.tb.Unscan()
+	p.tb.Unscan()
+	p.tb.Unscan()
+	return "", ""
+}
+
+// parseTypeParams parses generic parameters in angle brackets, if there
+// are any: <Key : Hashable, Value>.
+func (p *Parser) parseTypeParams() (params []TypeParam, err error) {
+	if tok, _, _ := p.tb.Scan(); tok != lexer.LT {
+		p.tb.Unscan()
+		return nil, nil
+	}
+	for {
+		var param TypeParam
+		if param.Name, err = p.expectWord(); err != nil {
+			return nil, err
+		}
+		if tok, _, _ := p.tb.Scan(); tok == lexer.COLON {
+			if param.Constraint, err = p.expectType(); err != nil {
+				return nil, err
+			}
+		} else {
+			p.tb.Unscan()
+		}
+		params = append(params, param)
+		if tok, _, _ := p.tb.Scan(); tok != lexer.COMMA {
+			p.tb.Unscan()
+			break
+		}
+	}
+	if err := p.expectToken(lexer.GT); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// parseWhere parses a where clause, if there is one.
+func (p *Parser) parseWhere() (reqs []Requirement, err error) {
+	if !p.isWord("where") {
+		return nil, nil
+	}
+	for {
+		var req Requirement
+		if req.Name, err = p.expectName(); err != nil {
+			return nil, err
+		}
+		switch tok, pos, lit := p.tb.Scan(); tok {
+		case lexer.COLON:
+		case lexer.EQ:
+			if err := p.expectToken(lexer.EQ); err != nil {
+				return nil, err
+			}
+			req.Same = true
+		default:
+			return nil, fmt.Errorf("found %s (%q), expected : or == in requirement at %v", tok, lit, pos)
+		}
+		typ, err := p.expectType()
+		if err != nil {
+			return nil, err
+		}
+		req.Type = *typ
+		reqs = append(reqs, req)
+		if tok, _, _ := p.tb.Scan(); tok != lexer.COMMA {
+			p.tb.Unscan()
+			break
+		}
+	}
+	return reqs, nil
+}
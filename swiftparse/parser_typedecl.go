@@ -0,0 +1,78 @@
+package swiftparse
+
+import (
+	"fmt"
+
+	"github.com/progrium/macschema/lexer"
+)
+
+// parseTypeDecl parses a class, struct, enum, protocol, actor or extension
+// after its keyword, with the declarations in its body.
+func (p *Parser) parseTypeDecl(kind string) (t *TypeDecl, err error) {
+	t = &TypeDecl{Kind: kind}
+	if t.Name, err = p.expectName(); err != nil {
+		return nil, err
+	}
+	if t.TypeParams, err = p.parseTypeParams(); err != nil {
+		return nil, err
+	}
+
+	if tok, _, _ := p.tb.Scan(); tok == lexer.COLON {
+		for {
+			typ, err := p.expectType()
+			if err != nil {
+				return nil, err
+			}
+			t.Inherits = append(t.Inherits, *typ)
+			if tok, _, _ := p.tb.Scan(); tok != lexer.COMMA {
+				p.tb.Unscan()
+				break
+			}
+		}
+	} else {
+		p.tb.Unscan()
+	}
+	if t.Where, err = p.parseWhere(); err != nil {
+		return nil, err
+	}
+
+	if tok, _, _ := p.tb.Scan(); tok != lexer.LCURLY {
+		p.tb.Unscan()
+		return t, nil
+	}
+	for {
+		p.skipEmpty()
+		switch tok, pos, _ := p.tb.Scan(); tok {
+		case lexer.RCURLY:
+			return t, nil
+		case lexer.EOF:
+			return nil, fmt.Errorf("found EOF, expected } of %s at %v", t.Name, pos)
+		}
+		p.tb.Unscan()
+		member, err := p.parseStatement()
+		if err != nil {
+			return nil, err
+		}
+		t.Members = append(t.Members, *member)
+	}
+}
+
+// parseTypeAlias parses a type alias after typealias.
+func (p *Parser) parseTypeAlias() (ta *TypeAliasDecl, err error) {
+	ta = &TypeAliasDecl{}
+	if ta.Name, err = p.expectName(); err != nil {
+		return nil, err
+	}
+	if ta.TypeParams, err = p.parseTypeParams(); err != nil {
+		return nil, err
+	}
+	if err := p.expectToken(lexer.EQ); err != nil {
+		return nil, err
+	}
+	typ, err := p.expectType()
+	if err != nil {
+		return nil, err
+	}
+	ta.Type = *typ
+	return ta, nil
+}
@@ -0,0 +1,123 @@
+package swiftparse
+
+import (
+	"fmt"
+
+	"github.com/progrium/macschema/lexer"
+)
+
+// parseVar parses a variable or constant after var or let.
+func (p *Parser) parseVar(let bool) (v *VarDecl, err error) {
+	v = &VarDecl{Let: let}
+	if v.Name, err = p.expectWord(); err != nil {
+		return nil, err
+	}
+	if tok, _, _ := p.tb.Scan(); tok == lexer.COLON {
+		if v.Type, err = p.expectType(); err != nil {
+			return nil, err
+		}
+	} else {
+		p.tb.Unscan()
+	}
+	if tok, _, _ := p.tb.Scan(); tok == lexer.EQ {
+		if v.Value, err = p.scanExpr(true, lexer.RCURLY); err != nil {
+			return nil, err
+		}
+		if v.Value == "" {
+			return nil, fmt.Errorf("expected value of %s", v.Name)
+		}
+	} else {
+		p.tb.Unscan()
+	}
+	if v.Type == nil && v.Value == "" {
+		tok, pos, lit := p.tb.Scan()
+		return nil, fmt.Errorf("found %s (%q), expected type or value of %s at %v", tok, lit, v.Name, pos)
+	}
+	if v.Accessors, err = p.parseAccessors(); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// accessorKeywords start an accessor, which may have effects or a body.
+var accessorKeywords = map[string]bool{
+	"get": true, "set": true, "willSet": true, "didSet": true,
+	"_read": true, "_modify": true, "read": true, "modify": true, "init": true,
+}
+
+// parseAccessors parses a block of accessors, if there is one, as in
+// { get set } and { get async throws }. A block with just a getter body is
+// a get accessor.
+func (p *Parser) parseAccessors() (accessors []string, err error) {
+	if tok, _, _ := p.tb.Scan(); tok != lexer.LCURLY {
+		p.tb.Unscan()
+		return nil, nil
+	}
+	var modifier string
+	for {
+		if tok, _, _ := p.tb.Scan(); tok == lexer.RCURLY {
+			return accessors, nil
+		} else if tok == lexer.LCURLY {
+			if len(accessors) == 0 {
+				return nil, fmt.Errorf("found {, expected accessor")
+			}
+			if err := p.skipBody(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		p.tb.Unscan()
+
+		w, pos, ok := p.word()
+		switch {
+		case ok && (w == "mutating" || w == "nonmutating") && modifier == "":
+			modifier = w + " "
+		case ok && accessorKeywords[w]:
+			accessors = append(accessors, modifier+w)
+			modifier = ""
+		case ok && (w == "async" || w == "throws") && len(accessors) > 0 && modifier == "":
+			accessors[len(accessors)-1] += " " + w
+		case len(accessors) == 0 && modifier == "":
+			// a getter body
+			if err := p.skipBody(); err != nil {
+				return nil, err
+			}
+			return []string{"get"}, nil
+		default:
+			tok, _, lit := p.tb.Scan()
+			return nil, fmt.Errorf("found %s (%q), expected accessor at %v", tok, lit, pos)
+		}
+	}
+}
+
+// parseCases parses enum cases after case.
+func (p *Parser) parseCases() (cases []CaseDecl, err error) {
+	for {
+		var c CaseDecl
+		if c.Name, err = p.expectWord(); err != nil {
+			return nil, err
+		}
+		if tok, _, _ := p.tb.Scan(); tok == lexer.LPAREN {
+			if c.Params, err = p.expectParams(false); err != nil {
+				return nil, err
+			}
+		} else {
+			p.tb.Unscan()
+		}
+		if tok, _, _ := p.tb.Scan(); tok == lexer.EQ {
+			if c.Value, err = p.scanExpr(true, lexer.COMMA, lexer.RCURLY); err != nil {
+				return nil, err
+			}
+			if c.Value == "" {
+				return nil, fmt.Errorf("expected value of case %s", c.Name)
+			}
+		} else {
+			p.tb.Unscan()
+		}
+		cases = append(cases, c)
+		if tok, _, _ := p.tb.Scan(); tok != lexer.COMMA {
+			p.tb.Unscan()
+			return cases, nil
+		}
+	}
+}
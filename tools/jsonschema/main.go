@@ -0,0 +1,35 @@
+// Command jsonschema writes the JSON Schemas of the files in api and doc
+// to a directory, as api.schema.json and doc.schema.json.
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/progrium/macschema/schema"
+)
+
+func main() {
+	dir := "."
+	if len(os.Args) > 1 {
+		dir = os.Args[1]
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Fatal(err)
+	}
+	for name, js := range map[string]*schema.JSONSchema{
+		"api.schema.json": schema.APIJSONSchema(),
+		"doc.schema.json": schema.DocJSONSchema(),
+	} {
+		b, err := json.MarshalIndent(js, "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, name), append(b, '\n'), 0644); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
@@ -6,7 +6,6 @@ import (
 	"os"
 	"strconv"
 
-	_ "github.com/progrium/macschema/declparse/keywords"
 	"github.com/progrium/macschema/lexer"
 )
 